@@ -0,0 +1,38 @@
+package main
+
+import (
+	"github.com/popodidi/log"
+
+	"github.com/haostudio/golinks/internal/analytics"
+	"github.com/haostudio/golinks/internal/analytics/kv"
+	"github.com/haostudio/golinks/internal/encoding"
+)
+
+// AnalyticsConfig defines the analytics config.
+type AnalyticsConfig struct {
+	Enabled    bool `conf:"default:true"`
+	BufferSize int  `conf:"default:1024"`
+	Kv         StoreConfig
+}
+
+func newAnalyticsStore(logger log.Logger,
+	conf AnalyticsConfig, enc encoding.Binary, traceEnabled bool) (
+	store analytics.Store, closeFunc func() error) {
+	if !conf.Enabled {
+		logger.Warn("analytics disabled")
+		return nil, func() error { return nil }
+	}
+	analyticsKv, kvClose := newStore(logger, conf.Kv, traceEnabled)
+	async := analytics.NewAsync(
+		kv.New(analyticsKv.In(analyticsNamespace), enc), conf.BufferSize,
+	)
+	closeFunc = func() error {
+		// flush the buffered hits before closing the kv store.
+		err := async.Close()
+		if err != nil {
+			return err
+		}
+		return kvClose()
+	}
+	return async, closeFunc
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/haostudio/golinks/internal/auth"
 	"github.com/haostudio/golinks/internal/auth/kv"
+	"github.com/haostudio/golinks/internal/auth/oidc"
 	"github.com/haostudio/golinks/internal/auth/traced"
 	"github.com/haostudio/golinks/internal/encoding"
 )
@@ -22,13 +23,31 @@ type AuthManagerConfig struct {
 		DefaultOrg string `conf:"default:_no_org_"`
 	}
 
+	// SSO configures the OpenID Connect single sign-on alongside the password
+	// login. The list values are comma separated.
+	SSO struct {
+		Enabled        bool `conf:"default:false"`
+		Issuer         string
+		ClientID       string
+		ClientSecret   string
+		RedirectURL    string // e.g. https://go.example.com/auth/sso/callback
+		Scopes         string // default to openid,email,profile
+		AllowedDomains string // allow all domains if empty
+		DomainOrgs     string // e.g. example.com=org
+		OrgClaim       string // ID token claim of the org
+		// accept the ID tokens without the email_verified claim
+		AllowMissingEmailVerified bool `conf:"default:false"`
+	}
+
 	Type string `conf:"default:kv"`
 	Kv   StoreConfig
 }
 
+// newAuthManager returns the auth manager. The users can't register the
+// reservedOrgs, which are created with the "org create" command.
 func newAuthManager(logger log.Logger,
-	conf AuthManagerConfig, enc encoding.Binary, traceEnabled bool) (
-	manager *auth.Manager, closeFunc func() error) {
+	conf AuthManagerConfig, enc encoding.Binary, traceEnabled bool,
+	reservedOrgs []string) (manager *auth.Manager, closeFunc func() error) {
 	var provider auth.Provider
 	if conf.NoAuth.Enabled {
 		closeFunc = func() error { return nil }
@@ -47,10 +66,39 @@ func newAuthManager(logger log.Logger,
 		Provider:         provider,
 		TokenExpieration: time.Duration(conf.TokenExpieration) * 24 * time.Hour,
 		TokenSecret:      []byte(conf.TokenSecret),
+		ReservedOrgs:     reservedOrgs,
 	})
 	return
 }
 
+func newSSOClient(logger log.Logger, conf AuthManagerConfig) *oidc.Client {
+	if conf.NoAuth.Enabled || !conf.SSO.Enabled {
+		return nil
+	}
+	domainOrgs := make(map[string]string)
+	for _, pair := range splitList(conf.SSO.DomainOrgs) {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			logger.Critical("invalid sso domain org: %s", pair)
+			continue
+		}
+		domainOrgs[strings.ToLower(strings.TrimSpace(kv[0]))] =
+			strings.TrimSpace(kv[1])
+	}
+	return oidc.New(oidc.Config{
+		Issuer:         conf.SSO.Issuer,
+		ClientID:       conf.SSO.ClientID,
+		ClientSecret:   conf.SSO.ClientSecret,
+		RedirectURL:    conf.SSO.RedirectURL,
+		Scopes:         splitList(conf.SSO.Scopes),
+		AllowedDomains: splitList(conf.SSO.AllowedDomains),
+		DomainOrgs:     domainOrgs,
+		OrgClaim:       conf.SSO.OrgClaim,
+
+		AllowMissingEmailVerified: conf.SSO.AllowMissingEmailVerified,
+	})
+}
+
 func newKvAuthProvider(logger log.Logger,
 	conf StoreConfig, enc encoding.Binary, traceEnabled bool) (
 	auth.Provider, func() error) {
@@ -0,0 +1,549 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/popodidi/conf"
+	"github.com/popodidi/conf/source/env"
+	"github.com/popodidi/conf/source/yaml"
+	"github.com/popodidi/log"
+
+	"github.com/haostudio/golinks/internal/auth"
+	"github.com/haostudio/golinks/internal/encoding/gob"
+	"github.com/haostudio/golinks/internal/kv"
+	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/history"
+)
+
+// cliEditor is recorded as the editor of the links changed by the cli.
+const cliEditor = "golinks-cli"
+
+// errUsage is returned by commands called with bad arguments.
+var errUsage = errors.New("bad usage")
+
+// command defines an offline admin subcommand, which runs against the
+// configured stores while the server is stopped.
+type command struct {
+	args  string
+	usage string
+	run   func(c *cli, args []string) error
+}
+
+var commands = map[string]map[string]command{
+	"user": {
+		"create": {
+			args:  "<email> [org]",
+			usage: "create a user with the password read from stdin",
+			run:   userCreate,
+		},
+		"passwd": {
+			args:  "<email>",
+			usage: "reset the password of a user to the one read from stdin",
+			run:   userPasswd,
+		},
+		"delete": {
+			args:  "<email>",
+			usage: "delete a user",
+			run:   userDelete,
+		},
+		"list": {
+			usage: "list all users",
+			run:   userList,
+		},
+		"migrate-orgs": {
+			usage: "rewrite the users stored in the single org format",
+			run:   userMigrateOrgs,
+		},
+	},
+	"org": {
+		"create": {
+			args:  "<name> <admin_email>",
+			usage: "create an org with an existing user as admin",
+			run:   orgCreate,
+		},
+		"add-user": {
+			args:  "<name> <email>",
+			usage: "add an existing user to an org",
+			run:   orgAddUser,
+		},
+	},
+	"link": {
+		"get": {
+			args:  "<org> <key>",
+			usage: "show a link",
+			run:   linkGet,
+		},
+		"set": {
+			args:  "<org> <key> <version> <payload> [description]",
+			usage: "create or update a link",
+			run:   linkSet,
+		},
+		"delete": {
+			args:  "<org> <key>",
+			usage: "delete a link",
+			run:   linkDelete,
+		},
+		"list": {
+			args:  "<org>",
+			usage: "list all links of an org",
+			run:   linkList,
+		},
+	},
+	"kv": {
+		"dump": {
+			args:  "<auth|link|analytics> [namespace...]",
+			usage: "dump the keys and values in a namespace recursively",
+			run:   kvDump,
+		},
+		"migrate": {
+			usage: "migrate Migrate.Source store to Migrate.Destination store",
+			run:   kvMigrate,
+		},
+	},
+}
+
+// isCommand returns if name is a known subcommand.
+func isCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// runCommand runs the subcommand in args and returns the exit code.
+func runCommand(args []string) int {
+	group := commands[args[0]]
+	if len(args) < 2 {
+		printUsage(os.Stderr, args[0], group)
+		return 2
+	}
+	cmd, ok := group[args[1]]
+	if !ok {
+		printUsage(os.Stderr, args[0], group)
+		return 2
+	}
+
+	// Load config from env and yaml only since the flags are the command args.
+	var config Config
+	err := conf.New(&config).Load(env.New(), yaml.New("golinks_config.yaml"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config. err: %v\n", err)
+		return 1
+	}
+	closeLogger := configLogger(config.Log)
+	defer func() { _ = closeLogger() }()
+
+	c := &cli{
+		ctx:    history.WithEditor(context.Background(), cliEditor),
+		config: config,
+		logger: log.New("golinks-cli"),
+		in:     os.Stdin,
+		out:    os.Stdout,
+	}
+	defer c.close()
+
+	err = cmd.run(c, args[2:])
+	if errors.Is(err, errUsage) {
+		fmt.Fprintf(os.Stderr, "usage: golinks %s %s %s\n",
+			args[0], args[1], cmd.args)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "golinks %s %s: %v\n", args[0], args[1], err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage(w io.Writer, name string, group map[string]command) {
+	names := make([]string, 0, len(group))
+	for sub := range group {
+		names = append(names, sub)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(w, "usage: golinks %s <command>\n\n", name)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, sub := range names {
+		cmd := group[sub]
+		fmt.Fprintf(tw, "  %s %s\t%s\n", sub, cmd.args, cmd.usage)
+	}
+	_ = tw.Flush()
+}
+
+// cli defines the stores lazily opened by the commands.
+type cli struct {
+	ctx     context.Context
+	config  Config
+	logger  log.Logger
+	in      *os.File
+	out     io.Writer
+	closers []func() error
+}
+
+func (c *cli) close() {
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		err := c.closers[i]()
+		if err != nil {
+			c.logger.Warn("failed to close store. %v", err)
+		}
+	}
+}
+
+func (c *cli) authManager() (*auth.Manager, error) {
+	if c.config.AuthProvider.NoAuth.Enabled {
+		return nil, errors.New("auth is disabled in NoAuth mode")
+	}
+	// the operators may create the reserved orgs.
+	manager, closeFunc := newAuthManager(
+		c.logger, c.config.AuthProvider, gob.New(), false, nil,
+	)
+	c.closers = append(c.closers, closeFunc)
+	return manager, nil
+}
+
+func (c *cli) linkStore() link.Store {
+	stores, closeFunc := newLinkStore(
+		c.logger, c.config.LinkStore, gob.New(), false,
+	)
+	c.closers = append(c.closers, closeFunc)
+	return stores.Links
+}
+
+func (c *cli) kvStore(name string) (kv.Store, error) {
+	var storeConf StoreConfig
+	switch name {
+	case "auth":
+		storeConf = c.config.AuthProvider.Kv
+	case "link":
+		storeConf = c.config.LinkStore.Kv
+	case "analytics":
+		storeConf = c.config.Analytics.Kv
+	default:
+		return nil, errUsage
+	}
+	// read the canonical store without cache
+	storeConf.LRUCache = false
+	store, closeFunc := newStore(c.logger, storeConf, false)
+	c.closers = append(c.closers, closeFunc)
+	return store, nil
+}
+
+// readPassword reads the password from the first line of stdin, so that it's
+// not exposed in the command line. It's prompted for without echo if stdin is
+// a terminal.
+func (c *cli) readPassword() (string, error) {
+	info, err := c.in.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password: ")
+		// best effort; the password is echoed if stty is unavailable.
+		if c.stty("-echo") == nil {
+			defer func() {
+				_ = c.stty("echo")
+				fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+	line, err := bufio.NewReader(c.in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if len(password) == 0 {
+		return "", errors.New("empty password")
+	}
+	return password, nil
+}
+
+// stty sets the terminal of stdin with arg.
+func (c *cli) stty(arg string) error {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = c.in
+	return cmd.Run()
+}
+
+func userCreate(c *cli, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return errUsage
+	}
+	var org string
+	if len(args) == 2 {
+		org = args[1]
+	}
+	password, err := c.readPassword()
+	if err != nil {
+		return err
+	}
+	manager, err := c.authManager()
+	if err != nil {
+		return err
+	}
+	user, err := auth.NewUser(args[0], password, org)
+	if err != nil {
+		return err
+	}
+	return manager.RegisterUser(c.ctx, *user)
+}
+
+func userPasswd(c *cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	password, err := c.readPassword()
+	if err != nil {
+		return err
+	}
+	manager, err := c.authManager()
+	if err != nil {
+		return err
+	}
+	user, err := manager.GetUser(c.ctx, args[0])
+	if err != nil {
+		return err
+	}
+	err = user.SetPassword(password)
+	if err != nil {
+		return err
+	}
+	return manager.SetUser(c.ctx, user)
+}
+
+func userDelete(c *cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	manager, err := c.authManager()
+	if err != nil {
+		return err
+	}
+	user, err := manager.GetUser(c.ctx, args[0])
+	if err != nil {
+		return err
+	}
+	for _, name := range user.Orgs {
+		org, err := manager.GetOrg(c.ctx, name)
+		if err != nil && !errors.Is(err, auth.ErrNotFound) {
+			return err
+		}
+		if err == nil && org.AdminEmail == user.Email {
+			return fmt.Errorf("user is the admin of org %s", org.Name)
+		}
+	}
+	// revoke the api tokens, which would be valid again if the user registers
+	// again.
+	tokens, err := manager.GetAPITokens(c.ctx, user.Email)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		err = manager.DeleteAPIToken(c.ctx, token.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return manager.DeleteUser(c.ctx, user.Email)
+}
+
+func userList(c *cli, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	manager, err := c.authManager()
+	if err != nil {
+		return err
+	}
+	emails, err := manager.GetUsers(c.ctx)
+	if err != nil {
+		return err
+	}
+	sort.Strings(emails)
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	for _, email := range emails {
+		user, err := manager.GetUser(c.ctx, email)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", user.Email, strings.Join(user.Orgs, ","))
+	}
+	return tw.Flush()
+}
+
+func userMigrateOrgs(c *cli, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	manager, err := c.authManager()
+	if err != nil {
+		return err
+	}
+	migrated, err := manager.MigrateUsers(c.ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "migrated %d users\n", migrated)
+	return nil
+}
+
+func orgCreate(c *cli, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	manager, err := c.authManager()
+	if err != nil {
+		return err
+	}
+	return manager.RegisterOrg(c.ctx, auth.Organization{
+		Name:       args[0],
+		AdminEmail: args[1],
+	})
+}
+
+func orgAddUser(c *cli, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	manager, err := c.authManager()
+	if err != nil {
+		return err
+	}
+	return manager.AddUserOrg(c.ctx, args[1], args[0])
+}
+
+func linkGet(c *cli, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	ln, err := c.linkStore().GetLink(c.ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	desc, err := ln.Description()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "link:\t%s\n", desc)
+	fmt.Fprintf(tw, "description:\t%s\n", ln.Meta.Description)
+	fmt.Fprintf(tw, "tags:\t%s\n", strings.Join(ln.Meta.Tags, ", "))
+	fmt.Fprintf(tw, "created:\t%s %s\n", ln.Meta.CreatedBy,
+		formatTime(ln.Meta.CreatedAt))
+	fmt.Fprintf(tw, "updated:\t%s %s\n", ln.Meta.UpdatedBy,
+		formatTime(ln.Meta.UpdatedAt))
+	return tw.Flush()
+}
+
+func linkSet(c *cli, args []string) error {
+	if len(args) != 4 && len(args) != 5 {
+		return errUsage
+	}
+	org, key := args[0], args[1]
+	ver, err := strconv.Atoi(args[2])
+	if err != nil {
+		return errUsage
+	}
+	ln, err := link.New(ver, args[3])
+	if err != nil {
+		return err
+	}
+	if len(args) == 5 {
+		ln.Meta.Description = args[4]
+	}
+	store := c.linkStore()
+	var prev *link.Link
+	existing, err := store.GetLink(c.ctx, org, key)
+	if err == nil {
+		prev = &existing
+		ln.Meta.Tags = existing.Meta.Tags
+		if len(args) == 4 {
+			ln.Meta.Description = existing.Meta.Description
+		}
+	} else if !errors.Is(err, link.ErrNotFound) {
+		return err
+	}
+	ln.Touch(cliEditor, time.Now(), prev)
+	return store.UpdateLink(c.ctx, org, key, *ln)
+}
+
+func linkDelete(c *cli, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	store := c.linkStore()
+	_, err := store.GetLink(c.ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return store.DeleteLink(c.ctx, args[0], args[1])
+}
+
+func linkList(c *cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	links, err := c.linkStore().GetLinks(c.ctx, args[0])
+	if errors.Is(err, link.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(links))
+	for key := range links {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	for _, key := range keys {
+		ln := links[key]
+		desc, err := ln.Description()
+		if err != nil {
+			desc = fmt.Sprintf("<%v>", err)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", key, desc, ln.Meta.Description)
+	}
+	return tw.Flush()
+}
+
+func kvDump(c *cli, args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+	store, err := c.kvStore(args[0])
+	if err != nil {
+		return err
+	}
+	root := store.In(args[1:]...)
+	err = kv.Walk(c.ctx, root, func(path []string, ns kv.Namespace) error {
+		prefix := strings.Join(append(path, ""), "/")
+		err := ns.Iterate(c.ctx, func(key string, value []byte) bool {
+			fmt.Fprintf(c.out, "%s%s\t%q\n", prefix, key, value)
+			return true
+		})
+		if errors.Is(err, kv.ErrNotFound) {
+			return nil
+		}
+		return err
+	})
+	if errors.Is(err, kv.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -2,40 +2,126 @@ package main
 
 import (
 	"strings"
+	"time"
 
 	"github.com/popodidi/log"
 
 	"github.com/haostudio/golinks/internal/encoding"
 	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/history"
 	"github.com/haostudio/golinks/internal/link/kv"
+	"github.com/haostudio/golinks/internal/link/pattern"
+	patternkv "github.com/haostudio/golinks/internal/link/pattern/kv"
+	"github.com/haostudio/golinks/internal/link/share"
+	sharekv "github.com/haostudio/golinks/internal/link/share/kv"
+	"github.com/haostudio/golinks/internal/link/suggest"
+	"github.com/haostudio/golinks/internal/link/sweeper"
 	"github.com/haostudio/golinks/internal/link/traced"
 )
 
 // LinkStoreConfig defines the link store config.
 type LinkStoreConfig struct {
-	Type string `conf:"default:kv"`
-	Kv   StoreConfig
+	Type    string `conf:"default:kv"`
+	Kv      StoreConfig
+	History struct {
+		Enabled bool `conf:"default:true"`
+	}
+	Patterns struct {
+		Enabled bool `conf:"default:true"`
+	}
+	Suggestions struct {
+		Enabled bool `conf:"default:true"`
+	}
+	Sharing struct {
+		Enabled bool `conf:"default:true"`
+		// comma separated orgs to resolve the links missing in an org, which
+		// only the "org create" command creates
+		Fallback string
+	}
+	Sweeper struct {
+		Enabled  bool `conf:"default:true"`
+		Interval int  `conf:"default:10"` // sweep interval in minute
+		Grace    int  `conf:"default:7"`  // days to keep expired links
+	}
+}
+
+// linkStores defines the link store and the optional stores along with it.
+type linkStores struct {
+	Links    link.Store
+	History  history.Store   // nil if disabled
+	Patterns *pattern.Router // nil if disabled
+	Shares   share.Store     // nil if disabled
+	// Suggestions indexes the keys of Links. nil if disabled.
+	Suggestions *suggest.Index
 }
 
 func newLinkStore(logger log.Logger,
 	conf LinkStoreConfig, enc encoding.Binary, traceEnabled bool) (
-	store link.Store, closeFunc func() error) {
+	stores linkStores, closeFunc func() error) {
 	switch strings.ToLower(conf.Type) {
 	case "kv":
-		store, closeFunc = newKvLinkStore(logger, conf.Kv, enc, traceEnabled)
+		stores, closeFunc = newKvLinkStore(logger, conf, enc, traceEnabled)
 	default:
 		logger.Critical("unknown link store type: %s", conf.Type)
 	}
 	if traceEnabled {
-		store = traced.New(store)
+		stores.Links = traced.New(stores.Links)
 	}
 	return
 }
 
+// fallbackOrgs returns the fallback orgs of the links missing in an org.
+func fallbackOrgs(conf LinkStoreConfig) []string {
+	return splitList(conf.Sharing.Fallback)
+}
+
+// splitList returns the non-empty values of the comma separated list.
+func splitList(list string) []string {
+	var values []string
+	for _, v := range strings.Split(list, ",") {
+		v = strings.TrimSpace(v)
+		if len(v) > 0 {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func newLinkSweeper(conf LinkStoreConfig, store link.Store) func() error {
+	if !conf.Sweeper.Enabled {
+		return func() error { return nil }
+	}
+	s := sweeper.New(sweeper.Config{
+		Store:    store,
+		Interval: time.Duration(conf.Sweeper.Interval) * time.Minute,
+		Grace:    time.Duration(conf.Sweeper.Grace) * 24 * time.Hour,
+	})
+	return s.Close
+}
+
 func newKvLinkStore(logger log.Logger,
-	conf StoreConfig, enc encoding.Binary, traceEnabled bool) (
-	link.Store, func() error) {
-	linkKv, closeFunc := newStore(logger, conf, traceEnabled)
-	store := kv.New(linkKv.In(linkNamespace), enc)
-	return store, closeFunc
+	conf LinkStoreConfig, enc encoding.Binary, traceEnabled bool) (
+	linkStores, func() error) {
+	linkKv, closeFunc := newStore(logger, conf.Kv, traceEnabled)
+	var stores linkStores
+	if conf.Patterns.Enabled {
+		stores.Patterns = pattern.NewRouter(
+			patternkv.New(linkKv.In(linkPatternNamespace), enc))
+	}
+	if conf.Sharing.Enabled {
+		stores.Shares = sharekv.New(linkKv.In(linkShareNamespace), enc)
+	}
+	links := kv.NewIn(linkKv.In(), linkNamespace, enc)
+	if conf.Suggestions.Enabled {
+		stores.Suggestions = suggest.New(links)
+		links = stores.Suggestions
+	}
+	stores.Links = links
+	if !conf.History.Enabled {
+		return stores, closeFunc
+	}
+	hist := history.New(links, linkKv.In(), linkHistoryNamespace, enc)
+	stores.Links = hist
+	stores.History = hist
+	return stores, closeFunc
 }
@@ -23,10 +23,14 @@ import (
 )
 
 const (
-	rootNamespace  = "github.com/haostudio/golinks"
-	linkNamespace  = "_link"
-	authNamespace  = "_auth"
-	cacheNamespace = "_cache"
+	rootNamespace        = "github.com/haostudio/golinks"
+	linkNamespace        = "_link"
+	linkHistoryNamespace = "_link_history"
+	linkPatternNamespace = "_link_pattern"
+	linkShareNamespace   = "_link_share"
+	authNamespace        = "_auth"
+	analyticsNamespace   = "_analytics"
+	cacheNamespace       = "_cache"
 )
 
 // Config defines golinks server config.
@@ -37,15 +41,23 @@ type Config struct {
 	LinkStore LinkStoreConfig
 	// XXX: Use AuthProvider for backward compatibility
 	AuthProvider AuthManagerConfig
+	Analytics    AnalyticsConfig
 	HTTP         struct {
 		Golinks struct {
 			Enabled bool `conf:"default:true"`
 			Wiki    bool `conf:"default:false"`
 		}
 	}
+	// Migrate is only used by the kv migrate command.
+	Migrate MigrateConfig
 }
 
 func main() {
+	// Run offline admin subcommands
+	if len(os.Args) > 1 && isCommand(os.Args[1]) {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	// Load server config
 	var config Config
 	cfg := conf.New(&config)
@@ -85,7 +97,7 @@ func main() {
 	enc := gob.New()
 
 	// links store
-	linkStore, linkStoreClose := newLinkStore(
+	linkStores, linkStoreClose := newLinkStore(
 		logger, config.LinkStore, enc, config.Metrics.Enabled(),
 	)
 	defer func() {
@@ -94,10 +106,18 @@ func main() {
 			logger.Warn("failed to close link store. %v", err)
 		}
 	}()
+	linkSweeperClose := newLinkSweeper(config.LinkStore, linkStores.Links)
+	defer func() {
+		err := linkSweeperClose()
+		if err != nil {
+			logger.Warn("failed to close link sweeper. %v", err)
+		}
+	}()
 
 	// auth provider
 	authManager, authManagerClose := newAuthManager(
 		logger, config.AuthProvider, enc, config.Metrics.Enabled(),
+		fallbackOrgs(config.LinkStore),
 	)
 	defer func() {
 		err := authManagerClose()
@@ -106,6 +126,17 @@ func main() {
 		}
 	}()
 
+	// analytics store
+	analyticsStore, analyticsStoreClose := newAnalyticsStore(
+		logger, config.Analytics, enc, config.Metrics.Enabled(),
+	)
+	defer func() {
+		err := analyticsStoreClose()
+		if err != nil {
+			logger.Warn("failed to close analytics store. %v", err)
+		}
+	}()
+
 	// Setup service mux
 	mux := service.NewMux(logger)
 	addr := fmt.Sprintf("0.0.0.0:%d", config.Port)
@@ -113,15 +144,22 @@ func main() {
 	// Setup default HTTP server
 	if config.HTTP.Golinks.Enabled {
 		golinksConfig := golinks.Config{
-			Gin:       gin.New(),
-			Address:   addr,
-			Traced:    config.Metrics.Enabled(),
-			Wiki:      config.HTTP.Golinks.Wiki,
-			LinkStore: linkStore,
+			Gin:          gin.New(),
+			Address:      addr,
+			Traced:       config.Metrics.Enabled(),
+			Wiki:         config.HTTP.Golinks.Wiki,
+			LinkStore:    linkStores.Links,
+			LinkHistory:  linkStores.History,
+			Analytics:    analyticsStore,
+			Patterns:     linkStores.Patterns,
+			Shares:       linkStores.Shares,
+			FallbackOrgs: fallbackOrgs(config.LinkStore),
+			Suggestions:  linkStores.Suggestions,
 		}
 		golinksConfig.Auth.Enabled = !config.AuthProvider.NoAuth.Enabled
 		golinksConfig.Auth.DefaultOrg = config.AuthProvider.NoAuth.DefaultOrg
 		golinksConfig.Auth.Manager = authManager
+		golinksConfig.Auth.SSO = newSSOClient(logger, config.AuthProvider)
 		mux.Append(golinks.New(golinksConfig))
 	}
 
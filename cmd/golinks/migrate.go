@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/haostudio/golinks/internal/kv/migrate"
+)
+
+// checkpointNamespace defines the namespace in the destination store that
+// records the migrated namespaces.
+const checkpointNamespace = "_migrate"
+
+// MigrateConfig defines the config of the kv migrate command.
+type MigrateConfig struct {
+	Source      StoreConfig
+	Destination StoreConfig
+}
+
+func kvMigrate(c *cli, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	conf := c.config.Migrate
+	// migrate the canonical stores without cache
+	conf.Source.LRUCache = false
+	conf.Destination.LRUCache = false
+	src, closeSrc := newStore(c.logger, conf.Source, false)
+	c.closers = append(c.closers, closeSrc)
+	dst, closeDst := newStore(c.logger, conf.Destination, false)
+	c.closers = append(c.closers, closeDst)
+	if src.String() == dst.String() {
+		return errors.New("source and destination are the same store")
+	}
+
+	paths, err := migrate.Paths(c.ctx, src, checkpointNamespace)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "migrating %d namespaces from %s to %s\n",
+		len(paths), src, dst)
+	_, err = migrate.Run(c.ctx, migrate.Config{
+		Source:      src,
+		Destination: dst,
+		Paths:       paths,
+		Checkpoint:  dst.In(checkpointNamespace),
+	}, func(res migrate.Result) {
+		status := "migrated"
+		if res.Skipped {
+			status = "skipped"
+		}
+		fmt.Fprintf(c.out, "%s\t%s\t%d keys\t%s\n",
+			status, strings.Join(res.Path, "/"), res.Count, res.Checksum)
+	})
+	return err
+}
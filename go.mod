@@ -27,4 +27,5 @@ require (
 	golang.org/x/sys v0.0.0-20220608164250-635b8c9b7f68 // indirect
 	golang.org/x/tools v0.0.0-20200329025819-fd4102a86c65 // indirect
 	google.golang.org/genproto v0.0.0-20200326112834-f447254575fd // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,54 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DayFormat defines the time format of the daily bucket keys.
+const DayFormat = "2006-01-02"
+
+// Stats defines the click stats of a link.
+type Stats struct {
+	Count        int64
+	LastAccessed time.Time
+	Daily        map[string]int64
+}
+
+// Hit adds a hit at t to s and keeps only the latest retention days of
+// daily buckets.
+func (s *Stats) Hit(t time.Time, retention int) {
+	s.Count++
+	if t.After(s.LastAccessed) {
+		s.LastAccessed = t
+	}
+	if s.Daily == nil {
+		s.Daily = make(map[string]int64)
+	}
+	s.Daily[t.UTC().Format(DayFormat)]++
+	if retention <= 0 {
+		return
+	}
+	oldest := s.LastAccessed.UTC().AddDate(0, 0, -retention).Format(DayFormat)
+	for day := range s.Daily {
+		// days in DayFormat are lexicographically ordered.
+		if day <= oldest {
+			delete(s.Daily, day)
+		}
+	}
+}
+
+// Recorder defines the interface to record hits of links.
+type Recorder interface {
+	Record(ctx context.Context, org, key string, t time.Time) error
+}
+
+// Store defines the analytics store interface.
+type Store interface {
+	fmt.Stringer
+	Recorder
+
+	GetStats(ctx context.Context, org, key string) (Stats, error)
+	GetOrgStats(ctx context.Context, org string) (map[string]Stats, error)
+}
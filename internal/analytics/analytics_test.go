@@ -0,0 +1,42 @@
+package analytics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/haostudio/golinks/internal/analytics"
+	"github.com/haostudio/golinks/internal/analytics/kv"
+	"github.com/haostudio/golinks/internal/encoding/gob"
+	"github.com/haostudio/golinks/internal/kv/memory"
+)
+
+func TestStatsRetention(t *testing.T) {
+	var stats Stats
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		stats.Hit(day.AddDate(0, 0, i), 3)
+	}
+	require.Equal(t, int64(10), stats.Count)
+	require.Equal(t, map[string]int64{
+		"2020-01-08": 1,
+		"2020-01-09": 1,
+		"2020-01-10": 1,
+	}, stats.Daily)
+}
+
+func TestAsync(t *testing.T) {
+	ctx := context.Background()
+	async := NewAsync(kv.New(memory.New().In("test"), gob.New()), 1<<10)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, async.Record(ctx, "org", "key", time.Now()))
+	}
+	require.NoError(t, async.Close())
+	require.Equal(t, ErrClosed, async.Record(ctx, "org", "key", time.Now()))
+
+	stats, err := async.GetStats(ctx, "org", "key")
+	require.NoError(t, err)
+	require.Equal(t, int64(100), stats.Count)
+}
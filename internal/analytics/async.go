@@ -0,0 +1,98 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/popodidi/log"
+)
+
+// NewAsync returns a Store recording hits to store asynchronously with a
+// buffer of size. Hits are dropped if the buffer is full so that recording
+// never blocks the caller.
+func NewAsync(store Store, size int) *Async {
+	a := &Async{
+		store:  store,
+		logger: log.New("analytics"),
+		hits:   make(chan hit, size),
+	}
+	a.wg.Add(1)
+	go a.loop()
+	return a
+}
+
+// Async defines an asynchronous analytics store.
+type Async struct {
+	store  Store
+	logger log.Logger
+	hits   chan hit
+	wg     sync.WaitGroup
+
+	closed struct {
+		sync.RWMutex
+		v bool
+	}
+}
+
+type hit struct {
+	org, key string
+	t        time.Time
+}
+
+// Record enqueues the hit and returns immediately.
+func (a *Async) Record(ctx context.Context, org, key string, t time.Time) error {
+	a.closed.RLock()
+	defer a.closed.RUnlock()
+	if a.closed.v {
+		return ErrClosed
+	}
+	select {
+	case a.hits <- hit{org: org, key: key, t: t}:
+		return nil
+	default:
+		return ErrBufferFull
+	}
+}
+
+// GetStats returns the stats of the link with key in org.
+func (a *Async) GetStats(ctx context.Context, org, key string) (
+	Stats, error) {
+	return a.store.GetStats(ctx, org, key)
+}
+
+// GetOrgStats returns the stats of all links in org.
+func (a *Async) GetOrgStats(ctx context.Context, org string) (
+	map[string]Stats, error) {
+	return a.store.GetOrgStats(ctx, org)
+}
+
+// Close stops accepting hits and waits for the buffered hits to be recorded.
+func (a *Async) Close() error {
+	a.closed.Lock()
+	if a.closed.v {
+		a.closed.Unlock()
+		return nil
+	}
+	a.closed.v = true
+	close(a.hits)
+	a.closed.Unlock()
+	a.wg.Wait()
+	return nil
+}
+
+func (a *Async) loop() {
+	defer a.wg.Done()
+	for h := range a.hits {
+		err := a.store.Record(context.Background(), h.org, h.key, h.t)
+		if err != nil {
+			a.logger.Error("failed to record hit of %s/%s. err: %v",
+				h.org, h.key, err)
+		}
+	}
+}
+
+func (a *Async) String() string {
+	return fmt.Sprintf("async(%s)", a.store)
+}
@@ -0,0 +1,9 @@
+package analytics
+
+import "errors"
+
+// Exported errors.
+var (
+	ErrBufferFull = errors.New("analytics buffer is full")
+	ErrClosed     = errors.New("analytics recorder is closed")
+)
@@ -0,0 +1,87 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/haostudio/golinks/internal/analytics"
+	"github.com/haostudio/golinks/internal/encoding"
+	"github.com/haostudio/golinks/internal/kv"
+)
+
+// DailyRetention defines the number of days of daily buckets to keep.
+const DailyRetention = 90
+
+// New returns a new analytics store with kv and enc.
+func New(kv kv.Namespace, enc encoding.Binary) analytics.Store {
+	return &store{
+		kv:  kv,
+		enc: enc,
+	}
+}
+
+type store struct {
+	// serializes the read-modify-write of Record.
+	sync.Mutex
+	kv  kv.Namespace
+	enc encoding.Binary
+}
+
+func (s *store) Record(
+	ctx context.Context, org, key string, t time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+	stats, err := s.GetStats(ctx, org, key)
+	if err != nil {
+		return err
+	}
+	stats.Hit(t, DailyRetention)
+	b, err := s.enc.Encode(stats)
+	if err != nil {
+		return err
+	}
+	return s.kv.In(org).Set(ctx, key, b)
+}
+
+func (s *store) GetStats(ctx context.Context, org, key string) (
+	stats analytics.Stats, err error) {
+	b, err := s.kv.In(org).Get(ctx, key)
+	if errors.Is(err, kv.ErrNotFound) {
+		// no hits yet.
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	err = s.enc.Decode(b, &stats)
+	return
+}
+
+func (s *store) GetOrgStats(ctx context.Context, org string) (
+	map[string]analytics.Stats, error) {
+	all := make(map[string]analytics.Stats)
+	err := s.kv.In(org).Iterate(ctx, func(key string, value []byte) bool {
+		var stats analytics.Stats
+		iterErr := s.enc.Decode(value, &stats)
+		if iterErr != nil {
+			return true
+		}
+		all[key] = stats
+		return true
+	})
+	if errors.Is(err, kv.ErrNotFound) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+func (s *store) String() string {
+	return fmt.Sprintf("kv.store(%s/%s)", s.kv, s.enc)
+}
@@ -0,0 +1,51 @@
+package kv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+	"github.com/haostudio/golinks/internal/kv/memory"
+)
+
+func TestStoreLogic(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New().In("test"), gob.New())
+
+	// no hits
+	stats, err := store.GetStats(ctx, "org", "key")
+	require.NoError(t, err)
+	require.Zero(t, stats.Count)
+	all, err := store.GetOrgStats(ctx, "org")
+	require.NoError(t, err)
+	require.Empty(t, all)
+
+	day1 := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	require.NoError(t, store.Record(ctx, "org", "key", day1))
+	require.NoError(t, store.Record(ctx, "org", "key", day2))
+	require.NoError(t, store.Record(ctx, "org", "key", day2))
+	require.NoError(t, store.Record(ctx, "org", "other", day1))
+
+	stats, err = store.GetStats(ctx, "org", "key")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), stats.Count)
+	require.True(t, day2.Equal(stats.LastAccessed))
+	require.Equal(t, map[string]int64{
+		"2020-01-01": 1,
+		"2020-01-02": 2,
+	}, stats.Daily)
+
+	all, err = store.GetOrgStats(ctx, "org")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	require.Equal(t, int64(1), all["other"].Count)
+
+	// orgs are isolated
+	stats, err = store.GetStats(ctx, "org2", "key")
+	require.NoError(t, err)
+	require.Zero(t, stats.Count)
+}
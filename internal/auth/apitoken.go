@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// apiTokenPrefix prefixes the personal API tokens so that they are
+// recognizable, e.g. by secret scanners.
+const apiTokenPrefix = "golinks_"
+
+// Scope defines the permission granted to a personal API token.
+type Scope string
+
+// Supported scopes, from the least to the most privileged.
+const (
+	ScopeRead  Scope = "read"
+	ScopeWrite Scope = "write"
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes lists the supported scopes in ascending privilege.
+var Scopes = []Scope{ScopeRead, ScopeWrite, ScopeAdmin}
+
+// ParseScope returns the scope named str.
+func ParseScope(str string) (Scope, error) {
+	for _, scope := range Scopes {
+		if string(scope) == str {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("unknown scope %q. %w", str, ErrBadParams)
+}
+
+// Role returns the most privileged role the scope grants in an org.
+func (s Scope) Role() Role {
+	switch s {
+	case ScopeRead:
+		return RoleViewer
+	case ScopeWrite:
+		return RoleEditor
+	case ScopeAdmin:
+		return RoleAdmin
+	}
+	return ""
+}
+
+// APIToken defines a personal API token of a user. Only the hash of the
+// token secret is stored.
+type APIToken struct {
+	ID     string
+	Email  string
+	Org    string // the org the token acts in
+	Name   string
+	Hash   []byte
+	Scopes []Scope
+
+	CreatedAt time.Time
+	// ExpiredAt is the expiry of the token. Zero means it never expires.
+	ExpiredAt  time.Time
+	LastUsedAt time.Time
+}
+
+// NewAPIToken generates a new API token and returns it with the token string
+// to present in the "Authorization: Bearer" header, which can not be
+// recovered from the token.
+func NewAPIToken(email, org, name string, scopes []Scope,
+	expiredAt time.Time) (token *APIToken, tokenStr string, err error) {
+	if len(email) == 0 || len(strings.TrimSpace(name)) == 0 ||
+		len(scopes) == 0 {
+		err = ErrBadParams
+		return
+	}
+	for _, scope := range scopes {
+		if scope.Role() == "" {
+			err = fmt.Errorf("unknown scope %q. %w", scope, ErrBadParams)
+			return
+		}
+	}
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	_, err = rand.Read(id)
+	if err == nil {
+		_, err = rand.Read(secret)
+	}
+	if err != nil {
+		err = fmt.Errorf("%v; %w", err, ErrInternalError)
+		return
+	}
+	secretStr := base64.RawURLEncoding.EncodeToString(secret)
+	token = &APIToken{
+		ID:        hex.EncodeToString(id),
+		Email:     email,
+		Org:       org,
+		Name:      strings.TrimSpace(name),
+		Hash:      hashAPITokenSecret(secretStr),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiredAt: expiredAt,
+	}
+	tokenStr = apiTokenPrefix + token.ID + "_" + secretStr
+	return
+}
+
+// parseAPIToken returns the ID and the secret of the token string.
+func parseAPIToken(tokenStr string) (id, secret string, err error) {
+	if !strings.HasPrefix(tokenStr, apiTokenPrefix) {
+		err = ErrInvalidToken
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(tokenStr, apiTokenPrefix), "_", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		err = ErrInvalidToken
+		return
+	}
+	return parts[0], parts[1], nil
+}
+
+// hashAPITokenSecret returns the hash of the secret. The secrets are random
+// enough that a fast hash is sufficient.
+func hashAPITokenSecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// verifySecret returns if secret is the secret of t.
+func (t APIToken) verifySecret(secret string) bool {
+	return subtle.ConstantTimeCompare(t.Hash, hashAPITokenSecret(secret)) == 1
+}
+
+// IsExpired returns if t is expired at now.
+func (t APIToken) IsExpired(now time.Time) bool {
+	return !t.ExpiredAt.IsZero() && !now.Before(t.ExpiredAt)
+}
+
+// Role returns the most privileged role granted by the scopes of t.
+func (t APIToken) Role() Role {
+	var role Role
+	for _, scope := range t.Scopes {
+		if r := scope.Role(); r.level() > role.level() {
+			role = r
+		}
+	}
+	return role
+}
+
+// Allows returns if the scopes of t grant the permissions of required.
+func (t APIToken) Allows(required Scope) bool {
+	return t.Role().Allows(required.Role())
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIToken(t *testing.T) {
+	token, tokenStr, err := NewAPIToken("a@haostudio", "org", " ci ",
+		[]Scope{ScopeRead}, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, "ci", token.Name)
+	require.True(t, strings.HasPrefix(tokenStr, apiTokenPrefix+token.ID+"_"))
+	require.NotContains(t, string(token.Hash), tokenStr)
+
+	id, secret, err := parseAPIToken(tokenStr)
+	require.NoError(t, err)
+	require.Equal(t, token.ID, id)
+	require.True(t, token.verifySecret(secret))
+	require.False(t, token.verifySecret(secret+"x"))
+	for _, str := range []string{"", "golinks_", "golinks_id", "other_id_x"} {
+		_, _, err = parseAPIToken(str)
+		require.True(t, errors.Is(err, ErrInvalidToken), str)
+	}
+
+	require.False(t, token.IsExpired(time.Now()))
+	token.ExpiredAt = time.Now()
+	require.True(t, token.IsExpired(time.Now()))
+
+	_, _, err = NewAPIToken("a@haostudio", "org", "", []Scope{ScopeRead},
+		time.Time{})
+	require.True(t, errors.Is(err, ErrBadParams))
+	_, _, err = NewAPIToken("a@haostudio", "org", "ci", nil, time.Time{})
+	require.True(t, errors.Is(err, ErrBadParams))
+	_, _, err = NewAPIToken("a@haostudio", "org", "ci", []Scope{"root"},
+		time.Time{})
+	require.True(t, errors.Is(err, ErrBadParams))
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	for _, scope := range Scopes {
+		s, err := ParseScope(string(scope))
+		require.NoError(t, err)
+		require.Equal(t, scope, s)
+	}
+	_, err := ParseScope("root")
+	require.True(t, errors.Is(err, ErrBadParams))
+
+	token := APIToken{Scopes: []Scope{ScopeRead}}
+	require.Equal(t, RoleViewer, token.Role())
+	require.True(t, token.Allows(ScopeRead))
+	require.False(t, token.Allows(ScopeWrite))
+	token.Scopes = []Scope{ScopeAdmin, ScopeRead}
+	require.Equal(t, RoleAdmin, token.Role())
+	require.True(t, token.Allows(ScopeWrite))
+	require.True(t, token.Allows(ScopeAdmin))
+}
@@ -2,7 +2,9 @@ package authtest
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -37,3 +39,63 @@ func ProviderLogicTest(t *testing.T, provider auth.Provider) {
 	require.NoError(t, err)
 	require.Equal(t, org, o)
 }
+
+// ProviderTokenTest test the provider stores tokens until they expire.
+func ProviderTokenTest(t *testing.T, provider auth.Provider) {
+	ctx := context.Background()
+
+	tokens := []auth.Token{
+		{JWT: "valid", ExpiredAt: time.Now().Add(time.Hour)},
+		{JWT: "expired", ExpiredAt: time.Now().Add(-time.Second)},
+		{JWT: "legacy"},
+	}
+	for _, token := range tokens {
+		require.NoError(t, provider.SetToken(ctx, token))
+	}
+	token, err := provider.GetToken(ctx, "valid")
+	require.NoError(t, err)
+	require.True(t, token.ExpiredAt.Equal(tokens[0].ExpiredAt))
+	_, err = provider.GetToken(ctx, "expired")
+	require.True(t, errors.Is(err, auth.ErrNotFound))
+	_, err = provider.GetToken(ctx, "legacy")
+	require.NoError(t, err)
+
+	require.NoError(t, provider.DeleteToken(ctx, "valid"))
+	_, err = provider.GetToken(ctx, "valid")
+	require.True(t, errors.Is(err, auth.ErrNotFound))
+}
+
+// ProviderAPITokenTest test the provider stores the API tokens by ID and lists
+// them by user.
+func ProviderAPITokenTest(t *testing.T, provider auth.Provider) {
+	ctx := context.Background()
+
+	tokens, err := provider.GetAPITokens(ctx, "a@haostudio")
+	require.NoError(t, err)
+	require.Empty(t, tokens)
+
+	a1 := auth.APIToken{ID: "a1", Email: "a@haostudio", Name: "ci",
+		Scopes: []auth.Scope{auth.ScopeRead}}
+	a2 := auth.APIToken{ID: "a2", Email: "a@haostudio", Name: "cli",
+		Scopes: []auth.Scope{auth.ScopeWrite}}
+	b1 := auth.APIToken{ID: "b1", Email: "b@haostudio", Name: "ci",
+		Scopes: []auth.Scope{auth.ScopeAdmin}}
+	for _, token := range []auth.APIToken{a1, a2, b1} {
+		require.NoError(t, provider.SetAPIToken(ctx, token))
+	}
+	require.Error(t, provider.SetAPIToken(ctx, auth.APIToken{}))
+
+	token, err := provider.GetAPIToken(ctx, "a2")
+	require.NoError(t, err)
+	require.Equal(t, a2, token)
+	tokens, err = provider.GetAPITokens(ctx, "a@haostudio")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []auth.APIToken{a1, a2}, tokens)
+
+	require.NoError(t, provider.DeleteAPIToken(ctx, "a1"))
+	_, err = provider.GetAPIToken(ctx, "a1")
+	require.True(t, errors.Is(err, auth.ErrNotFound))
+	tokens, err = provider.GetAPITokens(ctx, "a@haostudio")
+	require.NoError(t, err)
+	require.Equal(t, []auth.APIToken{a2}, tokens)
+}
@@ -25,4 +25,16 @@ type Provider interface {
 	GetToken(ctx context.Context, token string) (Token, error)
 	SetToken(ctx context.Context, token Token) error
 	DeleteToken(ctx context.Context, token string) error
+
+	// personal API tokens
+	GetAPIToken(ctx context.Context, id string) (APIToken, error)
+	// GetAPITokens returns the API tokens of the user with email, or an empty
+	// slice if there's none.
+	GetAPITokens(ctx context.Context, email string) ([]APIToken, error)
+	SetAPIToken(ctx context.Context, token APIToken) error
+	DeleteAPIToken(ctx context.Context, id string) error
+
+	// Update runs f in a transaction. The changes made through tx are
+	// committed atomically if f returns nil, and discarded otherwise.
+	Update(ctx context.Context, f func(tx Provider) error) error
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/haostudio/golinks/internal/auth"
 	"github.com/haostudio/golinks/internal/encoding"
@@ -14,6 +15,8 @@ const (
 	userNamespace  = "_user"
 	orgNamespace   = "_org"
 	tokenNamespace = "_token"
+
+	apiTokenNamespace = "_api_token"
 )
 
 // New returns an auth provider.
@@ -48,6 +51,10 @@ func (p *provider) GetUser(ctx context.Context, email string) (
 	}
 	// Decode
 	err = p.enc.Decode(b, &user)
+	if err != nil {
+		return
+	}
+	user.MigrateOrgs()
 	return
 }
 
@@ -65,7 +72,8 @@ func (p *provider) getUsersByOrg(ctx context.Context, org string) (
 			if iterErr != nil {
 				return true
 			}
-			if org != "" && org != user.Organization {
+			user.MigrateOrgs()
+			if org != "" && !user.IsMember(org) {
 				return true
 			}
 			users = append(users, user.Email)
@@ -168,13 +176,86 @@ func (p *provider) SetToken(ctx context.Context, token auth.Token) error {
 	if err != nil {
 		return err
 	}
-	return p.store.In(tokenNamespace).Set(ctx, token.JWT, blob)
+	if token.ExpiredAt.IsZero() {
+		return p.store.In(tokenNamespace).Set(ctx, token.JWT, blob)
+	}
+	// the token is removed from the store after it expires.
+	return p.store.In(tokenNamespace).SetWithTTL(
+		ctx, token.JWT, blob, time.Until(token.ExpiredAt))
 }
 
 func (p *provider) DeleteToken(ctx context.Context, token string) error {
 	return p.store.In(tokenNamespace).Delete(ctx, token)
 }
 
+// personal API tokens
+func (p *provider) GetAPIToken(ctx context.Context, id string) (
+	token auth.APIToken, err error) {
+	if len(id) == 0 {
+		err = fmt.Errorf("token id is required. %w", auth.ErrBadParams)
+		return
+	}
+	// Get blob from kv
+	b, err := p.store.In(apiTokenNamespace).Get(ctx, id)
+	if errors.Is(err, kv.ErrNotFound) {
+		err = auth.ErrNotFound
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("%v: %w", err, auth.ErrStoreError)
+		return
+	}
+	// Decode
+	err = p.enc.Decode(b, &token)
+	return
+}
+
+func (p *provider) GetAPITokens(ctx context.Context, email string) (
+	tokens []auth.APIToken, err error) {
+	// nolint: godox
+	// FIXME: Same as getUsersByOrg, all tokens are fetched and filtered by
+	// email in code level.
+	err = p.store.In(apiTokenNamespace).Iterate(ctx,
+		func(key string, value []byte) bool {
+			var token auth.APIToken
+			iterErr := p.enc.Decode(value, &token)
+			if iterErr != nil || token.Email != email {
+				return true
+			}
+			tokens = append(tokens, token)
+			return true
+		})
+	if errors.Is(err, kv.ErrNotFound) {
+		err = nil
+	}
+	return
+}
+
+func (p *provider) SetAPIToken(ctx context.Context, token auth.APIToken) error {
+	if len(token.ID) == 0 {
+		return fmt.Errorf("token id is required. %w", auth.ErrBadParams)
+	}
+	blob, err := p.enc.Encode(token)
+	if err != nil {
+		return err
+	}
+	return p.store.In(apiTokenNamespace).Set(ctx, token.ID, blob)
+}
+
+func (p *provider) DeleteAPIToken(ctx context.Context, id string) error {
+	return p.store.In(apiTokenNamespace).Delete(ctx, id)
+}
+
+func (p *provider) Update(
+	ctx context.Context, f func(tx auth.Provider) error) error {
+	return p.store.Update(ctx, func(tx kv.Namespace) error {
+		return f(&provider{
+			store: tx,
+			enc:   p.enc,
+		})
+	})
+}
+
 func (p *provider) String() string {
 	return fmt.Sprintf("kv.provider(%s/%s)", p.store, p.enc)
 }
@@ -11,4 +11,6 @@ import (
 func TestLogic(t *testing.T) {
 	provider := New(memory.New().In("test"), gob.New())
 	authtest.ProviderLogicTest(t, provider)
+	authtest.ProviderTokenTest(t, provider)
+	authtest.ProviderAPITokenTest(t, provider)
 }
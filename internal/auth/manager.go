@@ -7,11 +7,18 @@ import (
 	"time"
 )
 
+// apiTokenUsedInterval is the precision of the last used time of the API
+// tokens, which saves a store write on every use.
+const apiTokenUsedInterval = time.Minute
+
 // Config defines the auth manager config.
 type Config struct {
 	Provider         Provider
 	TokenExpieration time.Duration
 	TokenSecret      []byte
+	// ReservedOrgs are the org names the users can't register, e.g. the
+	// fallback orgs, which only the operators create.
+	ReservedOrgs []string // optional
 }
 
 // New returns an auth manager with provider.
@@ -20,6 +27,7 @@ func New(config Config) *Manager {
 		Provider:         config.Provider,
 		TokenExpieration: config.TokenExpieration,
 		TokenSecret:      config.TokenSecret,
+		ReservedOrgs:     config.ReservedOrgs,
 	}
 }
 
@@ -28,134 +36,203 @@ type Manager struct {
 	Provider
 	TokenExpieration time.Duration
 	TokenSecret      []byte
+	ReservedOrgs     []string
 }
 
-// RegisterUser creates the user, ensuring the user does not exist and the org
-// does exist if user.Organization is not empty.
-func (m *Manager) RegisterUser(ctx context.Context, user User) (err error) {
-	var exists bool
-	exists, err = m.IsUserExists(ctx, user.Email)
-	if err != nil {
-		err = fmt.Errorf("failed to get user exists. %w", err)
-		return
-	}
-	if exists {
-		err = ErrUserExists
-		return
-	}
-	if user.Organization != "" {
-		exists, err = m.IsOrgExists(ctx, user.Organization)
+// RegisterUser creates the user, ensuring the user does not exist and the orgs
+// of the user do exist.
+func (m *Manager) RegisterUser(ctx context.Context, user User) error {
+	user.MigrateOrgs()
+	return m.update(ctx, func(m *Manager) (err error) {
+		var exists bool
+		exists, err = m.IsUserExists(ctx, user.Email)
 		if err != nil {
-			err = fmt.Errorf("failed to get org exists. %w", err)
+			err = fmt.Errorf("failed to get user exists. %w", err)
 			return
 		}
-		if !exists {
-			err = fmt.Errorf("org not found. %w", ErrNotFound)
+		if exists {
+			err = ErrUserExists
 			return
 		}
-	}
-	return m.SetUser(ctx, user)
+		for _, org := range user.Orgs {
+			exists, err = m.IsOrgExists(ctx, org)
+			if err != nil {
+				err = fmt.Errorf("failed to get org exists. %w", err)
+				return
+			}
+			if !exists {
+				err = fmt.Errorf("org %s not found. %w", org, ErrNotFound)
+				return
+			}
+		}
+		return m.SetUser(ctx, user)
+	})
 }
 
-// RegisterOrg creates the org if org doesn't exist and admin holds no other
+// RegisterOrg creates the org if org doesn't exist, and adds the admin to the
 // org.
-func (m *Manager) RegisterOrg(ctx context.Context, org Organization) (
-	err error) {
-	// check if org already exists
-	var exists bool
-	exists, err = m.IsOrgExists(ctx, org.Name)
-	if err != nil {
-		err = fmt.Errorf("failed to get org exists. %w", err)
-		return
-	}
-	if exists {
-		err = ErrOrgExists
-		return
+func (m *Manager) RegisterOrg(ctx context.Context, org Organization) error {
+	if m.IsOrgReserved(org.Name) {
+		return fmt.Errorf("org %s is reserved. %w", org.Name, ErrBadParams)
 	}
-	// update admin user
-	admin, err := m.GetUser(ctx, org.AdminEmail)
-	if err != nil {
-		err = fmt.Errorf("failed to get admin exists. %w", err)
-		return
-	}
-	if admin.Organization != "" {
-		err = fmt.Errorf("admin holds another org. %w", ErrBadParams)
-		return
-	}
-	admin.Organization = org.Name
-	err = m.SetUser(ctx, admin)
-	if err != nil {
+	return m.update(ctx, func(m *Manager) (err error) {
+		// check if org already exists
+		var exists bool
+		exists, err = m.IsOrgExists(ctx, org.Name)
+		if err != nil {
+			err = fmt.Errorf("failed to get org exists. %w", err)
+			return
+		}
+		if exists {
+			err = ErrOrgExists
+			return
+		}
+		// update admin user
+		admin, err := m.GetUser(ctx, org.AdminEmail)
+		if err != nil {
+			err = fmt.Errorf("failed to get admin exists. %w", err)
+			return
+		}
+		admin.AddOrg(org.Name)
+		err = m.SetUser(ctx, admin)
+		if err != nil {
+			return
+		}
+		// create org
+		err = m.SetOrg(ctx, org)
+		if err != nil {
+			err = fmt.Errorf("%v;%w", err, ErrStoreError)
+		}
 		return
-	}
-	// create org
-	err = m.SetOrg(ctx, org)
-	if err != nil {
-		err = fmt.Errorf("%v;%w", err, ErrStoreError)
-		// best effort to revert user
-		admin.Organization = ""
-		_ = m.SetUser(ctx, admin)
-	}
-	return
+	})
 }
 
 // RegisterOrgWithAdmin creates the org and the admin user.
 func (m *Manager) RegisterOrgWithAdmin(
-	ctx context.Context, org Organization, admin User) (err error) {
+	ctx context.Context, org Organization, admin User) error {
 	// check parameters
 	if org.AdminEmail != admin.Email {
-		err = ErrBadParams
-		return
+		return ErrBadParams
 	}
-	// check if org or user exists
-	var exists bool
-	exists, err = m.IsOrgExists(ctx, org.Name)
-	if err != nil {
-		err = fmt.Errorf("failed to get org exists. %w", err)
-		return
-	}
-	if exists {
-		err = ErrOrgExists
-		return
+	if m.IsOrgReserved(org.Name) {
+		return fmt.Errorf("org %s is reserved. %w", org.Name, ErrBadParams)
 	}
-	exists, err = m.IsUserExists(ctx, admin.Email)
-	if err != nil {
-		err = fmt.Errorf("failed to get admin exists. %w", err)
+	return m.update(ctx, func(m *Manager) (err error) {
+		// check if org or user exists
+		var exists bool
+		exists, err = m.IsOrgExists(ctx, org.Name)
+		if err != nil {
+			err = fmt.Errorf("failed to get org exists. %w", err)
+			return
+		}
+		if exists {
+			err = ErrOrgExists
+			return
+		}
+		exists, err = m.IsUserExists(ctx, admin.Email)
+		if err != nil {
+			err = fmt.Errorf("failed to get admin exists. %w", err)
+			return
+		}
+		if exists {
+			err = ErrUserExists
+			return
+		}
+		// create org and user.
+		err = m.SetOrg(ctx, org)
+		if err != nil {
+			err = fmt.Errorf("%v;%w", err, ErrStoreError)
+			return
+		}
+		err = m.SetUser(ctx, admin)
+		if err != nil {
+			err = fmt.Errorf("%v;%w", err, ErrStoreError)
+		}
 		return
-	}
-	if exists {
-		err = ErrUserExists
+	})
+}
+
+// AddUserOrg adds the user with email to org.
+func (m *Manager) AddUserOrg(ctx context.Context, email, org string) error {
+	return m.update(ctx, func(m *Manager) error {
+		user, err := m.GetUser(ctx, email)
+		if err != nil {
+			return fmt.Errorf("user not found. %w", err)
+		}
+		if user.IsMember(org) {
+			return nil
+		}
+		_, err = m.GetOrg(ctx, org)
+		if err != nil {
+			return fmt.Errorf("org not found. %w", err)
+		}
+		user.AddOrg(org)
+		return m.SetUser(ctx, user)
+	})
+}
+
+// MigrateUsers rewrites the users stored in the single org format, and
+// returns the number of migrated users. The users are already read in the
+// current format, so the migration only updates the store.
+func (m *Manager) MigrateUsers(ctx context.Context) (migrated int, err error) {
+	emails, err := m.GetUsers(ctx)
+	if errors.Is(err, ErrNotFound) {
+		err = nil
 		return
 	}
-	// create org and user.
-	err = m.SetOrg(ctx, org)
 	if err != nil {
-		err = fmt.Errorf("%v;%w", err, ErrStoreError)
 		return
 	}
-	err = m.SetUser(ctx, admin)
-	if err != nil {
-		err = fmt.Errorf("%v;%w", err, ErrStoreError)
-		// best effort to delete org
-		_ = m.DeleteOrg(ctx, org.Name)
+	for _, email := range emails {
+		err = m.update(ctx, func(m *Manager) error {
+			user, err := m.GetUser(ctx, email)
+			if err != nil {
+				return err
+			}
+			return m.SetUser(ctx, user)
+		})
+		if err != nil {
+			err = fmt.Errorf("failed to migrate user %s. %w", email, err)
+			return
+		}
+		migrated++
 	}
 	return
 }
 
-// SetUserOrg sets the org of user with email.
-func (m *Manager) SetUserOrg(ctx context.Context, email, org string) error {
-	user, err := m.GetUser(ctx, email)
-	if err != nil {
-		return fmt.Errorf("user not found. %w", err)
-	}
-	if user.Organization == org {
-		return nil
-	}
-	_, err = m.GetOrg(ctx, org)
-	if err != nil {
-		return fmt.Errorf("org not found. %w", err)
+// SetUserRole sets the role of the user with email in org. The org admin can
+// not be demoted.
+func (m *Manager) SetUserRole(
+	ctx context.Context, org, email string, role Role) error {
+	if role.level() < 0 {
+		return fmt.Errorf("unknown role %q. %w", role, ErrBadParams)
 	}
-	user.Organization = org
-	return m.SetUser(ctx, user)
+	return m.update(ctx, func(m *Manager) error {
+		user, err := m.GetUser(ctx, email)
+		if err != nil {
+			return fmt.Errorf("user not found. %w", err)
+		}
+		if !user.IsMember(org) {
+			return fmt.Errorf("user not in org. %w", ErrBadParams)
+		}
+		o, err := m.GetOrg(ctx, org)
+		if err != nil {
+			return fmt.Errorf("org not found. %w", err)
+		}
+		if o.AdminEmail == email {
+			if role == RoleAdmin {
+				return nil
+			}
+			return fmt.Errorf("org admin can not be demoted. %w", ErrBadParams)
+		}
+		roles := make(map[string]Role, len(o.Roles)+1)
+		for e, r := range o.Roles {
+			roles[e] = r
+		}
+		roles[email] = role
+		o.Roles = roles
+		return m.SetOrg(ctx, o)
+	})
 }
 
 // IsUserExists returns if the user exists.
@@ -174,6 +251,16 @@ func (m *Manager) IsUserExists(ctx context.Context, email string) (
 	return
 }
 
+// IsOrgReserved returns if the org name is reserved from registration.
+func (m *Manager) IsOrgReserved(org string) bool {
+	for _, reserved := range m.ReservedOrgs {
+		if org == reserved {
+			return true
+		}
+	}
+	return false
+}
+
 // IsOrgExists returns if the org exists.
 func (m *Manager) IsOrgExists(ctx context.Context, org string) (
 	exists bool, err error) {
@@ -203,6 +290,53 @@ func (m *Manager) Login(ctx context.Context, email string, password string) (
 		err = fmt.Errorf("%v; %w", err, ErrBadParams)
 		return
 	}
+	return m.issueToken(ctx, user)
+}
+
+// LoginSSO returns the access token of the user with email verified by a
+// single sign-on provider. The user is created without password on the first
+// login, and is added to org if org is not empty and exists.
+func (m *Manager) LoginSSO(ctx context.Context, email, org string) (
+	token *Token, err error) {
+	if len(email) == 0 {
+		err = ErrBadParams
+		return
+	}
+	var user User
+	err = m.update(ctx, func(m *Manager) error {
+		var err error
+		changed := false
+		user, err = m.GetUser(ctx, email)
+		if errors.Is(err, ErrNotFound) {
+			user, err, changed = User{Email: email}, nil, true
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user. %w", err)
+		}
+		if len(org) != 0 && !user.IsMember(org) {
+			exists, err := m.IsOrgExists(ctx, org)
+			if err != nil {
+				return fmt.Errorf("failed to get org exists. %w", err)
+			}
+			if exists {
+				user.AddOrg(org)
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		return m.SetUser(ctx, user)
+	})
+	if err != nil {
+		return
+	}
+	return m.issueToken(ctx, user)
+}
+
+// issueToken generates and stores a new access token of user.
+func (m *Manager) issueToken(ctx context.Context, user User) (
+	token *Token, err error) {
 	token, err = NewToken(user, m.TokenSecret, m.TokenExpieration)
 	if err != nil {
 		return
@@ -227,15 +361,107 @@ func (m *Manager) Verify(ctx context.Context, tokenStr string) (
 		return
 	}
 	claims, err = verifyToken(token.JWT, m.TokenSecret)
+	if err == nil && claims.ExpiredAt < time.Now().Unix() {
+		err = ErrTokenExpired
+	}
+	if err != nil && token.ExpiredAt.IsZero() {
+		// best effort to remove the unusable token stored without expiry
+		_ = m.DeleteToken(ctx, tokenStr)
+	}
+	return
+}
+
+// CreateAPIToken creates a personal API token of the user with email acting
+// in org, and returns it with the token string, which is not stored.
+func (m *Manager) CreateAPIToken(ctx context.Context, email, org, name string,
+	scopes []Scope, expiredAt time.Time) (
+	token *APIToken, tokenStr string, err error) {
+	token, tokenStr, err = NewAPIToken(email, org, name, scopes, expiredAt)
 	if err != nil {
 		return
 	}
-	if claims.ExpiredAt < time.Now().Unix() {
+	err = m.update(ctx, func(m *Manager) error {
+		user, err := m.GetUser(ctx, email)
+		if err != nil {
+			return fmt.Errorf("user not found. %w", err)
+		}
+		if !user.IsMember(org) {
+			return fmt.Errorf("user not in org. %w", ErrBadParams)
+		}
+		return m.SetAPIToken(ctx, *token)
+	})
+	if err != nil {
+		token, tokenStr = nil, ""
+	}
+	return
+}
+
+// RevokeAPIToken deletes the API token with id of the user with email.
+func (m *Manager) RevokeAPIToken(ctx context.Context, email, id string) error {
+	return m.update(ctx, func(m *Manager) error {
+		token, err := m.GetAPIToken(ctx, id)
+		if err != nil {
+			return err
+		}
+		if token.Email != email {
+			return ErrNotFound
+		}
+		return m.DeleteAPIToken(ctx, id)
+	})
+}
+
+// VerifyAPIToken verifies the API token string and records the time it's
+// used.
+func (m *Manager) VerifyAPIToken(ctx context.Context, tokenStr string) (
+	token APIToken, err error) {
+	id, secret, err := parseAPIToken(tokenStr)
+	if err != nil {
+		return
+	}
+	token, err = m.GetAPIToken(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		err = ErrInvalidToken
+		return
+	}
+	if err != nil {
+		return
+	}
+	if !token.verifySecret(secret) {
+		err = ErrInvalidToken
+		return
+	}
+	now := time.Now()
+	if token.IsExpired(now) {
 		err = ErrTokenExpired
+		return
+	}
+	if now.Sub(token.LastUsedAt) < apiTokenUsedInterval {
+		return
 	}
+	token.LastUsedAt = now
+	err = m.update(ctx, func(m *Manager) error {
+		// skip the token revoked in the meantime
+		_, err := m.GetAPIToken(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			return ErrInvalidToken
+		}
+		if err != nil {
+			return err
+		}
+		return m.SetAPIToken(ctx, token)
+	})
 	return
 }
 
+// update runs f with a manager bound to a provider transaction.
+func (m *Manager) update(ctx context.Context, f func(m *Manager) error) error {
+	return m.Provider.Update(ctx, func(tx Provider) error {
+		txm := *m
+		txm.Provider = tx
+		return f(&txm)
+	})
+}
+
 // Logout deletes the access token.
 func (m *Manager) Logout(ctx context.Context, token string) (err error) {
 	return m.DeleteToken(ctx, token)
@@ -50,13 +50,285 @@ func TestManagerRegisterOrg(t *testing.T) {
 		require.NoError(t, manager.SetUser(context.Background(), *user))
 		require.NoError(t, manager.RegisterOrg(context.Background(), org))
 
-		err = manager.RegisterOrg(context.Background(), Organization{
+		// the admin can hold multiple orgs.
+		require.NoError(t, manager.RegisterOrg(context.Background(), Organization{
 			Name:       "orgxx",
 			AdminEmail: "admin@test.com",
-		})
+		}))
+		admin, err := manager.GetUser(context.Background(), "admin@test.com")
+		require.NoError(t, err)
+		require.Equal(t, []string{"org", "orgxx"}, admin.Orgs)
+
+		err = manager.RegisterOrg(context.Background(), org)
 		require.True(t,
-			errors.Is(err, ErrBadParams), "%v is not  %v", err, ErrBadParams)
+			errors.Is(err, ErrOrgExists), "%v is not  %v", err, ErrOrgExists)
+
+		// the reserved orgs can't be registered.
+		manager.ReservedOrgs = []string{"_global_"}
+		err = manager.RegisterOrg(context.Background(), Organization{
+			Name:       "_global_",
+			AdminEmail: "admin@test.com",
+		})
+		require.True(t, errors.Is(err, ErrBadParams), err)
+		exists, err := manager.IsOrgExists(context.Background(), "_global_")
+		require.NoError(t, err)
+		require.False(t, exists)
+	}
+}
+
+func TestManagerAddUserOrg(t *testing.T) {
+	ctx := context.Background()
+	manager := testManager()
+	for _, name := range []string{"org1", "org2"} {
+		require.NoError(t, manager.SetOrg(ctx, Organization{
+			Name:       name,
+			AdminEmail: "admin@test.com",
+		}))
+	}
+	user, err := NewUser("email@test.com", "test_pwd", "org1")
+	require.NoError(t, err)
+	require.NoError(t, manager.RegisterUser(ctx, *user))
+
+	require.NoError(t, manager.AddUserOrg(ctx, user.Email, "org2"))
+	require.NoError(t, manager.AddUserOrg(ctx, user.Email, "org2"))
+	err = manager.AddUserOrg(ctx, user.Email, "org3")
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	got, err := manager.GetUser(ctx, user.Email)
+	require.NoError(t, err)
+	require.Equal(t, []string{"org1", "org2"}, got.Orgs)
+	require.Equal(t, "org1", got.DefaultOrg())
+	for _, name := range []string{"org1", "org2"} {
+		users, err := manager.GetOrgUsers(ctx, name)
+		require.NoError(t, err)
+		require.Equal(t, []string{user.Email}, users)
+	}
+}
+
+func TestManagerMigrateUsers(t *testing.T) {
+	ctx := context.Background()
+	manager := testManager()
+	legacy := User{Email: "legacy@test.com", Organization: "org"}
+	require.NoError(t, manager.SetUser(ctx, legacy))
+
+	user, err := manager.GetUser(ctx, legacy.Email)
+	require.NoError(t, err)
+	require.Equal(t, []string{"org"}, user.Orgs)
+	require.Empty(t, user.Organization)
+
+	migrated, err := manager.MigrateUsers(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, migrated)
+	users, err := manager.GetOrgUsers(ctx, "org")
+	require.NoError(t, err)
+	require.Equal(t, []string{legacy.Email}, users)
+}
+
+func TestManagerRegisterOrgWithAdmin(t *testing.T) {
+	ctx := context.Background()
+	manager := testManager()
+	org := Organization{
+		Name:       "org",
+		AdminEmail: "admin@test.com",
 	}
+	admin, err := NewUser("admin@test.com", "test_pwd", "org")
+	require.NoError(t, err)
+
+	// nothing is created if the admin fails to be stored
+	errStore := errors.New("store error")
+	failing := *manager
+	failing.Provider = &failingProvider{manager.Provider, errStore}
+	err = failing.RegisterOrgWithAdmin(ctx, org, *admin)
+	require.True(t, errors.Is(err, ErrStoreError))
+	exists, err := manager.IsOrgExists(ctx, org.Name)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, manager.RegisterOrgWithAdmin(ctx, org, *admin))
+	exists, err = manager.IsOrgExists(ctx, org.Name)
+	require.NoError(t, err)
+	require.True(t, exists)
+	err = manager.RegisterOrgWithAdmin(ctx, org, *admin)
+	require.True(t, errors.Is(err, ErrOrgExists))
+}
+
+// failingProvider fails to set users.
+type failingProvider struct {
+	Provider
+	err error
+}
+
+func (p *failingProvider) SetUser(ctx context.Context, user User) error {
+	return p.err
+}
+
+func (p *failingProvider) Update(
+	ctx context.Context, f func(tx Provider) error) error {
+	return p.Provider.Update(ctx, func(tx Provider) error {
+		return f(&failingProvider{tx, p.err})
+	})
+}
+
+func TestManagerLoginTokenExpiry(t *testing.T) {
+	ctx := context.Background()
+	manager := testManager()
+	user, err := NewUser("email@test.com", "test_pwd", "")
+	require.NoError(t, err)
+	require.NoError(t, manager.RegisterUser(ctx, *user))
+
+	token, err := manager.Login(ctx, user.Email, "test_pwd")
+	require.NoError(t, err)
+	require.WithinDuration(t,
+		time.Now().Add(manager.TokenExpieration), token.ExpiredAt, time.Minute)
+	stored, err := manager.GetToken(ctx, token.JWT)
+	require.NoError(t, err)
+	require.True(t, token.ExpiredAt.Equal(stored.ExpiredAt))
+	_, err = manager.Verify(ctx, token.JWT)
+	require.NoError(t, err)
+
+	// an unusable token stored without expiry is removed on verification
+	require.NoError(t, manager.SetToken(ctx, Token{JWT: "legacy"}))
+	_, err = manager.Verify(ctx, "legacy")
+	require.True(t, errors.Is(err, ErrInvalidToken))
+	_, err = manager.GetToken(ctx, "legacy")
+	require.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestManagerLoginSSO(t *testing.T) {
+	ctx := context.Background()
+	manager := testManager()
+	require.NoError(t, manager.SetOrg(ctx, Organization{
+		Name:       "org",
+		AdminEmail: "admin@test.com",
+	}))
+
+	// the user is provisioned on the first login
+	token, err := manager.LoginSSO(ctx, "sso@test.com", "")
+	require.NoError(t, err)
+	claims, err := manager.Verify(ctx, token.JWT)
+	require.NoError(t, err)
+	require.Equal(t, "sso@test.com", claims.Email)
+	require.Equal(t, "", claims.Org)
+	user, err := manager.GetUser(ctx, "sso@test.com")
+	require.NoError(t, err)
+	require.Empty(t, user.PasswordHash)
+	require.Empty(t, user.Orgs)
+	// the user can not log in with password
+	_, err = manager.Login(ctx, "sso@test.com", "")
+	require.Error(t, err)
+
+	// the unknown org is ignored
+	_, err = manager.LoginSSO(ctx, "sso@test.com", "unknown")
+	require.NoError(t, err)
+	user, err = manager.GetUser(ctx, "sso@test.com")
+	require.NoError(t, err)
+	require.Empty(t, user.Orgs)
+
+	// the user is added to the mapped org
+	token, err = manager.LoginSSO(ctx, "sso@test.com", "org")
+	require.NoError(t, err)
+	claims, err = manager.Verify(ctx, token.JWT)
+	require.NoError(t, err)
+	require.Equal(t, "org", claims.Org)
+
+	// the existing password user keeps the password
+	pwdUser, err := NewUser("pwd@test.com", "test_pwd", "")
+	require.NoError(t, err)
+	require.NoError(t, manager.RegisterUser(ctx, *pwdUser))
+	_, err = manager.LoginSSO(ctx, "pwd@test.com", "org")
+	require.NoError(t, err)
+	_, err = manager.Login(ctx, "pwd@test.com", "test_pwd")
+	require.NoError(t, err)
+
+	_, err = manager.LoginSSO(ctx, "", "org")
+	require.True(t, errors.Is(err, ErrBadParams))
+}
+
+func TestManagerAPIToken(t *testing.T) {
+	ctx := context.Background()
+	manager := testManager()
+	user, err := NewUser("email@test.com", "test_pwd", "")
+	require.NoError(t, err)
+	require.NoError(t, manager.RegisterUser(ctx, *user))
+	require.NoError(t, manager.RegisterOrg(ctx, Organization{
+		Name:       "org",
+		AdminEmail: user.Email,
+	}))
+
+	_, _, err = manager.CreateAPIToken(ctx, user.Email, "other", "ci",
+		[]Scope{ScopeRead}, time.Time{})
+	require.True(t, errors.Is(err, ErrBadParams))
+	_, _, err = manager.CreateAPIToken(ctx, "unknown@test.com", "org", "ci",
+		[]Scope{ScopeRead}, time.Time{})
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	token, tokenStr, err := manager.CreateAPIToken(ctx, user.Email, "org",
+		"ci", []Scope{ScopeWrite}, time.Time{})
+	require.NoError(t, err)
+	require.True(t, token.LastUsedAt.IsZero())
+
+	// verification records the last used time
+	verified, err := manager.VerifyAPIToken(ctx, tokenStr)
+	require.NoError(t, err)
+	require.Equal(t, token.ID, verified.ID)
+	require.Equal(t, "org", verified.Org)
+	stored, err := manager.GetAPIToken(ctx, token.ID)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), stored.LastUsedAt, time.Minute)
+	_, err = manager.VerifyAPIToken(ctx, tokenStr+"x")
+	require.True(t, errors.Is(err, ErrInvalidToken))
+	_, err = manager.VerifyAPIToken(ctx, "golinks_unknown_x")
+	require.True(t, errors.Is(err, ErrInvalidToken))
+
+	// expired
+	_, expiredStr, err := manager.CreateAPIToken(ctx, user.Email, "org",
+		"old", []Scope{ScopeRead}, time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	_, err = manager.VerifyAPIToken(ctx, expiredStr)
+	require.True(t, errors.Is(err, ErrTokenExpired))
+
+	tokens, err := manager.GetAPITokens(ctx, user.Email)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+
+	// only the owner can revoke the token
+	err = manager.RevokeAPIToken(ctx, "other@test.com", token.ID)
+	require.True(t, errors.Is(err, ErrNotFound))
+	require.NoError(t, manager.RevokeAPIToken(ctx, user.Email, token.ID))
+	_, err = manager.VerifyAPIToken(ctx, tokenStr)
+	require.True(t, errors.Is(err, ErrInvalidToken))
+}
+
+func TestManagerSetUserRole(t *testing.T) {
+	ctx := context.Background()
+	manager := testManager()
+	admin, err := NewUser("admin@test.com", "test_pwd", "")
+	require.NoError(t, err)
+	require.NoError(t, manager.RegisterOrgWithAdmin(ctx, Organization{
+		Name:       "org",
+		AdminEmail: admin.Email,
+	}, *admin))
+	member, err := NewUser("member@test.com", "test_pwd", "org")
+	require.NoError(t, err)
+	require.NoError(t, manager.RegisterUser(ctx, *member))
+	outsider, err := NewUser("outsider@test.com", "test_pwd", "")
+	require.NoError(t, err)
+	require.NoError(t, manager.RegisterUser(ctx, *outsider))
+
+	require.NoError(t,
+		manager.SetUserRole(ctx, "org", member.Email, RoleViewer))
+	org, err := manager.GetOrg(ctx, "org")
+	require.NoError(t, err)
+	require.Equal(t, RoleViewer, org.Role(member.Email))
+
+	err = manager.SetUserRole(ctx, "org", admin.Email, RoleEditor)
+	require.True(t, errors.Is(err, ErrBadParams))
+	err = manager.SetUserRole(ctx, "org", outsider.Email, RoleEditor)
+	require.True(t, errors.Is(err, ErrBadParams))
+	err = manager.SetUserRole(ctx, "org", "nobody@test.com", RoleEditor)
+	require.True(t, errors.Is(err, ErrNotFound))
+	err = manager.SetUserRole(ctx, "org", member.Email, Role("owner"))
+	require.True(t, errors.Is(err, ErrBadParams))
 }
 
 func testManager() *Manager {
@@ -1,13 +1,18 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"fmt"
 
-// NewUser returns a user with email, pwd and org.
+	"golang.org/x/crypto/bcrypt"
+)
+
+// NewUser returns a user with email, pwd and org. The user is in no org if org
+// is empty.
 func NewUser(email, password, org string) (*User, error) {
 	user := User{
-		Email:        email,
-		Organization: org,
+		Email: email,
 	}
+	user.AddOrg(org)
 	err := user.SetPassword(password)
 	if err != nil {
 		return nil, err
@@ -19,7 +24,52 @@ func NewUser(email, password, org string) (*User, error) {
 type User struct {
 	Email        string
 	PasswordHash []byte
+	// Organization is the only org of a user stored before users could be in
+	// multiple orgs. MigrateOrgs moves it into Orgs.
 	Organization string
+	// Orgs are the orgs the user is a member of, the default one first.
+	Orgs []string
+}
+
+// IsMember returns if u is a member of org.
+func (u *User) IsMember(org string) bool {
+	for _, o := range u.Orgs {
+		if o == org {
+			return true
+		}
+	}
+	return false
+}
+
+// AddOrg adds u to org.
+func (u *User) AddOrg(org string) {
+	if org == "" || u.IsMember(org) {
+		return
+	}
+	u.Orgs = append(u.Orgs, org)
+}
+
+// DefaultOrg returns the default org of u, or an empty string if u is in no
+// org.
+func (u *User) DefaultOrg() string {
+	if len(u.Orgs) == 0 {
+		return ""
+	}
+	return u.Orgs[0]
+}
+
+// MigrateOrgs moves the org of u stored in the single org format into Orgs
+// as the default org, and returns if u is changed.
+func (u *User) MigrateOrgs() bool {
+	if u.Organization == "" {
+		return false
+	}
+	org := u.Organization
+	u.Organization = ""
+	if !u.IsMember(org) {
+		u.Orgs = append([]string{org}, u.Orgs...)
+	}
+	return true
 }
 
 // SetPassword sets password hash with bcrypt.
@@ -39,8 +89,65 @@ func (u *User) VerifyPassword(pwd string) error {
 	return bcrypt.CompareHashAndPassword(u.PasswordHash, pwdBytes)
 }
 
+// Role defines the permission level of a user within an org.
+type Role string
+
+// Supported roles, from the least to the most privileged.
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// DefaultRole is the role of an org user without an explicit role.
+const DefaultRole = RoleEditor
+
+// Roles lists the supported roles in ascending privilege.
+var Roles = []Role{RoleViewer, RoleEditor, RoleAdmin}
+
+// ParseRole returns the role named str.
+func ParseRole(str string) (Role, error) {
+	for _, role := range Roles {
+		if string(role) == str {
+			return role, nil
+		}
+	}
+	return "", fmt.Errorf("unknown role %q. %w", str, ErrBadParams)
+}
+
+// level returns the privilege level of the role, or -1 if it is unknown.
+func (r Role) level() int {
+	for i, role := range Roles {
+		if role == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// Allows returns if the role grants the permissions of required.
+func (r Role) Allows(required Role) bool {
+	level := r.level()
+	return level >= 0 && level >= required.level()
+}
+
 // Organization defines the org model.
 type Organization struct {
 	Name       string
 	AdminEmail string
+	// Roles maps user emails to their roles. Users without an entry have the
+	// DefaultRole.
+	Roles map[string]Role
+}
+
+// Role returns the role of the user with email in the org. The org admin is
+// always an admin.
+func (o Organization) Role(email string) Role {
+	if email == o.AdminEmail {
+		return RoleAdmin
+	}
+	if role, ok := o.Roles[email]; ok {
+		return role
+	}
+	return DefaultRole
 }
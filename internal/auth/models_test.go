@@ -13,3 +13,46 @@ func TestUserPwd(t *testing.T) {
 	require.NoError(t, u.SetPassword(pwd))
 	require.NoError(t, u.VerifyPassword(pwd))
 }
+
+func TestUserOrgs(t *testing.T) {
+	u := User{Organization: "legacy", Orgs: []string{"other"}}
+	require.True(t, u.MigrateOrgs())
+	require.False(t, u.MigrateOrgs())
+	require.Equal(t, []string{"legacy", "other"}, u.Orgs)
+	require.Equal(t, "legacy", u.DefaultOrg())
+
+	u.AddOrg("other")
+	u.AddOrg("")
+	u.AddOrg("new")
+	require.Equal(t, []string{"legacy", "other", "new"}, u.Orgs)
+	require.True(t, u.IsMember("new"))
+	require.False(t, u.IsMember(""))
+	require.Empty(t, (&User{}).DefaultOrg())
+}
+
+func TestRole(t *testing.T) {
+	for _, role := range Roles {
+		parsed, err := ParseRole(string(role))
+		require.NoError(t, err)
+		require.Equal(t, role, parsed)
+	}
+	_, err := ParseRole("owner")
+	require.Error(t, err)
+
+	require.True(t, RoleAdmin.Allows(RoleEditor))
+	require.True(t, RoleEditor.Allows(RoleEditor))
+	require.False(t, RoleViewer.Allows(RoleEditor))
+	require.False(t, Role("").Allows(RoleViewer))
+
+	org := Organization{
+		Name:       "org",
+		AdminEmail: "admin@test.com",
+		Roles: map[string]Role{
+			"admin@test.com":  RoleViewer,
+			"viewer@test.com": RoleViewer,
+		},
+	}
+	require.Equal(t, RoleAdmin, org.Role("admin@test.com"))
+	require.Equal(t, RoleViewer, org.Role("viewer@test.com"))
+	require.Equal(t, DefaultRole, org.Role("other@test.com"))
+}
@@ -0,0 +1,12 @@
+package oidc
+
+import "errors"
+
+// Exported errors.
+var (
+	ErrDiscovery        = errors.New("provider discovery failed")
+	ErrExchange         = errors.New("code exchange failed")
+	ErrInvalidIDToken   = errors.New("invalid id token")
+	ErrEmailNotVerified = errors.New("email not verified")
+	ErrDomainNotAllowed = errors.New("email domain not allowed")
+)
@@ -0,0 +1,46 @@
+package oidc
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// jwkSet defines the JSON web key set of the provider.
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwk defines the fields of an RSA JSON web key.
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// rsaKeys returns the RSA signing keys in s by kid. The keys of other types or
+// uses are skipped.
+func (s jwkSet) rsaKeys() map[string]*rsa.PublicKey {
+	keys := make(map[string]*rsa.PublicKey, len(s.Keys))
+	for _, k := range s.Keys {
+		if k.Kty != "RSA" || (len(k.Use) != 0 && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		exp := 0
+		for _, b := range e {
+			exp = exp<<8 | int(b)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}
+	}
+	return keys
+}
@@ -0,0 +1,320 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// DefaultScopes defines the scopes requested if none is configured.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// Config defines the OpenID Connect client config.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // optional
+	// AllowedDomains are the email domains allowed to log in. Any domain is
+	// allowed if it's empty.
+	AllowedDomains []string // optional
+	// DomainOrgs maps the email domains to the orgs of the users.
+	DomainOrgs map[string]string // optional
+	// OrgClaim is the ID token claim of the org of the user. It takes
+	// precedence over DomainOrgs.
+	OrgClaim string // optional
+	// AllowMissingEmailVerified accepts the ID tokens without the
+	// email_verified claim, for the providers which only issue verified emails
+	// and omit the claim. The emails must be verified otherwise.
+	AllowMissingEmailVerified bool         // optional
+	HTTPClient                *http.Client // optional
+}
+
+// Identity defines the user identity verified by the provider.
+type Identity struct {
+	Subject string
+	Email   string
+	// Org is the org mapped from the identity, or empty if there's none.
+	Org string
+}
+
+// New returns an OpenID Connect client. The provider is discovered on the
+// first use.
+func New(conf Config) *Client {
+	if len(conf.Scopes) == 0 {
+		conf.Scopes = DefaultScopes
+	}
+	if conf.HTTPClient == nil {
+		conf.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	conf.Issuer = strings.TrimSuffix(conf.Issuer, "/")
+	return &Client{conf: conf}
+}
+
+// Client implements the OpenID Connect authorization code flow.
+type Client struct {
+	conf Config
+
+	mutex    sync.Mutex
+	provider *providerMetadata
+	keys     map[string]*rsa.PublicKey
+}
+
+// providerMetadata defines the fields used in the provider discovery document.
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// AuthCodeURL returns the URL of the provider to redirect the user to for
+// authorization.
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce string) (
+	string, error) {
+	provider, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.conf.ClientID},
+		"redirect_uri":  {c.conf.RedirectURL},
+		"scope":         {strings.Join(c.conf.Scopes, " ")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	endpoint := provider.AuthorizationEndpoint
+	if strings.Contains(endpoint, "?") {
+		return endpoint + "&" + params.Encode(), nil
+	}
+	return endpoint + "?" + params.Encode(), nil
+}
+
+// Exchange exchanges the authorization code for the ID token, and returns the
+// identity of the verified ID token.
+func (c *Client) Exchange(ctx context.Context, code, nonce string) (
+	identity Identity, err error) {
+	provider, err := c.discover(ctx)
+	if err != nil {
+		return
+	}
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {c.conf.RedirectURL},
+	}
+	req, err := http.NewRequest(http.MethodPost, provider.TokenEndpoint,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		err = fmt.Errorf("%v; %w", err, ErrExchange)
+		return
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(
+		url.QueryEscape(c.conf.ClientID), url.QueryEscape(c.conf.ClientSecret))
+	var res struct {
+		IDToken string `json:"id_token"`
+	}
+	err = c.getJSON(req, &res)
+	if err != nil {
+		err = fmt.Errorf("%v; %w", err, ErrExchange)
+		return
+	}
+	if len(res.IDToken) == 0 {
+		err = fmt.Errorf("id_token not found. %w", ErrExchange)
+		return
+	}
+	return c.verify(ctx, provider, res.IDToken, nonce)
+}
+
+// verify verifies the ID token and returns its identity.
+func (c *Client) verify(ctx context.Context, provider *providerMetadata,
+	idToken, nonce string) (identity Identity, err error) {
+	parser := jwt.Parser{ValidMethods: []string{"RS256"}}
+	claims := jwt.MapClaims{}
+	_, err = parser.ParseWithClaims(idToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return c.key(ctx, provider, kid)
+		})
+	if err != nil {
+		err = fmt.Errorf("%v; %w", err, ErrInvalidIDToken)
+		return
+	}
+	if iss, _ := claims["iss"].(string); iss != provider.Issuer {
+		err = fmt.Errorf("unexpected issuer %q. %w", iss, ErrInvalidIDToken)
+		return
+	}
+	if !hasAudience(claims["aud"], c.conf.ClientID) {
+		err = fmt.Errorf("unexpected audience. %w", ErrInvalidIDToken)
+		return
+	}
+	if _, ok := claims["exp"]; !ok {
+		err = fmt.Errorf("exp not found. %w", ErrInvalidIDToken)
+		return
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		err = fmt.Errorf("unexpected nonce. %w", ErrInvalidIDToken)
+		return
+	}
+
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Email = strings.ToLower(identity.Email)
+	at := strings.LastIndex(identity.Email, "@")
+	if at < 1 || at == len(identity.Email)-1 {
+		err = fmt.Errorf("invalid email %q. %w", identity.Email, ErrInvalidIDToken)
+		return
+	}
+	verified, ok := claims["email_verified"].(bool)
+	if !verified && (ok || !c.conf.AllowMissingEmailVerified) {
+		err = fmt.Errorf("email %s not verified. %w", identity.Email,
+			ErrEmailNotVerified)
+		return
+	}
+	domain := identity.Email[at+1:]
+	if !c.allowed(domain) {
+		err = fmt.Errorf("domain %s. %w", domain, ErrDomainNotAllowed)
+		return
+	}
+	identity.Org = c.org(claims, domain)
+	return
+}
+
+// allowed returns if the email domain is allowed to log in.
+func (c *Client) allowed(domain string) bool {
+	if len(c.conf.AllowedDomains) == 0 {
+		return true
+	}
+	for _, d := range c.conf.AllowedDomains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// org returns the org mapped from the claims or the email domain.
+func (c *Client) org(claims jwt.MapClaims, domain string) string {
+	if len(c.conf.OrgClaim) != 0 {
+		switch v := claims[c.conf.OrgClaim].(type) {
+		case string:
+			if len(v) != 0 {
+				return v
+			}
+		case []interface{}:
+			for _, o := range v {
+				if s, ok := o.(string); ok && len(s) != 0 {
+					return s
+				}
+			}
+		}
+	}
+	return c.conf.DomainOrgs[domain]
+}
+
+// hasAudience returns if the aud claim, a string or an array of strings,
+// contains clientID.
+func hasAudience(aud interface{}, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok && s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// discover returns the provider metadata, fetching it from the issuer if it's
+// not yet discovered.
+func (c *Client) discover(ctx context.Context) (*providerMetadata, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.provider != nil {
+		return c.provider, nil
+	}
+	req, err := http.NewRequest(http.MethodGet,
+		c.conf.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("%v; %w", err, ErrDiscovery)
+	}
+	var provider providerMetadata
+	err = c.getJSON(req.WithContext(ctx), &provider)
+	if err != nil {
+		return nil, fmt.Errorf("%v; %w", err, ErrDiscovery)
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != c.conf.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %q. %w",
+			provider.Issuer, ErrDiscovery)
+	}
+	if len(provider.AuthorizationEndpoint) == 0 ||
+		len(provider.TokenEndpoint) == 0 || len(provider.JWKSURI) == 0 {
+		return nil, fmt.Errorf("missing endpoints. %w", ErrDiscovery)
+	}
+	c.provider = &provider
+	return c.provider, nil
+}
+
+// key returns the provider signing key with kid. The keys are refetched if kid
+// is unknown, in case the provider rotated its keys.
+func (c *Client) key(ctx context.Context, provider *providerMetadata,
+	kid string) (*rsa.PublicKey, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if key := lookupKey(c.keys, kid); key != nil {
+		return key, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, provider.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set jwkSet
+	err = c.getJSON(req.WithContext(ctx), &set)
+	if err != nil {
+		return nil, err
+	}
+	c.keys = set.rsaKeys()
+	if key := lookupKey(c.keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("signing key %q not found", kid)
+}
+
+// lookupKey returns the key with kid, or the only key if kid is empty.
+func lookupKey(keys map[string]*rsa.PublicKey, kid string) *rsa.PublicKey {
+	if len(kid) == 0 && len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return keys[kid]
+}
+
+// getJSON sends req and decodes the JSON response body into v.
+func (c *Client) getJSON(req *http.Request, v interface{}) error {
+	res, err := c.conf.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
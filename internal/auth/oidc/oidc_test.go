@@ -0,0 +1,159 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/auth/oidc"
+	"github.com/haostudio/golinks/internal/auth/oidc/oidctest"
+)
+
+func newClient(idp *oidctest.Server, conf oidc.Config) *oidc.Client {
+	conf.Issuer = idp.URL
+	conf.ClientID = oidctest.ClientID
+	conf.ClientSecret = oidctest.ClientSecret
+	conf.RedirectURL = "http://golinks.test/auth/sso/callback"
+	return oidc.New(conf)
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := oidctest.NewServer()
+	defer idp.Close()
+	client := newClient(idp, oidc.Config{})
+
+	u, err := client.AuthCodeURL(context.Background(), "state", "nonce")
+	require.NoError(t, err)
+	parsed, err := url.Parse(u)
+	require.NoError(t, err)
+	require.Equal(t, idp.URL+"/authorize",
+		strings.Split(parsed.String(), "?")[0])
+	q := parsed.Query()
+	require.Equal(t, "code", q.Get("response_type"))
+	require.Equal(t, oidctest.ClientID, q.Get("client_id"))
+	require.Equal(t, "http://golinks.test/auth/sso/callback",
+		q.Get("redirect_uri"))
+	require.Equal(t, "openid email profile", q.Get("scope"))
+	require.Equal(t, "state", q.Get("state"))
+	require.Equal(t, "nonce", q.Get("nonce"))
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewServer()
+	defer idp.Close()
+	client := oidc.New(oidc.Config{Issuer: idp.URL + "/other"})
+	_, err := client.AuthCodeURL(context.Background(), "state", "nonce")
+	require.True(t, errors.Is(err, oidc.ErrDiscovery), err)
+}
+
+func TestExchange(t *testing.T) {
+	ctx := context.Background()
+	idp := oidctest.NewServer()
+	defer idp.Close()
+	client := newClient(idp, oidc.Config{
+		AllowedDomains: []string{"haostudio.tw", "example.com"},
+		DomainOrgs:     map[string]string{"haostudio.tw": "haostudio"},
+		OrgClaim:       "golinks_org",
+	})
+
+	// org by domain
+	identity, err := client.Exchange(
+		ctx, idp.Code("Hao@haostudio.tw", "n1"), "n1")
+	require.NoError(t, err)
+	require.Equal(t, oidc.Identity{
+		Subject: "sub-Hao@haostudio.tw",
+		Email:   "hao@haostudio.tw",
+		Org:     "haostudio",
+	}, identity)
+
+	// org by claim
+	idp.SetClaims("a@example.com", jwt.MapClaims{
+		"golinks_org": []interface{}{"example"},
+	})
+	identity, err = client.Exchange(ctx, idp.Code("a@example.com", "n2"), "n2")
+	require.NoError(t, err)
+	require.Equal(t, "example", identity.Org)
+
+	// no org
+	identity, err = client.Exchange(ctx, idp.Code("b@example.com", "n3"), "n3")
+	require.NoError(t, err)
+	require.Equal(t, "", identity.Org)
+
+	// code is used once
+	code := idp.Code("b@example.com", "n4")
+	_, err = client.Exchange(ctx, code, "n4")
+	require.NoError(t, err)
+	_, err = client.Exchange(ctx, code, "n4")
+	require.True(t, errors.Is(err, oidc.ErrExchange), err)
+}
+
+func TestExchangeRejected(t *testing.T) {
+	ctx := context.Background()
+	idp := oidctest.NewServer()
+	defer idp.Close()
+	client := newClient(idp, oidc.Config{
+		AllowedDomains: []string{"haostudio.tw"},
+	})
+
+	// nonce mismatch
+	_, err := client.Exchange(ctx, idp.Code("a@haostudio.tw", "n"), "other")
+	require.True(t, errors.Is(err, oidc.ErrInvalidIDToken), err)
+
+	// domain not allowed
+	_, err = client.Exchange(ctx, idp.Code("a@example.com", "n"), "n")
+	require.True(t, errors.Is(err, oidc.ErrDomainNotAllowed), err)
+
+	// email not verified
+	idp.SetClaims("b@haostudio.tw", jwt.MapClaims{"email_verified": false})
+	_, err = client.Exchange(ctx, idp.Code("b@haostudio.tw", "n"), "n")
+	require.True(t, errors.Is(err, oidc.ErrEmailNotVerified), err)
+	idp.SetClaims("b@haostudio.tw", jwt.MapClaims{"email_verified": nil})
+	_, err = client.Exchange(ctx, idp.Code("b@haostudio.tw", "n"), "n")
+	require.True(t, errors.Is(err, oidc.ErrEmailNotVerified), err)
+
+	// audience mismatch
+	idp.SetClaims("c@haostudio.tw", jwt.MapClaims{
+		"aud": []interface{}{"other-client"},
+	})
+	_, err = client.Exchange(ctx, idp.Code("c@haostudio.tw", "n"), "n")
+	require.True(t, errors.Is(err, oidc.ErrInvalidIDToken), err)
+
+	// issuer mismatch
+	idp.SetClaims("d@haostudio.tw", jwt.MapClaims{"iss": "http://evil.test"})
+	_, err = client.Exchange(ctx, idp.Code("d@haostudio.tw", "n"), "n")
+	require.True(t, errors.Is(err, oidc.ErrInvalidIDToken), err)
+
+	// expired
+	idp.SetClaims("e@haostudio.tw", jwt.MapClaims{
+		"exp": time.Now().Add(-time.Minute).Unix(),
+	})
+	_, err = client.Exchange(ctx, idp.Code("e@haostudio.tw", "n"), "n")
+	require.True(t, errors.Is(err, oidc.ErrInvalidIDToken), err)
+
+	// missing email
+	_, err = client.Exchange(ctx, idp.Code("", "n"), "n")
+	require.True(t, errors.Is(err, oidc.ErrInvalidIDToken), err)
+}
+
+func TestExchangeMissingEmailVerified(t *testing.T) {
+	ctx := context.Background()
+	idp := oidctest.NewServer()
+	defer idp.Close()
+	client := newClient(idp, oidc.Config{AllowMissingEmailVerified: true})
+
+	idp.SetClaims("a@haostudio.tw", jwt.MapClaims{"email_verified": nil})
+	identity, err := client.Exchange(ctx, idp.Code("a@haostudio.tw", "n"), "n")
+	require.NoError(t, err)
+	require.Equal(t, "a@haostudio.tw", identity.Email)
+
+	// still rejected if the email is not verified
+	idp.SetClaims("b@haostudio.tw", jwt.MapClaims{"email_verified": false})
+	_, err = client.Exchange(ctx, idp.Code("b@haostudio.tw", "n"), "n")
+	require.True(t, errors.Is(err, oidc.ErrEmailNotVerified), err)
+}
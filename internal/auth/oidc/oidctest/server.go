@@ -0,0 +1,170 @@
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Stub IdP credentials.
+const (
+	ClientID     = "golinks"
+	ClientSecret = "golinks-secret"
+	KeyID        = "stub-key"
+)
+
+// NewServer starts a stub OpenID Connect provider. The authorization endpoint
+// redirects back immediately with a code issuing an ID token with the claims
+// of the email given in the login_hint parameter.
+func NewServer() *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{
+		Key:    key,
+		claims: make(map[string]jwt.MapClaims),
+		codes:  make(map[string]jwt.MapClaims),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("/authorize", s.handleAuthorize)
+	mux.HandleFunc("/token", s.handleToken)
+	mux.HandleFunc("/jwks", s.handleJWKS)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Server defines the stub OpenID Connect provider.
+type Server struct {
+	*httptest.Server
+	Key *rsa.PrivateKey
+
+	mutex  sync.Mutex
+	claims map[string]jwt.MapClaims
+	codes  map[string]jwt.MapClaims
+}
+
+// SetClaims sets the extra ID token claims of email, which override the
+// default ones.
+func (s *Server) SetClaims(email string, claims jwt.MapClaims) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.claims[email] = claims
+}
+
+// Code issues an authorization code of email with nonce.
+func (s *Server) Code(email, nonce string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            s.URL,
+		"sub":            "sub-" + email,
+		"aud":            ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          nonce,
+		"email":          email,
+		"email_verified": true,
+	}
+	for k, v := range s.claims[email] {
+		claims[k] = v
+	}
+	code := fmt.Sprintf("code-%d", len(s.codes))
+	s.codes[code] = claims
+	return code
+}
+
+// Sign signs claims as an ID token.
+func (s *Server) Sign(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = KeyID
+	signed, err := token.SignedString(s.Key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{
+		"issuer":                 s.URL,
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"jwks_uri":               s.URL + "/jwks",
+	})
+}
+
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != ClientID {
+		http.Error(w, "unknown client", http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := redirect.Query()
+	params.Set("code", s.Code(q.Get("login_hint"), q.Get("nonce")))
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != ClientID || secret != ClientSecret {
+		http.Error(w, "invalid client", http.StatusUnauthorized)
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		http.Error(w, "unsupported grant type", http.StatusBadRequest)
+		return
+	}
+	s.mutex.Lock()
+	claims, ok := s.codes[r.PostFormValue("code")]
+	delete(s.codes, r.PostFormValue("code"))
+	s.mutex.Unlock()
+	if !ok {
+		http.Error(w, "invalid code", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, map[string]string{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"id_token":     s.Sign(claims),
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	pub := s.Key.PublicKey
+	writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": KeyID,
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(
+				big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -10,18 +10,20 @@ import (
 // NewToken generates a new signed JWT token.
 func NewToken(user User, secret []byte, expiration time.Duration) (
 	token *Token, err error) {
-	jwt, err := genToken(tokenParams{
+	params := tokenParams{
 		User:      user,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(expiration),
 		Secret:    secret,
-	})
+	}
+	jwt, err := genToken(params)
 	if err != nil {
 		err = fmt.Errorf("%v; %w", err, ErrInternalError)
 		return
 	}
 	token = &Token{
-		JWT: jwt,
+		JWT:       jwt,
+		ExpiredAt: time.Unix(params.ExpiredAt.Unix(), 0),
 	}
 	return
 }
@@ -29,6 +31,8 @@ func NewToken(user User, secret []byte, expiration time.Duration) (
 // Token defines the token model.
 type Token struct {
 	JWT string
+	// ExpiredAt is the expiry of the JWT. Zero means it never expires.
+	ExpiredAt time.Time
 }
 
 type tokenParams struct {
@@ -57,7 +61,7 @@ func (c *TokenClaims) Valid() error {
 func genToken(params tokenParams) (string, error) {
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, &TokenClaims{
 		Email:     params.User.Email,
-		Org:       params.User.Organization,
+		Org:       params.User.DefaultOrg(),
 		IssuedAt:  params.IssuedAt.Unix(),
 		ExpiredAt: params.ExpiredAt.Unix(),
 	})
@@ -14,7 +14,7 @@ func TestToken(t *testing.T) {
 		User: User{
 			Email:        "hi",
 			PasswordHash: []byte("how are you"),
-			Organization: "hello",
+			Orgs:         []string{"hello", "world"},
 		},
 		IssuedAt:  now,
 		ExpiredAt: now.Add(1),
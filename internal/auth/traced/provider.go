@@ -100,6 +100,42 @@ func (p *provider) DeleteToken(ctx context.Context, token string) error {
 	return p.provider.DeleteToken(ctx, token)
 }
 
+// personal API tokens
+func (p *provider) GetAPIToken(ctx context.Context, id string) (
+	auth.APIToken, error) {
+	ctx, span := p.getSpan(ctx, "provider.GetAPIToken")
+	defer span.End()
+	return p.provider.GetAPIToken(ctx, id)
+}
+
+func (p *provider) GetAPITokens(ctx context.Context, email string) (
+	[]auth.APIToken, error) {
+	ctx, span := p.getSpan(ctx, "provider.GetAPITokens")
+	defer span.End()
+	return p.provider.GetAPITokens(ctx, email)
+}
+
+func (p *provider) SetAPIToken(ctx context.Context, token auth.APIToken) error {
+	ctx, span := p.getSpan(ctx, "provider.SetAPIToken")
+	defer span.End()
+	return p.provider.SetAPIToken(ctx, token)
+}
+
+func (p *provider) DeleteAPIToken(ctx context.Context, id string) error {
+	ctx, span := p.getSpan(ctx, "provider.DeleteAPIToken")
+	defer span.End()
+	return p.provider.DeleteAPIToken(ctx, id)
+}
+
+func (p *provider) Update(
+	ctx context.Context, f func(tx auth.Provider) error) error {
+	ctx, span := p.getSpan(ctx, "provider.Update")
+	defer span.End()
+	return p.provider.Update(ctx, func(tx auth.Provider) error {
+		return f(&provider{tx})
+	})
+}
+
 func (p *provider) String() string {
 	return fmt.Sprintf("traced(%s)", p.provider)
 }
@@ -13,4 +13,6 @@ func TestLogic(t *testing.T) {
 	provider := kv.New(memory.New().In("test"), gob.New())
 	provider = New(provider)
 	authtest.ProviderLogicTest(t, provider)
+	authtest.ProviderTokenTest(t, provider)
+	authtest.ProviderAPITokenTest(t, provider)
 }
@@ -1,9 +1,11 @@
 package bolt
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	bolt "go.etcd.io/bbolt"
 
@@ -13,6 +15,8 @@ import (
 type namespace struct {
 	store  *store
 	bucket []string
+	// tx is the transaction the namespace is bound to, if any.
+	tx *bolt.Tx
 }
 
 // In returns the namespace instance with path.
@@ -22,13 +26,13 @@ func (n *namespace) In(path ...string) kv.Namespace {
 	}
 	b := append(n.bucket[:0:0], n.bucket...)
 	b = append(b, path...)
-	return &namespace{n.store, b}
+	return &namespace{n.store, b, n.tx}
 }
 
 // Get returns the value in the namespace with key.
 func (n *namespace) Get(ctx context.Context, keyStr string) (
 	b []byte, err error) {
-	err = n.store.db.View(func(tx *bolt.Tx) error {
+	err = n.view(func(tx *bolt.Tx) error {
 		root := n.root(tx)
 		if root == nil {
 			return kv.ErrNotFound
@@ -38,6 +42,9 @@ func (n *namespace) Get(ctx context.Context, keyStr string) (
 			return kv.ErrNotFound
 		}
 		b = bucket.Get(key(keyStr))
+		if b != nil && expired(root, n.bucket, key(keyStr), time.Now()) {
+			b = nil
+		}
 		return nil
 	})
 	if err != nil {
@@ -53,7 +60,66 @@ func (n *namespace) Get(ctx context.Context, keyStr string) (
 // Set sets the value in the namespace with key.
 func (n *namespace) Set(
 	ctx context.Context, keyStr string, value []byte) error {
-	return n.store.db.Update(func(tx *bolt.Tx) error {
+	return n.set(keyStr, value, time.Time{})
+}
+
+// SetWithTTL sets the value in the namespace with key, which expires after
+// ttl.
+func (n *namespace) SetWithTTL(ctx context.Context,
+	keyStr string, value []byte, ttl time.Duration) error {
+	return n.set(keyStr, value, time.Now().Add(ttl))
+}
+
+func (n *namespace) set(keyStr string, value []byte, expiry time.Time) error {
+	return n.update(func(tx *bolt.Tx) error {
+		root, err := n.rootIfNotExists(tx)
+		if err != nil {
+			return err
+		}
+		bucket, err := n.getBucketIfNotExists(root, n.bucket...)
+		if err != nil {
+			return err
+		}
+		err = bucket.Put(key(keyStr), value)
+		if err != nil {
+			return err
+		}
+		return setExpiry(root, n.bucket, key(keyStr), expiry)
+	})
+}
+
+// TTL returns the remaining time to live of the value with key.
+func (n *namespace) TTL(ctx context.Context, keyStr string) (
+	ttl time.Duration, err error) {
+	err = n.view(func(tx *bolt.Tx) error {
+		root := n.root(tx)
+		if root == nil {
+			return kv.ErrNotFound
+		}
+		bucket := n.getBucket(root, n.bucket...)
+		if bucket == nil || bucket.Get(key(keyStr)) == nil {
+			return kv.ErrNotFound
+		}
+		t := getExpiry(root, n.bucket, key(keyStr))
+		if t.IsZero() {
+			return nil
+		}
+		ttl = time.Until(t)
+		if ttl <= 0 {
+			return kv.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		ttl = 0
+	}
+	return
+}
+
+// CompareAndSet sets the value with key if the current value equals old.
+func (n *namespace) CompareAndSet(
+	ctx context.Context, keyStr string, old, value []byte) error {
+	return n.update(func(tx *bolt.Tx) error {
 		root, err := n.rootIfNotExists(tx)
 		if err != nil {
 			return err
@@ -62,13 +128,24 @@ func (n *namespace) Set(
 		if err != nil {
 			return err
 		}
-		return bucket.Put(key(keyStr), value)
+		cur := bucket.Get(key(keyStr))
+		if cur != nil && expired(root, n.bucket, key(keyStr), time.Now()) {
+			cur = nil
+		}
+		if !kv.Equal(cur, old) {
+			return kv.ErrConflict
+		}
+		err = bucket.Put(key(keyStr), value)
+		if err != nil {
+			return err
+		}
+		return setExpiry(root, n.bucket, key(keyStr), time.Time{})
 	})
 }
 
 // Delete deletes the value in the namespace with key.
 func (n *namespace) Delete(ctx context.Context, keyStr string) error {
-	return n.store.db.Update(func(tx *bolt.Tx) error {
+	return n.update(func(tx *bolt.Tx) error {
 		root := n.root(tx)
 		if root == nil {
 			return nil
@@ -77,14 +154,18 @@ func (n *namespace) Delete(ctx context.Context, keyStr string) error {
 		if bucket == nil {
 			return nil
 		}
-		return bucket.Delete(key(keyStr))
+		err := bucket.Delete(key(keyStr))
+		if err != nil {
+			return err
+		}
+		return setExpiry(root, n.bucket, key(keyStr), time.Time{})
 	})
 }
 
 // Iterate iterates the values in the namespace.
 func (n *namespace) Iterate(
 	ctx context.Context, f func(key string, value []byte) (next bool)) error {
-	return n.store.db.View(func(tx *bolt.Tx) error {
+	return n.view(func(tx *bolt.Tx) error {
 		root := n.root(tx)
 		if root == nil {
 			return kv.ErrNotFound
@@ -93,8 +174,16 @@ func (n *namespace) Iterate(
 		if bucket == nil {
 			return kv.ErrNotFound
 		}
+		now := time.Now()
 		cursor := bucket.Cursor()
 		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			if v == nil {
+				// nested bucket of a child namespace
+				continue
+			}
+			if expired(root, n.bucket, k, now) {
+				continue
+			}
 			next := f(string(k), v)
 			if !next {
 				return nil
@@ -104,9 +193,47 @@ func (n *namespace) Iterate(
 	})
 }
 
+// IterateFrom iterates the values with keys having prefix and not less than
+// start in the key order.
+func (n *namespace) IterateFrom(ctx context.Context, prefix, start string,
+	f func(key string, value []byte) (next bool)) error {
+	if start < prefix {
+		start = prefix
+	}
+	return n.view(func(tx *bolt.Tx) error {
+		root := n.root(tx)
+		if root == nil {
+			return kv.ErrNotFound
+		}
+		bucket := n.getBucket(root, n.bucket...)
+		if bucket == nil {
+			return kv.ErrNotFound
+		}
+		now := time.Now()
+		cursor := bucket.Cursor()
+		p := key(prefix)
+		for k, v := cursor.Seek(key(start)); k != nil; k, v = cursor.Next() {
+			if !bytes.HasPrefix(k, p) {
+				break
+			}
+			if v == nil {
+				// nested bucket of a child namespace
+				continue
+			}
+			if expired(root, n.bucket, k, now) {
+				continue
+			}
+			if !f(string(k), v) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
 // Drop drops all the values in the namespace.
 func (n *namespace) Drop(ctx context.Context) error {
-	return n.store.db.Update(func(tx *bolt.Tx) error {
+	return n.update(func(tx *bolt.Tx) error {
 		if len(n.bucket) == 0 {
 			// drop everything
 			err := tx.DeleteBucket(key(n.store.root))
@@ -131,10 +258,65 @@ func (n *namespace) Drop(ctx context.Context) error {
 		if errors.Is(err, bolt.ErrBucketNotFound) {
 			return nil
 		}
-		return err
+		if err != nil {
+			return err
+		}
+		return dropExpiry(root, n.bucket)
 	})
 }
 
+// Namespaces returns the sorted names of the child namespaces.
+func (n *namespace) Namespaces(ctx context.Context) (names []string,
+	err error) {
+	err = n.view(func(tx *bolt.Tx) error {
+		root := n.root(tx)
+		if root == nil {
+			return kv.ErrNotFound
+		}
+		bucket := n.getBucket(root, n.bucket...)
+		if bucket == nil {
+			return kv.ErrNotFound
+		}
+		cursor := bucket.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			if v == nil && string(k) != ttlBucket {
+				names = append(names, string(k))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		names = nil
+	}
+	return
+}
+
+// Update runs f in a read-write transaction.
+func (n *namespace) Update(
+	ctx context.Context, f func(tx kv.Namespace) error) error {
+	if n.tx != nil {
+		// already in a transaction
+		return f(n)
+	}
+	return n.store.db.Update(func(tx *bolt.Tx) error {
+		return f(&namespace{n.store, n.bucket, tx})
+	})
+}
+
+func (n *namespace) view(f func(tx *bolt.Tx) error) error {
+	if n.tx != nil {
+		return f(n.tx)
+	}
+	return n.store.db.View(f)
+}
+
+func (n *namespace) update(f func(tx *bolt.Tx) error) error {
+	if n.tx != nil {
+		return f(n.tx)
+	}
+	return n.store.db.Update(f)
+}
+
 func (n *namespace) root(tx *bolt.Tx) *bolt.Bucket {
 	return tx.Bucket(key(n.store.root))
 }
@@ -1,9 +1,12 @@
 package bolt
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	bolt "go.etcd.io/bbolt"
 
@@ -13,6 +16,9 @@ import (
 type store struct {
 	path, name, root string
 	db               *bolt.DB
+	// stop stops the compaction goroutine.
+	stop context.CancelFunc
+	wg   sync.WaitGroup
 }
 
 // New returns a bolt kev-value store.
@@ -37,18 +43,56 @@ func New(path, name, root string) (kv.Store, error) {
 		root: root,
 		db:   db,
 	}
+	ctx, stop := context.WithCancel(context.Background())
+	s.stop = stop
+	s.wg.Add(1)
+	go s.compactLoop(ctx)
 	return s, nil
 }
 
 // Close finalizes a kv.Store.
 func (s *store) Close() error {
+	s.stop()
+	s.wg.Wait()
 	file := filepath.Join(s.path, s.name)
 	return close(file)
 }
 
 // In returns the namespace instance with path.
 func (s *store) In(path ...string) kv.Namespace {
-	return &namespace{s, path}
+	return &namespace{s, path, nil}
+}
+
+// Update runs f in a read-write transaction.
+func (s *store) Update(
+	ctx context.Context, f func(tx kv.Namespace) error) error {
+	return s.In().Update(ctx, f)
+}
+
+// compact deletes the expired values in the store.
+func (s *store) compact(now time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(key(s.root))
+		if root == nil {
+			return nil
+		}
+		return compact(root, now)
+	})
+}
+
+func (s *store) compactLoop(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(compactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// the expired values are still hidden if compaction fails.
+			_ = s.compact(now)
+		}
+	}
 }
 
 func (s *store) String() string {
@@ -1,6 +1,7 @@
 package bolt
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
 
 	"github.com/haostudio/golinks/internal/kv/kvtest"
 )
@@ -23,9 +25,15 @@ func TestLogic(t *testing.T) {
 	store, err := New(dbPath, dbName, "test")
 	require.NoError(t, err)
 	kvtest.StoreLogicTest(t, store)
+	kvtest.StoreUpdateTest(t, store)
+	kvtest.StoreCompareAndSetTest(t, store)
+	kvtest.StoreIterateFromTest(t, store)
+	kvtest.StoreTTLTest(t, store)
+	kvtest.StoreSlashKeyTest(t, store)
 	store, err = New(dbPath, dbName, "test")
 	require.NoError(t, err)
 	kvtest.StoreLogicTest(t, store)
+	kvtest.StoreWalkTest(t, store)
 	// Clean up
 	require.NoError(t, os.RemoveAll(dbPath))
 }
@@ -44,3 +52,70 @@ func TestConcurrent(t *testing.T) {
 	// Clean up
 	require.NoError(t, os.RemoveAll(dbPath))
 }
+
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	// Prepare DB path
+	dir, err := os.Getwd()
+	require.NoError(t, err)
+	dbPath := filepath.Join(dir,
+		fmt.Sprintf("bolt_test_%d", time.Now().UnixNano()))
+	defer func() {
+		require.NoError(t, os.RemoveAll(dbPath))
+	}()
+	kvStore, err := New(dbPath, "test.db", "test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, kvStore.Close())
+	}()
+	s := kvStore.(*store)
+
+	ns := s.In("NAMESPACE")
+	require.NoError(t, ns.SetWithTTL(ctx, "expired", []byte("v"), time.Hour))
+	require.NoError(t, ns.In("CHILD").SetWithTTL(
+		ctx, "expired", []byte("v"), time.Hour))
+	require.NoError(t, ns.SetWithTTL(ctx, "kept", []byte("v"), 3*time.Hour))
+	require.NoError(t, ns.Set(ctx, "forever", []byte("v")))
+
+	// the ttl bucket is not a namespace
+	names, err := s.In().Namespaces(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"NAMESPACE"}, names)
+
+	require.NoError(t, s.compact(time.Now().Add(2*time.Hour)))
+	values := func(path ...string) (n int) {
+		require.NoError(t, s.db.View(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket(key(s.root))
+			for _, p := range path {
+				bucket = bucket.Bucket(key(p))
+			}
+			return bucket.ForEach(func(k, v []byte) error {
+				if v != nil {
+					n++
+				}
+				return nil
+			})
+		}))
+		return
+	}
+	expiries := func() (n int) {
+		require.NoError(t, s.db.View(func(tx *bolt.Tx) error {
+			ttl := tx.Bucket(key(s.root)).Bucket(key(ttlBucket))
+			if ttl != nil {
+				n = ttl.Stats().KeyN
+			}
+			return nil
+		}))
+		return
+	}
+	require.Equal(t, 2, values("NAMESPACE"))
+	require.Equal(t, 0, values("NAMESPACE", "CHILD"))
+	require.Equal(t, 1, expiries())
+
+	// dropping a namespace drops the expiry times of its keys
+	require.NoError(t, ns.In("CHILD").SetWithTTL(
+		ctx, "dropped", []byte("v"), time.Hour))
+	require.Equal(t, 2, expiries())
+	require.NoError(t, ns.In("CHILD").Drop(ctx))
+	require.Equal(t, 1, expiries())
+}
@@ -0,0 +1,151 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/haostudio/golinks/internal/kv"
+)
+
+// ttlBucket is the bucket in the root bucket holding the expiry times of the
+// keys set with TTL, keyed by their namespace path and key. Compaction only
+// scans it instead of the whole store.
+const ttlBucket = "github.com/haostudio/golinks/internal/kv/bolt.ttl"
+
+const compactInterval = time.Minute
+
+func encodeExpiry(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(t.UnixNano()))
+	return b
+}
+
+func decodeExpiry(b []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(b)))
+}
+
+// expiryKey returns the key of k in the namespace at path in the ttl bucket.
+func expiryKey(path []string, k []byte) []byte {
+	escaped := make([]string, len(path)+1)
+	for i, name := range path {
+		escaped[i] = kv.EscapeKey(name)
+	}
+	escaped[len(path)] = kv.EscapeKey(string(k))
+	return key(strings.Join(escaped, "/"))
+}
+
+// splitExpiryKey returns the namespace path and the key of the ttl bucket
+// key b.
+func splitExpiryKey(b []byte) (path []string, k []byte) {
+	names := strings.Split(string(b), "/")
+	for i := range names {
+		names[i] = kv.UnescapeKey(names[i])
+	}
+	last := len(names) - 1
+	return names[:last], key(names[last])
+}
+
+// getExpiry returns the expiry time of k in the namespace at path, or zero
+// time if it never expires.
+func getExpiry(root *bolt.Bucket, path []string, k []byte) time.Time {
+	ttl := root.Bucket(key(ttlBucket))
+	if ttl == nil {
+		return time.Time{}
+	}
+	b := ttl.Get(expiryKey(path, k))
+	if b == nil {
+		return time.Time{}
+	}
+	return decodeExpiry(b)
+}
+
+// expired returns true if k in the namespace at path has expired at now.
+func expired(root *bolt.Bucket, path []string, k []byte, now time.Time) bool {
+	t := getExpiry(root, path, k)
+	return !t.IsZero() && !now.Before(t)
+}
+
+// setExpiry sets the expiry time of k in the namespace at path. Zero time
+// clears it.
+func setExpiry(root *bolt.Bucket, path []string, k []byte,
+	t time.Time) error {
+	if t.IsZero() {
+		ttl := root.Bucket(key(ttlBucket))
+		if ttl == nil {
+			return nil
+		}
+		return ttl.Delete(expiryKey(path, k))
+	}
+	ttl, err := root.CreateBucketIfNotExists(key(ttlBucket))
+	if err != nil {
+		return err
+	}
+	return ttl.Put(expiryKey(path, k), encodeExpiry(t))
+}
+
+// compact deletes the keys expired at now in the root bucket.
+func compact(root *bolt.Bucket, now time.Time) error {
+	ttl := root.Bucket(key(ttlBucket))
+	if ttl == nil {
+		return nil
+	}
+	var keys [][]byte
+	cursor := ttl.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		if !now.Before(decodeExpiry(v)) {
+			keys = append(keys, append(k[:0:0], k...))
+		}
+	}
+	// modifying the bucket while iterating it is not safe.
+	for _, k := range keys {
+		path, valueKey := splitExpiryKey(k)
+		bucket := root
+		for _, name := range path {
+			if bucket == nil {
+				break
+			}
+			bucket = bucket.Bucket(key(name))
+		}
+		// the namespace may be dropped already.
+		if bucket != nil {
+			err := bucket.Delete(valueKey)
+			if err != nil {
+				return err
+			}
+		}
+		err := ttl.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropExpiry deletes the expiry times of the keys in the namespace at path and
+// its child namespaces.
+func dropExpiry(root *bolt.Bucket, path []string) error {
+	ttl := root.Bucket(key(ttlBucket))
+	if ttl == nil {
+		return nil
+	}
+	var keys [][]byte
+	prefix := expiryKey(path, nil)
+	cursor := ttl.Cursor()
+	for k, _ := cursor.Seek(prefix); k != nil; k, _ = cursor.Next() {
+		if !bytes.HasPrefix(k, prefix) {
+			break
+		}
+		keys = append(keys, append(k[:0:0], k...))
+	}
+	for _, k := range keys {
+		err := ttl.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
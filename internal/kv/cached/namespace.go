@@ -2,7 +2,9 @@ package cached
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/haostudio/golinks/internal/kv"
 )
@@ -12,10 +14,15 @@ type namespace struct {
 	root      []string
 	canonical kv.Namespace
 	cache     kv.Namespace
+	// tx is the transaction the namespace is bound to, if any.
+	tx *transaction
 }
 
 // In returns the namespace instance with path.
 func (n *namespace) In(path ...string) kv.Namespace {
+	if n.tx != nil {
+		return n.txIn(path...)
+	}
 	p := append(n.root[:0:0], n.root...)
 	p = append(p, path...)
 	return n.store.In(p...)
@@ -23,6 +30,10 @@ func (n *namespace) In(path ...string) kv.Namespace {
 
 // Get returns the value in the namespace with key.
 func (n *namespace) Get(ctx context.Context, key string) ([]byte, error) {
+	if n.tx != nil {
+		// the cache doesn't hold the changes of the transaction
+		return n.canonical.Get(ctx, key)
+	}
 	val, err := n.cache.Get(ctx, key)
 	if err == nil {
 		return val, nil
@@ -31,18 +42,77 @@ func (n *namespace) Get(ctx context.Context, key string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	// best effort to set the cache
+	// best effort to set the cache, which must not outlive the canonical value.
+	ttl, err := n.canonical.TTL(ctx, key)
+	if err != nil {
+		return val, nil
+	}
 	cloned := append(val[:0:0], val...)
-	_ = n.cache.Set(ctx, key, cloned)
+	if ttl > 0 {
+		_ = n.cache.SetWithTTL(ctx, key, cloned, ttl)
+	} else {
+		_ = n.cache.Set(ctx, key, cloned)
+	}
 	return val, nil
 }
 
+// TTL returns the remaining time to live of the value with key from the
+// canonical store.
+func (n *namespace) TTL(ctx context.Context, key string) (
+	time.Duration, error) {
+	return n.canonical.TTL(ctx, key)
+}
+
 // Set sets the value in the namespace with key.
 func (n *namespace) Set(ctx context.Context, key string, value []byte) error {
 	err := n.canonical.Set(ctx, key, value)
 	if err != nil {
 		return err
 	}
+	if n.tx != nil {
+		n.tx.invalidate(n.cache, key)
+		return nil
+	}
+	// best effort to set the cache
+	cloned := append(value[:0:0], value...)
+	_ = n.cache.Set(ctx, key, cloned)
+	return nil
+}
+
+// SetWithTTL sets the value in the namespace with key, which expires after
+// ttl.
+func (n *namespace) SetWithTTL(ctx context.Context,
+	key string, value []byte, ttl time.Duration) error {
+	err := n.canonical.SetWithTTL(ctx, key, value, ttl)
+	if err != nil {
+		return err
+	}
+	if n.tx != nil {
+		n.tx.invalidate(n.cache, key)
+		return nil
+	}
+	// best effort to set the cache
+	cloned := append(value[:0:0], value...)
+	_ = n.cache.SetWithTTL(ctx, key, cloned, ttl)
+	return nil
+}
+
+// CompareAndSet sets the value with key if the current value equals old.
+func (n *namespace) CompareAndSet(
+	ctx context.Context, key string, old, value []byte) error {
+	err := n.canonical.CompareAndSet(ctx, key, old, value)
+	if errors.Is(err, kv.ErrConflict) {
+		// best effort to drop the stale cache
+		_ = n.cache.Delete(ctx, key)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if n.tx != nil {
+		n.tx.invalidate(n.cache, key)
+		return nil
+	}
 	// best effort to set the cache
 	cloned := append(value[:0:0], value...)
 	_ = n.cache.Set(ctx, key, cloned)
@@ -55,6 +125,10 @@ func (n *namespace) Delete(ctx context.Context, key string) error {
 	if err != nil {
 		return err
 	}
+	if n.tx != nil {
+		n.tx.invalidate(n.cache, key)
+		return nil
+	}
 	// best effort to delete cache
 	_ = n.cache.Delete(ctx, key)
 	return nil
@@ -63,24 +137,101 @@ func (n *namespace) Delete(ctx context.Context, key string) error {
 // Iterate iterates the values in the namespace.
 func (n *namespace) Iterate(
 	ctx context.Context, f func(key string, value []byte) (next bool)) error {
-	err := n.cache.Iterate(ctx, f)
-	if err == nil {
-		return nil
-	}
+	// the cache may hold only part of the values.
 	return n.canonical.Iterate(ctx, f)
 }
 
+// IterateFrom iterates the values with keys having prefix and not less than
+// start in the key order.
+func (n *namespace) IterateFrom(ctx context.Context, prefix, start string,
+	f func(key string, value []byte) (next bool)) error {
+	return n.canonical.IterateFrom(ctx, prefix, start, f)
+}
+
+// Namespaces returns the sorted names of the child namespaces.
+func (n *namespace) Namespaces(ctx context.Context) ([]string, error) {
+	return n.canonical.Namespaces(ctx)
+}
+
 // Drop drops all the values in the namespace.
 func (n *namespace) Drop(ctx context.Context) error {
 	err := n.canonical.Drop(ctx)
 	if err != nil {
 		return err
 	}
+	if n.tx != nil {
+		n.tx.invalidate(n.cache, "")
+		return nil
+	}
 	// best effort to delete cache
 	_ = n.cache.Drop(ctx)
 	return nil
 }
 
+// Update runs f in a transaction of the canonical store. The cache of the
+// values changed in the transaction is invalidated afterwards.
+func (n *namespace) Update(
+	ctx context.Context, f func(tx kv.Namespace) error) error {
+	if n.tx != nil {
+		// already in a transaction
+		return f(n)
+	}
+	tx := &transaction{}
+	err := n.canonical.Update(ctx, func(canonical kv.Namespace) error {
+		return f(&namespace{
+			store:     n.store,
+			root:      n.root,
+			canonical: canonical,
+			cache:     n.cache,
+			tx:        tx,
+		})
+	})
+	// best effort to invalidate the cache
+	tx.flush(ctx)
+	return err
+}
+
+func (n *namespace) txIn(path ...string) kv.Namespace {
+	if len(path) == 0 {
+		return n
+	}
+	p := append(n.root[:0:0], n.root...)
+	p = append(p, path...)
+	return &namespace{
+		store:     n.store,
+		root:      p,
+		canonical: n.canonical.In(path...),
+		cache:     n.cache.In(path...),
+		tx:        n.tx,
+	}
+}
+
 func (n *namespace) String() string {
 	return fmt.Sprintf("cached.namespace(%s/%s)", n.canonical, n.cache)
 }
+
+// transaction collects the cache to invalidate after a transaction.
+type transaction struct {
+	caches []cacheKey
+}
+
+type cacheKey struct {
+	cache kv.Namespace
+	// key is the key to delete, or empty to drop the whole cache namespace.
+	key string
+}
+
+func (tx *transaction) invalidate(cache kv.Namespace, key string) {
+	tx.caches = append(tx.caches, cacheKey{cache, key})
+}
+
+func (tx *transaction) flush(ctx context.Context) {
+	for _, c := range tx.caches {
+		if c.key == "" {
+			_ = c.cache.Drop(ctx)
+			continue
+		}
+		_ = c.cache.Delete(ctx, c.key)
+	}
+	tx.caches = nil
+}
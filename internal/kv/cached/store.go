@@ -1,6 +1,7 @@
 package cached
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -58,6 +59,12 @@ func (s *store) In(path ...string) kv.Namespace {
 	return s.namespaces.m[key]
 }
 
+// Update runs f in a transaction on the root namespace.
+func (s *store) Update(
+	ctx context.Context, f func(tx kv.Namespace) error) error {
+	return s.In().Update(ctx, f)
+}
+
 func (s *store) String() string {
 	return fmt.Sprintf("cached.store(%s/%s)", s.canonical, s.cache)
 }
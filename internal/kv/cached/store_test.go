@@ -1,8 +1,14 @@
 package cached
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/kv"
 	"github.com/haostudio/golinks/internal/kv/kvtest"
 	"github.com/haostudio/golinks/internal/kv/memory"
 )
@@ -10,9 +16,32 @@ import (
 func TestLogic(t *testing.T) {
 	store := New(memory.New(), memory.NewLRU(1<<10).In())
 	kvtest.StoreLogicTest(t, store)
+	kvtest.StoreUpdateTest(t, store)
+	kvtest.StoreCompareAndSetTest(t, store)
+	kvtest.StoreIterateFromTest(t, store)
+	kvtest.StoreTTLTest(t, store)
+	kvtest.StoreSlashKeyTest(t, store)
+	kvtest.StoreWalkTest(t, store)
 }
 
 func TestConcurrent(t *testing.T) {
 	store := New(memory.New(), memory.NewLRU(1<<10).In())
 	kvtest.StoreConcurrentTest(t, store, 1<<12, false)
 }
+
+func TestTTLCacheFill(t *testing.T) {
+	ctx := context.Background()
+	canonical := memory.New()
+	store := New(canonical, memory.NewLRU(1<<10).In())
+
+	ttl := 50 * time.Millisecond
+	require.NoError(t, canonical.In().SetWithTTL(ctx, "K", []byte("V"), ttl))
+	// fill the cache
+	val, err := store.In().Get(ctx, "K")
+	require.NoError(t, err)
+	require.Equal(t, []byte("V"), val)
+	// the cache expires with the canonical value
+	time.Sleep(2 * ttl)
+	_, err = store.In().Get(ctx, "K")
+	require.True(t, errors.Is(err, kv.ErrNotFound))
+}
@@ -0,0 +1,12 @@
+package kv
+
+import "bytes"
+
+// Equal reports whether the current value cur, which is nil if the value
+// doesn't exist, matches the value old expected by CompareAndSet.
+func Equal(cur, old []byte) bool {
+	if cur == nil || old == nil {
+		return cur == nil && old == nil
+	}
+	return bytes.Equal(cur, old)
+}
@@ -7,4 +7,5 @@ var (
 	ErrNotFound      = errors.New("value not found")
 	ErrInternalError = errors.New("internal store error")
 	ErrNotSupport    = errors.New("not support")
+	ErrConflict      = errors.New("value has been changed")
 )
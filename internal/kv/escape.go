@@ -0,0 +1,32 @@
+package kv
+
+import "strings"
+
+var (
+	keyEscaper = strings.NewReplacer(
+		"\x00", "\x00\x01",
+		"/", "\x00\x02",
+	)
+	keyUnescaper = strings.NewReplacer(
+		"\x00\x01", "\x00",
+		"\x00\x02", "/",
+	)
+)
+
+// EscapeKey returns key or namespace name without "/", for the backends
+// joining the namespace path and key with "/". Keys without "/" or "\x00" are
+// returned as is, so the existing encoded keys stay the same.
+func EscapeKey(key string) string {
+	if !strings.ContainsAny(key, "\x00/") {
+		return key
+	}
+	return keyEscaper.Replace(key)
+}
+
+// UnescapeKey returns the key escaped by EscapeKey.
+func UnescapeKey(key string) string {
+	if !strings.Contains(key, "\x00") {
+		return key
+	}
+	return keyUnescaper.Replace(key)
+}
@@ -0,0 +1,59 @@
+package kvtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/kv"
+)
+
+// NamespaceSlashKeyTest tests the kv.Namespace keys and namespace names
+// containing "/", which must not be mixed up with the nested namespaces.
+func NamespaceSlashKeyTest(t *testing.T, store kv.Namespace) {
+	ctx := context.Background()
+	defer require.NoError(t, store.Drop(ctx))
+
+	ns := store.In("NAMESPACE")
+	require.NoError(t, ns.Set(ctx, "a/b", []byte("a/b")))
+	require.NoError(t, ns.Set(ctx, "a/", []byte("a/")))
+	require.NoError(t, ns.Set(ctx, "a%2Fb", []byte("a%2Fb")))
+	require.NoError(t, ns.In("a").Set(ctx, "b", []byte("ns a/b")))
+	require.NoError(t, ns.In("c/d").Set(ctx, "e", []byte("ns c/d/e")))
+
+	for _, key := range []string{"a/b", "a/", "a%2Fb"} {
+		value, err := ns.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, key, string(value))
+	}
+	value, err := ns.In("a").Get(ctx, "b")
+	require.NoError(t, err)
+	require.Equal(t, "ns a/b", string(value))
+	value, err = ns.In("c/d").Get(ctx, "e")
+	require.NoError(t, err)
+	require.Equal(t, "ns c/d/e", string(value))
+	_, err = ns.In("c", "d").Get(ctx, "e")
+	require.True(t, errors.Is(err, kv.ErrNotFound))
+
+	var keys []string
+	err = ns.IterateFrom(ctx, "a", "", func(key string, value []byte) bool {
+		require.Equal(t, key, string(value))
+		keys = append(keys, key)
+		return true
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a%2Fb", "a/", "a/b"}, keys)
+
+	require.NoError(t, ns.In("a").Drop(ctx))
+	value, err = ns.Get(ctx, "a/b")
+	require.NoError(t, err)
+	require.Equal(t, "a/b", string(value))
+	require.NoError(t, ns.Delete(ctx, "a/b"))
+	_, err = ns.Get(ctx, "a/b")
+	require.True(t, errors.Is(err, kv.ErrNotFound))
+	value, err = ns.In("c/d").Get(ctx, "e")
+	require.NoError(t, err)
+	require.Equal(t, "ns c/d/e", string(value))
+}
@@ -0,0 +1,48 @@
+package kvtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/kv"
+)
+
+// NamespaceIterateFromTest tests the kv.Namespace prefix and range scans.
+func NamespaceIterateFromTest(t *testing.T, store kv.Namespace) {
+	ctx := context.Background()
+	defer require.NoError(t, store.Drop(ctx))
+
+	ns := store.In("NAMESPACE")
+	for _, key := range []string{
+		"b", "a", "eng-b", "eng-a", "eng", "z", "eng!", "eng/a",
+	} {
+		require.NoError(t, ns.Set(ctx, key, []byte(key)))
+	}
+	// values in the child namespaces are not scanned
+	require.NoError(t, ns.In("eng-c").Set(ctx, "eng-d", []byte("eng-d")))
+
+	scan := func(prefix, start string, limit int) []string {
+		var keys []string
+		err := ns.IterateFrom(ctx, prefix, start,
+			func(key string, value []byte) bool {
+				require.Equal(t, key, string(value))
+				keys = append(keys, key)
+				return len(keys) < limit
+			})
+		require.NoError(t, err)
+		return keys
+	}
+	require.Equal(t, []string{
+		"a", "b", "eng", "eng!", "eng-a", "eng-b", "eng/a", "z",
+	}, scan("", "", 10))
+	require.Equal(t, []string{"eng", "eng!", "eng-a", "eng-b", "eng/a"},
+		scan("eng", "", 10))
+	require.Equal(t, []string{"eng-a", "eng-b"}, scan("eng-", "", 10))
+	require.Equal(t, []string{"eng-b", "eng/a"}, scan("eng", "eng-b", 10))
+	require.Equal(t, []string{"eng-a"}, scan("eng", "eng-0", 1))
+	require.Equal(t, []string{"eng-b", "eng/a", "z"}, scan("", "eng-az", 10))
+	require.Empty(t, scan("eng", "f", 10))
+	require.Empty(t, scan("x", "", 10))
+}
@@ -15,3 +15,33 @@ func StoreLogicTest(t *testing.T, store kv.Store) {
 func StoreConcurrentTest(t *testing.T, store kv.Store, count int, iter bool) {
 	NamespaceConcurrentTest(t, store.In(), count, iter)
 }
+
+// StoreWalkTest test the kv.Store namespace enumeration.
+func StoreWalkTest(t *testing.T, store kv.Store) {
+	NamespaceWalkTest(t, store.In())
+}
+
+// StoreUpdateTest test the kv.Store transactions.
+func StoreUpdateTest(t *testing.T, store kv.Store) {
+	NamespaceUpdateTest(t, store.In())
+}
+
+// StoreCompareAndSetTest test the kv.Store conditional writes.
+func StoreCompareAndSetTest(t *testing.T, store kv.Store) {
+	NamespaceCompareAndSetTest(t, store.In())
+}
+
+// StoreIterateFromTest test the kv.Store prefix and range scans.
+func StoreIterateFromTest(t *testing.T, store kv.Store) {
+	NamespaceIterateFromTest(t, store.In())
+}
+
+// StoreTTLTest test the kv.Store values with TTL.
+func StoreTTLTest(t *testing.T, store kv.Store) {
+	NamespaceTTLTest(t, store.In())
+}
+
+// StoreSlashKeyTest test the kv.Store keys containing "/".
+func StoreSlashKeyTest(t *testing.T, store kv.Store) {
+	NamespaceSlashKeyTest(t, store.In())
+}
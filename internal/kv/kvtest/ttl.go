@@ -0,0 +1,83 @@
+package kvtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/kv"
+)
+
+// NamespaceTTLTest tests the kv.Namespace values with TTL.
+func NamespaceTTLTest(t *testing.T, store kv.Namespace) {
+	ctx := context.Background()
+	defer require.NoError(t, store.Drop(ctx))
+
+	ns := store.In("NAMESPACE")
+	short := 50 * time.Millisecond
+	require.NoError(t, ns.SetWithTTL(ctx, "short", []byte("short"), short))
+	require.NoError(t, ns.SetWithTTL(ctx, "long", []byte("long"), time.Hour))
+	require.NoError(t, ns.Set(ctx, "forever", []byte("forever")))
+
+	ttl, err := ns.TTL(ctx, "long")
+	require.NoError(t, err)
+	require.True(t, ttl > 0 && ttl <= time.Hour)
+	ttl, err = ns.TTL(ctx, "forever")
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), ttl)
+	_, err = ns.TTL(ctx, "none")
+	require.True(t, errors.Is(err, kv.ErrNotFound))
+
+	// expired values are not found
+	time.Sleep(2 * short)
+	_, err = ns.Get(ctx, "short")
+	require.True(t, errors.Is(err, kv.ErrNotFound))
+	_, err = ns.TTL(ctx, "short")
+	require.True(t, errors.Is(err, kv.ErrNotFound))
+	val, err := ns.Get(ctx, "long")
+	require.NoError(t, err)
+	require.Equal(t, []byte("long"), val)
+	var keys []string
+	require.NoError(t, ns.IterateFrom(ctx, "", "",
+		func(key string, value []byte) bool {
+			keys = append(keys, key)
+			return true
+		}))
+	require.Equal(t, []string{"forever", "long"}, keys)
+	keys = nil
+	err = ns.Iterate(ctx, func(key string, value []byte) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if !errors.Is(err, kv.ErrNotSupport) {
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"forever", "long"}, keys)
+	}
+
+	// an expired value can be created again, and Set and CompareAndSet clear
+	// the expiry
+	require.NoError(t, ns.CompareAndSet(ctx, "short", nil, []byte("new")))
+	require.NoError(t, ns.Set(ctx, "long", []byte("long")))
+	for _, key := range []string{"short", "long"} {
+		ttl, err = ns.TTL(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, time.Duration(0), ttl)
+	}
+
+	// deleted with the expiry
+	require.NoError(t, ns.SetWithTTL(ctx, "deleted", []byte("v"), time.Hour))
+	require.NoError(t, ns.Delete(ctx, "deleted"))
+	_, err = ns.TTL(ctx, "deleted")
+	require.True(t, errors.Is(err, kv.ErrNotFound))
+
+	// in a transaction
+	require.NoError(t, ns.Update(ctx, func(tx kv.Namespace) error {
+		return tx.SetWithTTL(ctx, "tx", []byte("tx"), time.Hour)
+	}))
+	ttl, err = ns.TTL(ctx, "tx")
+	require.NoError(t, err)
+	require.True(t, ttl > 0 && ttl <= time.Hour)
+}
@@ -0,0 +1,108 @@
+package kvtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/kv"
+)
+
+// NamespaceUpdateTest tests the kv.Namespace transactions.
+func NamespaceUpdateTest(t *testing.T, store kv.Namespace) {
+	ctx := context.Background()
+	defer require.NoError(t, store.Drop(ctx))
+
+	key := "KEY"
+	value := []byte("VALUE")
+	updated := []byte("UPDATED")
+	require.NoError(t, store.In("a").Set(ctx, key, value))
+	require.NoError(t, store.In("b").Set(ctx, key, value))
+
+	// rolled back on error
+	errAbort := errors.New("abort")
+	err := store.Update(ctx, func(tx kv.Namespace) error {
+		require.NoError(t, tx.In("a").Set(ctx, key, updated))
+		require.NoError(t, tx.In("b").Delete(ctx, key))
+		require.NoError(t, tx.In("c").Set(ctx, key, value))
+		// the changes are visible in the transaction
+		val, err := tx.In("a").Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, updated, val)
+		_, err = tx.In("b").Get(ctx, key)
+		require.True(t, errors.Is(err, kv.ErrNotFound))
+		return errAbort
+	})
+	require.True(t, errors.Is(err, errAbort))
+	val, err := store.In("a").Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, value, val)
+	val, err = store.In("b").Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, value, val)
+	_, err = store.In("c").Get(ctx, key)
+	require.True(t, errors.Is(err, kv.ErrNotFound))
+
+	// committed across namespaces
+	err = store.Update(ctx, func(tx kv.Namespace) error {
+		require.NoError(t, tx.In("a").Set(ctx, key, updated))
+		require.NoError(t, tx.In("b").Delete(ctx, key))
+		// nested updates join the transaction
+		return tx.In("c").Update(ctx, func(tx kv.Namespace) error {
+			return tx.Set(ctx, key, value)
+		})
+	})
+	require.NoError(t, err)
+	val, err = store.In("a").Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, updated, val)
+	_, err = store.In("b").Get(ctx, key)
+	require.True(t, errors.Is(err, kv.ErrNotFound))
+	val, err = store.In("c").Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, value, val)
+}
+
+// NamespaceCompareAndSetTest tests the kv.Namespace conditional writes.
+func NamespaceCompareAndSetTest(t *testing.T, store kv.Namespace) {
+	ctx := context.Background()
+	defer require.NoError(t, store.Drop(ctx))
+
+	ns := store.In("NAMESPACE")
+	key := "KEY"
+	value := []byte("VALUE")
+	updated := []byte("UPDATED")
+
+	// create only if not exists
+	require.NoError(t, ns.CompareAndSet(ctx, key, nil, value))
+	err := ns.CompareAndSet(ctx, key, nil, updated)
+	require.True(t, errors.Is(err, kv.ErrConflict))
+
+	// update only if unchanged
+	err = ns.CompareAndSet(ctx, key, updated, updated)
+	require.True(t, errors.Is(err, kv.ErrConflict))
+	val, err := ns.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, value, val)
+	require.NoError(t, ns.CompareAndSet(ctx, key, value, updated))
+	val, err = ns.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, updated, val)
+
+	// empty value is not a missing one
+	require.NoError(t, ns.CompareAndSet(ctx, key, updated, []byte{}))
+	err = ns.CompareAndSet(ctx, key, nil, value)
+	require.True(t, errors.Is(err, kv.ErrConflict))
+	require.NoError(t, ns.CompareAndSet(ctx, key, []byte{}, value))
+
+	// in transaction
+	err = store.Update(ctx, func(tx kv.Namespace) error {
+		return tx.In("NAMESPACE").CompareAndSet(ctx, key, value, updated)
+	})
+	require.NoError(t, err)
+	val, err = ns.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, updated, val)
+}
@@ -0,0 +1,86 @@
+package kvtest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/kv"
+)
+
+// NamespaceWalkTest tests the kv.Namespace namespace enumeration.
+func NamespaceWalkTest(t *testing.T, store kv.Namespace) {
+	ctx := context.Background()
+	defer require.NoError(t, store.Drop(ctx))
+
+	key := "KEY"
+	paths := [][]string{
+		{},
+		{"a"},
+		{"a", "b"},
+		{"a", "c", "d"},
+		{"e"},
+	}
+	for _, path := range paths {
+		require.NoError(t, store.In(path...).Set(ctx, key, []byte(key)))
+	}
+
+	// children
+	names, err := store.Namespaces(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "e"}, names)
+	names, err = store.In("a").Namespaces(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "c"}, names)
+	names, err = store.In("e").Namespaces(ctx)
+	require.NoError(t, err)
+	require.Empty(t, names)
+	_, err = store.In("x").Namespaces(ctx)
+	require.True(t, errors.Is(err, kv.ErrNotFound))
+
+	// child namespaces are not iterated as values
+	var keys []string
+	require.NoError(t, store.In("a").Iterate(ctx,
+		func(key string, _ []byte) bool {
+			keys = append(keys, key)
+			return true
+		}))
+	require.Equal(t, []string{key}, keys)
+
+	// walk
+	var walked []string
+	require.NoError(t, kv.Walk(ctx, store,
+		func(path []string, ns kv.Namespace) error {
+			walked = append(walked, strings.Join(path, "/"))
+			return nil
+		}))
+	require.Equal(t, []string{"", "a", "a/b", "a/c", "a/c/d", "e"}, walked)
+
+	// skip child namespaces
+	walked = nil
+	require.NoError(t, kv.Walk(ctx, store,
+		func(path []string, ns kv.Namespace) error {
+			walked = append(walked, strings.Join(path, "/"))
+			if len(path) == 1 && path[0] == "a" {
+				return kv.SkipNamespace
+			}
+			return nil
+		}))
+	require.Equal(t, []string{"", "a", "e"}, walked)
+
+	// stop walking on error
+	errStop := errors.New("stop")
+	walked = nil
+	err = kv.Walk(ctx, store, func(path []string, ns kv.Namespace) error {
+		walked = append(walked, strings.Join(path, "/"))
+		if len(path) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	require.True(t, errors.Is(err, errStop))
+	require.Equal(t, []string{"", "a", "a/b"}, walked)
+}
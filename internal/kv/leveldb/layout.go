@@ -0,0 +1,101 @@
+package leveldb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+
+	"github.com/haostudio/golinks/internal/kv"
+)
+
+var (
+	layoutKey       = []byte("github.com/haostudio/golinks/internal/kv/leveldb.layout") // nolint: lll
+	legacyKeyPrefix = []byte("github.com/haostudio/golinks/internal/kv/leveldb.key")    // nolint: lll
+)
+
+// layoutVersion is the version of the key layout. The values were stored
+// with the keys doubled, e.g. "A/K/K" for key K in namespace A, until version
+// 2, which stores them in the key order.
+const layoutVersion = "2"
+
+// getLegacyKeyIn returns the key of the value before layout version 2.
+func (m *meta) getLegacyKeyIn(key string, namespace ...string) []byte {
+	k := append(legacyKeyPrefix[:0:0], legacyKeyPrefix...)
+	k = append(k, []byte(joinKeys(append(namespace, key)...))...)
+	return append(k, []byte("/"+kv.EscapeKey(key))...)
+}
+
+// migrateLayout moves the values and their expiry stored in the previous
+// layout, and records the current layout version.
+func (m *meta) migrateLayout(tx *leveldb.Transaction) error {
+	version, err := tx.Get(layoutKey, nil)
+	if err == nil && string(version) == layoutVersion {
+		return nil
+	}
+	if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+		return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+	}
+	err = m.migrateNamespace(tx)
+	if err != nil {
+		return err
+	}
+	err = tx.Put(layoutKey, []byte(layoutVersion), &opt.WriteOptions{Sync: true})
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+	}
+	return nil
+}
+
+// migrateNamespace moves the values of namespace and its child namespaces to
+// the current layout.
+func (m *meta) migrateNamespace(tx *leveldb.Transaction,
+	namespace ...string) error {
+	nsMeta, err := m.getNamespaceMetaTx(tx, namespace...)
+	if errors.Is(err, kv.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for key := range nsMeta.Keys {
+		legacy := m.getLegacyKeyIn(key, namespace...)
+		err = move(tx, legacy, m.getKeyIn(key, namespace...))
+		if err != nil {
+			return err
+		}
+		legacyExpiry := append(expiryPrefix[:0:0], expiryPrefix...)
+		legacyExpiry = append(legacyExpiry, legacy...)
+		err = move(tx, legacyExpiry, m.getExpiryKey(key, namespace...))
+		if err != nil {
+			return err
+		}
+	}
+	for name := range nsMeta.Namespaces {
+		sub := append(namespace[:0:0], namespace...)
+		err = m.migrateNamespace(tx, append(sub, name)...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// move moves the value of from, if any, to to.
+func move(tx *leveldb.Transaction, from, to []byte) error {
+	b, err := tx.Get(from, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil
+	}
+	if err == nil {
+		err = tx.Put(to, b, &opt.WriteOptions{Sync: true})
+	}
+	if err == nil {
+		err = tx.Delete(from, &opt.WriteOptions{Sync: true})
+	}
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+	}
+	return nil
+}
@@ -13,7 +13,7 @@ import (
 )
 
 var (
-	keyPrefix           = []byte("github.com/haostudio/golinks/internal/kv/leveldb.key")    // nolint: lll
+	valuePrefix         = []byte("github.com/haostudio/golinks/internal/kv/leveldb.value")  // nolint: lll
 	namespaceMetaPrefix = []byte("github.com/haostudio/golinks/internal/kv/leveldb.nsmeta") // nolint: lll
 
 	metaEnc = gob.New()
@@ -28,10 +28,29 @@ type namespaceMeta struct {
 	Namespaces map[string]struct{}
 }
 
+// keySeparator separates the namespace path and the key of a value. The
+// escaped namespace names don't contain it, so the values of a namespace are
+// the ones having its prefix, sorted in the key order.
+const keySeparator = "\x00\x00"
+
 func (m *meta) getKeyIn(key string, namespace ...string) []byte {
-	k := append(keyPrefix[:0:0], keyPrefix...)
-	k = append(k, []byte(strings.Join(append(namespace, key), "/"))...)
-	return append(k, []byte("/"+key)...)
+	return append(m.getValuePrefix(namespace...), []byte(key)...)
+}
+
+// getValuePrefix returns the prefix of the values in namespace.
+func (m *meta) getValuePrefix(namespace ...string) []byte {
+	k := append(valuePrefix[:0:0], valuePrefix...)
+	k = append(k, []byte(joinKeys(namespace...))...)
+	return append(k, []byte(keySeparator)...)
+}
+
+// joinKeys joins the escaped keys with "/".
+func joinKeys(keys ...string) string {
+	escaped := make([]string, len(keys))
+	for i, key := range keys {
+		escaped[i] = kv.EscapeKey(key)
+	}
+	return strings.Join(escaped, "/")
 }
 
 func (m *meta) addKeyIn(tx *leveldb.Transaction,
@@ -62,7 +81,7 @@ func (m *meta) deleteKeyIn(tx *leveldb.Transaction,
 	}
 	if _, ok := nsMeta.Keys[key]; ok {
 		delete(nsMeta.Keys, key)
-		err = m.setNamespaceMeta(tx, nsMeta)
+		err = m.setNamespaceMeta(tx, nsMeta, namespace...)
 		if err != nil {
 			return nil, err
 		}
@@ -70,24 +89,6 @@ func (m *meta) deleteKeyIn(tx *leveldb.Transaction,
 	return m.getKeyIn(key, namespace...), nil
 }
 
-func (m *meta) getKeysIn(db *leveldb.DB, namespace ...string) (
-	[]string, error) {
-	nsMeta, err := m.getNamespaceMeta(db, namespace...)
-	if err != nil {
-		return nil, err
-	}
-	keys := make([]string, len(nsMeta.Keys))
-	if len(keys) == 0 {
-		return keys, nil
-	}
-	var i int
-	for k := range nsMeta.Keys {
-		keys[i] = k
-		i++
-	}
-	return keys, nil
-}
-
 func (m *meta) getKeysInTx(tx *leveldb.Transaction,
 	namespace ...string) ([]string, error) {
 	nsMeta, err := m.getNamespaceMetaTx(tx, namespace...)
@@ -146,7 +147,7 @@ func (m *meta) dropNamespaceMeta(tx *leveldb.Transaction,
 	return m.setNamespaceMeta(tx, nsMeta, namespace[:last]...)
 }
 
-func (m *meta) getNamespaceMeta(db *leveldb.DB,
+func (m *meta) getNamespaceMeta(db reader,
 	namespace ...string) (nsMeta namespaceMeta, err error) {
 	k := m.getNamespaceMetaKey(namespace...)
 	b, err := db.Get(k, nil)
@@ -195,6 +196,7 @@ func (m *meta) ensureNamespaceMeta(tx *leveldb.Transaction,
 		rootNsMeta, err = m.getNamespaceMetaTx(tx, rootNamespace...)
 		if errors.Is(err, kv.ErrNotFound) {
 			updateRoot = true
+			err = nil
 		} else if err != nil {
 			return
 		}
@@ -258,5 +260,5 @@ func (m *meta) setNamespaceMeta(tx *leveldb.Transaction,
 
 func (m *meta) getNamespaceMetaKey(namespace ...string) []byte {
 	k := append(namespaceMetaPrefix[:0:0], namespaceMetaPrefix...)
-	return append(k, []byte(strings.Join(namespace, "/"))...)
+	return append(k, []byte(joinKeys(namespace...))...)
 }
@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"github.com/haostudio/golinks/internal/kv"
 )
@@ -14,6 +17,8 @@ import (
 type namespace struct {
 	store     *store
 	namespace []string
+	// tx is the transaction the namespace is bound to, if any.
+	tx *leveldb.Transaction
 }
 
 // In returns the namespace instance with path.
@@ -26,6 +31,7 @@ func (n *namespace) In(path ...string) kv.Namespace {
 	return &namespace{
 		store:     n.store,
 		namespace: ns,
+		tx:        n.tx,
 	}
 }
 
@@ -33,10 +39,13 @@ func (n *namespace) In(path ...string) kv.Namespace {
 func (n *namespace) Get(ctx context.Context, key string) ([]byte, error) {
 	k := n.store.meta.getKeyIn(key, n.namespace...)
 	var b []byte
-	err := n.store.read(func(db *leveldb.DB) error {
+	err := n.read(func(db reader) error {
 		var readErr error
 		b, readErr = n.get(db, k)
-		return readErr
+		if readErr != nil {
+			return readErr
+		}
+		return n.checkExpiry(db, time.Now(), key)
 	})
 	if err != nil {
 		return nil, err
@@ -44,7 +53,43 @@ func (n *namespace) Get(ctx context.Context, key string) ([]byte, error) {
 	return b, nil
 }
 
-func (n *namespace) get(db *leveldb.DB, key []byte) ([]byte, error) {
+// checkExpiry returns kv.ErrNotFound if key has expired at now.
+func (n *namespace) checkExpiry(db reader, now time.Time, key string) error {
+	t, err := n.store.meta.getExpiry(db, key, n.namespace...)
+	if err != nil {
+		return err
+	}
+	if !t.IsZero() && !now.Before(t) {
+		return kv.ErrNotFound
+	}
+	return nil
+}
+
+// TTL returns the remaining time to live of the value with key.
+func (n *namespace) TTL(ctx context.Context, key string) (
+	ttl time.Duration, err error) {
+	err = n.read(func(db reader) error {
+		_, err := n.get(db, n.store.meta.getKeyIn(key, n.namespace...))
+		if err != nil {
+			return err
+		}
+		t, err := n.store.meta.getExpiry(db, key, n.namespace...)
+		if err != nil || t.IsZero() {
+			return err
+		}
+		ttl = time.Until(t)
+		if ttl <= 0 {
+			return kv.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		ttl = 0
+	}
+	return
+}
+
+func (n *namespace) get(db reader, key []byte) ([]byte, error) {
 	b, err := db.Get(key, nil)
 	if err != nil && errors.Is(err, leveldb.ErrNotFound) {
 		return nil, fmt.Errorf("%v: %w", err, kv.ErrNotFound)
@@ -56,22 +101,56 @@ func (n *namespace) get(db *leveldb.DB, key []byte) ([]byte, error) {
 
 // Set sets the value in the namespace with key.
 func (n *namespace) Set(ctx context.Context, key string, value []byte) error {
-	return n.store.write(func(tx *leveldb.Transaction) error {
-		k, err := n.store.meta.addKeyIn(tx, key, n.namespace...)
-		if err != nil {
-			return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+	return n.write(func(tx *leveldb.Transaction) error {
+		return n.set(tx, key, value, time.Time{})
+	})
+}
+
+// SetWithTTL sets the value in the namespace with key, which expires after
+// ttl.
+func (n *namespace) SetWithTTL(ctx context.Context,
+	key string, value []byte, ttl time.Duration) error {
+	return n.write(func(tx *leveldb.Transaction) error {
+		return n.set(tx, key, value, time.Now().Add(ttl))
+	})
+}
+
+func (n *namespace) set(tx *leveldb.Transaction,
+	key string, value []byte, expiry time.Time) error {
+	k, err := n.store.meta.addKeyIn(tx, key, n.namespace...)
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+	}
+	err = tx.Put(k, value, &opt.WriteOptions{Sync: true})
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+	}
+	return n.store.meta.setExpiry(tx, expiry, key, n.namespace...)
+}
+
+// CompareAndSet sets the value with key if the current value equals old.
+func (n *namespace) CompareAndSet(
+	ctx context.Context, key string, old, value []byte) error {
+	return n.write(func(tx *leveldb.Transaction) error {
+		cur, err := n.get(tx, n.store.meta.getKeyIn(key, n.namespace...))
+		if err == nil {
+			err = n.checkExpiry(tx, time.Now(), key)
 		}
-		err = tx.Put(k, value, &opt.WriteOptions{Sync: true})
-		if err != nil {
-			return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+		if errors.Is(err, kv.ErrNotFound) {
+			cur = nil
+		} else if err != nil {
+			return err
 		}
-		return nil
+		if !kv.Equal(cur, old) {
+			return kv.ErrConflict
+		}
+		return n.set(tx, key, value, time.Time{})
 	})
 }
 
 // Delete deletes the value in the namespace with key.
 func (n *namespace) Delete(ctx context.Context, key string) error {
-	return n.store.write(func(tx *leveldb.Transaction) error {
+	return n.write(func(tx *leveldb.Transaction) error {
 		k, err := n.store.meta.deleteKeyIn(tx, key, n.namespace...)
 		if err != nil {
 			return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
@@ -80,37 +159,79 @@ func (n *namespace) Delete(ctx context.Context, key string) error {
 		if err != nil {
 			return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
 		}
-		return nil
+		return n.store.meta.setExpiry(tx, time.Time{}, key, n.namespace...)
 	})
 }
 
 // Iterate iterates the values in the namespace.
 func (n *namespace) Iterate(
 	ctx context.Context, f func(key string, value []byte) (next bool)) error {
-	return n.store.read(func(db *leveldb.DB) error {
-		keys, err := n.store.meta.getKeysIn(db, n.namespace...)
+	return n.IterateFrom(ctx, "", "", f)
+}
+
+// IterateFrom iterates the values with keys having prefix and not less than
+// start in the key order.
+func (n *namespace) IterateFrom(ctx context.Context, prefix, start string,
+	f func(key string, value []byte) (next bool)) error {
+	if start < prefix {
+		start = prefix
+	}
+	return n.read(func(db reader) error {
+		_, err := n.get(db, n.store.meta.getNamespaceMetaKey(n.namespace...))
 		if err != nil {
 			return err
 		}
-		for _, key := range keys {
-			k := n.store.meta.getKeyIn(key, n.namespace...)
-			val, err := n.get(db, k)
-			if err != nil && errors.Is(err, leveldb.ErrNotFound) {
+		base := n.store.meta.getValuePrefix(n.namespace...)
+		r := util.BytesPrefix(append(base[:len(base):len(base)], prefix...))
+		r.Start = append(base[:len(base):len(base)], start...)
+		iter := db.NewIterator(r, nil)
+		defer iter.Release()
+		now := time.Now()
+		for iter.Next() {
+			key := string(iter.Key()[len(base):])
+			err = n.checkExpiry(db, now, key)
+			if errors.Is(err, kv.ErrNotFound) {
 				continue
 			} else if err != nil {
 				return err
 			}
+			// the iterator reuses the value buffer
+			val := append([]byte(nil), iter.Value()...)
 			if !f(key, val) {
 				break
 			}
 		}
+		err = iter.Error()
+		if err != nil {
+			return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+		}
+		return nil
+	})
+}
+
+// Namespaces returns the sorted names of the child namespaces.
+func (n *namespace) Namespaces(ctx context.Context) (names []string,
+	err error) {
+	err = n.read(func(db reader) error {
+		nsMeta, err := n.store.meta.getNamespaceMeta(db, n.namespace...)
+		if err != nil {
+			return err
+		}
+		for name := range nsMeta.Namespaces {
+			names = append(names, name)
+		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
 }
 
 // Drop drops all the values in the namespace.
 func (n *namespace) Drop(ctx context.Context) error {
-	return n.store.write(n.drop)
+	return n.write(n.drop)
 }
 
 func (n *namespace) drop(tx *leveldb.Transaction) error {
@@ -127,6 +248,7 @@ func (n *namespace) drop(tx *leveldb.Transaction) error {
 		ns := &namespace{
 			store:     n.store,
 			namespace: subns,
+			tx:        tx,
 		}
 		err = ns.drop(tx)
 		if err != nil {
@@ -145,12 +267,46 @@ func (n *namespace) drop(tx *leveldb.Transaction) error {
 		if err != nil {
 			return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
 		}
+		err = n.store.meta.setExpiry(tx, time.Time{}, key, n.namespace...)
+		if err != nil {
+			return err
+		}
 	}
 
 	// drop meta data
 	return n.store.meta.dropNamespaceMeta(tx, n.namespace...)
 }
 
+// Update runs f in a read-write transaction.
+func (n *namespace) Update(
+	ctx context.Context, f func(tx kv.Namespace) error) error {
+	if n.tx != nil {
+		// already in a transaction
+		return f(n)
+	}
+	return n.store.write(func(tx *leveldb.Transaction) error {
+		return f(&namespace{
+			store:     n.store,
+			namespace: n.namespace,
+			tx:        tx,
+		})
+	})
+}
+
+func (n *namespace) read(f func(db reader) error) error {
+	if n.tx != nil {
+		return f(n.tx)
+	}
+	return n.store.read(f)
+}
+
+func (n *namespace) write(f func(tx *leveldb.Transaction) error) error {
+	if n.tx != nil {
+		return f(n.tx)
+	}
+	return n.store.write(f)
+}
+
 func (n *namespace) String() string {
 	return fmt.Sprintf("leveldb.namespace(%s:%v)", n.store, n.namespace)
 }
@@ -1,12 +1,17 @@
 package leveldb
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"github.com/haostudio/golinks/internal/kv"
 )
@@ -23,6 +28,9 @@ type store struct {
 		sync.RWMutex
 		db *leveldb.DB
 	}
+	// stop stops the compaction goroutine.
+	stop context.CancelFunc
+	wg   sync.WaitGroup
 }
 
 // New returns a level kev-value store.
@@ -47,11 +55,22 @@ func New(path, name string, metaCache kv.Store) (kv.Store, error) {
 		meta: &meta{},
 	}
 	s.io.db = db
+	err = s.write(s.meta.migrateLayout)
+	if err != nil {
+		_ = close(file)
+		return nil, err
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	s.stop = stop
+	s.wg.Add(1)
+	go s.compactLoop(ctx)
 	return s, nil
 }
 
 // Close finalizes a kv.Store.
 func (s *store) Close() error {
+	s.stop()
+	s.wg.Wait()
 	s.io.Lock()
 	defer s.io.Unlock()
 	file := filepath.Join(s.path, s.name)
@@ -66,7 +85,19 @@ func (s *store) In(path ...string) kv.Namespace {
 	}
 }
 
-func (s *store) read(f func(db *leveldb.DB) error) (err error) {
+// Update runs f in a read-write transaction.
+func (s *store) Update(
+	ctx context.Context, f func(tx kv.Namespace) error) error {
+	return s.In().Update(ctx, f)
+}
+
+// reader reads from a leveldb.DB or a leveldb.Transaction.
+type reader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+func (s *store) read(f func(db reader) error) (err error) {
 	s.io.RLock()
 	defer s.io.RUnlock()
 	// s.io.Lock()
@@ -100,6 +131,28 @@ func (s *store) write(f func(tx *leveldb.Transaction) error) (err error) {
 	return
 }
 
+// compact deletes the expired values in the store.
+func (s *store) compact(now time.Time) error {
+	return s.write(func(tx *leveldb.Transaction) error {
+		return s.meta.compact(tx, now)
+	})
+}
+
+func (s *store) compactLoop(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(compactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// the expired values are still hidden if compaction fails.
+			_ = s.compact(now)
+		}
+	}
+}
+
 func (s *store) String() string {
 	return fmt.Sprintf("leveldb.store(%s:%s)", s.path, s.name)
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 
+	"github.com/haostudio/golinks/internal/kv"
 	"github.com/haostudio/golinks/internal/kv/kvtest"
 )
 
@@ -25,6 +26,10 @@ func TestLogic(t *testing.T) {
 	dbPath := filepath.Join(
 		dir, fmt.Sprintf("leveldb_test_%d", time.Now().UnixNano()))
 	dbName := testdb
+	// Clean up after the stores are closed
+	defer func() {
+		require.NoError(t, os.RemoveAll(dbPath))
+	}()
 	// Test twice
 	store, err := New(dbPath, dbName, nil)
 	defer func() {
@@ -32,14 +37,59 @@ func TestLogic(t *testing.T) {
 	}()
 	require.NoError(t, err)
 	kvtest.StoreLogicTest(t, store)
+	kvtest.StoreUpdateTest(t, store)
+	kvtest.StoreCompareAndSetTest(t, store)
+	kvtest.StoreIterateFromTest(t, store)
+	kvtest.StoreTTLTest(t, store)
+	kvtest.StoreSlashKeyTest(t, store)
 	store, err = New(dbPath, dbName, nil)
 	defer func() {
 		require.NoError(t, store.Close())
 	}()
 	require.NoError(t, err)
 	kvtest.StoreLogicTest(t, store)
-	// Clean up
-	require.NoError(t, os.RemoveAll(dbPath))
+	kvtest.StoreWalkTest(t, store)
+}
+
+func TestNestedNamespace(t *testing.T) {
+	ctx := context.Background()
+	// Prepare DB path
+	dir, err := os.Getwd()
+	require.NoError(t, err)
+	dbPath := filepath.Join(
+		dir, fmt.Sprintf("leveldb_test_%d", time.Now().UnixNano()))
+	store, err := New(dbPath, testdb, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, store.Close())
+		require.NoError(t, os.RemoveAll(dbPath))
+	}()
+
+	// set in a nested namespace of an empty store
+	require.NoError(t, store.In("A", "B").Set(ctx, "K", []byte("V")))
+	require.NoError(t, store.In("A", "C").Set(ctx, "K", []byte("V")))
+	require.NoError(t, store.In().Set(ctx, "K", []byte("V")))
+
+	// delete in a nested namespace keeps the others
+	require.NoError(t, store.In("A", "B").Delete(ctx, "K"))
+	_, err = store.In("A", "B").Get(ctx, "K")
+	require.True(t, errors.Is(err, kv.ErrNotFound))
+	var keys []string
+	require.NoError(t, store.In("A", "C").Iterate(ctx,
+		func(key string, value []byte) bool {
+			keys = append(keys, key)
+			return true
+		},
+	))
+	require.Equal(t, []string{"K"}, keys)
+	keys = nil
+	require.NoError(t, store.In().Iterate(ctx,
+		func(key string, value []byte) bool {
+			keys = append(keys, key)
+			return true
+		},
+	))
+	require.Equal(t, []string{"K"}, keys)
 }
 
 func TestTransaction(t *testing.T) {
@@ -81,6 +131,96 @@ func TestTransaction(t *testing.T) {
 	require.NoError(t, os.RemoveAll(dbPath))
 }
 
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	// Prepare DB path
+	dir, err := os.Getwd()
+	require.NoError(t, err)
+	dbPath := filepath.Join(
+		dir, fmt.Sprintf("leveldb_test_%d", time.Now().UnixNano()))
+	kvStore, err := New(dbPath, testdb, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, kvStore.Close())
+		require.NoError(t, os.RemoveAll(dbPath))
+	}()
+	s := kvStore.(*store)
+
+	ns := s.In("NAMESPACE")
+	require.NoError(t, ns.SetWithTTL(ctx, "expired", []byte("v"), time.Hour))
+	require.NoError(t, ns.SetWithTTL(ctx, "kept", []byte("v"), 3*time.Hour))
+	require.NoError(t, ns.Set(ctx, "forever", []byte("v")))
+
+	require.NoError(t, s.compact(time.Now().Add(2*time.Hour)))
+	var keys []string
+	require.NoError(t, ns.Iterate(ctx, func(key string, value []byte) bool {
+		keys = append(keys, key)
+		return true
+	}))
+	require.ElementsMatch(t, []string{"kept", "forever"}, keys)
+	for _, k := range [][]byte{
+		s.meta.getKeyIn("expired", "NAMESPACE"),
+		s.meta.getExpiryKey("expired", "NAMESPACE"),
+	} {
+		_, err = s.io.db.Get(k, nil)
+		require.True(t, errors.Is(err, leveldb.ErrNotFound))
+	}
+	_, err = s.io.db.Get(s.meta.getExpiryKey("kept", "NAMESPACE"), nil)
+	require.NoError(t, err)
+}
+
+func TestMigrateLayout(t *testing.T) {
+	ctx := context.Background()
+	// Prepare DB path
+	dir, err := os.Getwd()
+	require.NoError(t, err)
+	dbPath := filepath.Join(
+		dir, fmt.Sprintf("leveldb_test_%d", time.Now().UnixNano()))
+	kvStore, err := New(dbPath, testdb, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, kvStore.Close())
+		require.NoError(t, os.RemoveAll(dbPath))
+	}()
+	s := kvStore.(*store)
+
+	// store the values in the legacy layout
+	require.NoError(t, s.write(func(tx *leveldb.Transaction) error {
+		for _, key := range []string{"b", "a/b", "a"} {
+			_, err := s.meta.addKeyIn(tx, key, "A", "B")
+			require.NoError(t, err)
+			require.NoError(t, tx.Put(s.meta.getLegacyKeyIn(key, "A", "B"),
+				[]byte(key), nil))
+		}
+		_, err := s.meta.addKeyIn(tx, "ttl", "A")
+		require.NoError(t, err)
+		legacy := s.meta.getLegacyKeyIn("ttl", "A")
+		require.NoError(t, tx.Put(legacy, []byte("ttl"), nil))
+		require.NoError(t, s.meta.setExpiry(
+			tx, time.Now().Add(time.Hour), "ttl", "A"))
+		expiryKey := append(expiryPrefix[:0:0], expiryPrefix...)
+		require.NoError(t, move(tx, s.meta.getExpiryKey("ttl", "A"),
+			append(expiryKey, legacy...)))
+		return tx.Delete(layoutKey, nil)
+	}))
+
+	require.NoError(t, s.write(s.meta.migrateLayout))
+	var keys []string
+	require.NoError(t, s.In("A", "B").Iterate(ctx,
+		func(key string, value []byte) bool {
+			require.Equal(t, key, string(value))
+			keys = append(keys, key)
+			return true
+		},
+	))
+	require.Equal(t, []string{"a", "a/b", "b"}, keys)
+	ttl, err := s.In("A").TTL(ctx, "ttl")
+	require.NoError(t, err)
+	require.True(t, ttl > 0 && ttl <= time.Hour, ttl)
+	_, err = s.io.db.Get(s.meta.getLegacyKeyIn("a", "A", "B"), nil)
+	require.True(t, errors.Is(err, leveldb.ErrNotFound))
+}
+
 func TestConcurrentConsistency_1(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping testing in short mode")
@@ -114,7 +254,7 @@ func TestConcurrentConsistency_1(t *testing.T) {
 	// snapshot, err := leveldbStore.io.db.GetSnapshot()
 	// require.NoError(t, err)
 	// snapshot := leveldbStore.io.db
-	require.NoError(t, leveldbStore.read(func(snapshot *leveldb.DB) error {
+	require.NoError(t, leveldbStore.read(func(snapshot reader) error {
 		for value, namespace := range cases {
 			nsMetaKey := leveldbStore.meta.getNamespaceMetaKey(namespace...)
 			valueKey := leveldbStore.meta.getKeyIn(key, namespace...)
@@ -187,7 +327,7 @@ func TestConcurrentConsistency_2(t *testing.T) {
 	for ctx.Err() == nil {
 		// snapshot, err := leveldbStore.io.db.GetSnapshot()
 		// require.NoError(t, err)
-		require.NoError(t, leveldbStore.read(func(snapshot *leveldb.DB) error {
+		require.NoError(t, leveldbStore.read(func(snapshot reader) error {
 			for value, namespace := range cases {
 				nsMetaKey := leveldbStore.meta.getNamespaceMetaKey(namespace...)
 				valueKey := leveldbStore.meta.getKeyIn(key, namespace...)
@@ -0,0 +1,111 @@
+package leveldb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/haostudio/golinks/internal/kv"
+)
+
+var expiryPrefix = []byte("github.com/haostudio/golinks/internal/kv/leveldb.expiry") // nolint: lll
+
+const compactInterval = time.Minute
+
+// expiry defines the expiry record of a key set with TTL.
+type expiry struct {
+	Namespace []string
+	Key       string
+	ExpiresAt time.Time
+}
+
+func (m *meta) getExpiryKey(key string, namespace ...string) []byte {
+	k := append(expiryPrefix[:0:0], expiryPrefix...)
+	return append(k, m.getKeyIn(key, namespace...)...)
+}
+
+// getExpiry returns the expiry time of the key, or zero time if it never
+// expires.
+func (m *meta) getExpiry(db reader,
+	key string, namespace ...string) (time.Time, error) {
+	b, err := db.Get(m.getExpiryKey(key, namespace...), nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+	}
+	var e expiry
+	err = metaEnc.Decode(b, &e)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+	}
+	return e.ExpiresAt, nil
+}
+
+// setExpiry sets the expiry time of the key. Zero time clears it.
+func (m *meta) setExpiry(tx *leveldb.Transaction, t time.Time,
+	key string, namespace ...string) error {
+	k := m.getExpiryKey(key, namespace...)
+	if t.IsZero() {
+		err := tx.Delete(k, &opt.WriteOptions{Sync: true})
+		if err != nil {
+			return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+		}
+		return nil
+	}
+	b, err := metaEnc.Encode(&expiry{
+		Namespace: namespace,
+		Key:       key,
+		ExpiresAt: t,
+	})
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+	}
+	err = tx.Put(k, b, &opt.WriteOptions{Sync: true})
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+	}
+	return nil
+}
+
+// compact deletes the keys expired at now.
+func (m *meta) compact(tx *leveldb.Transaction, now time.Time) error {
+	var expired []expiry
+	iter := tx.NewIterator(util.BytesPrefix(expiryPrefix), nil)
+	for iter.Next() {
+		var e expiry
+		err := metaEnc.Decode(iter.Value(), &e)
+		if err != nil {
+			iter.Release()
+			return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+		}
+		if !now.Before(e.ExpiresAt) {
+			expired = append(expired, e)
+		}
+	}
+	iter.Release()
+	err := iter.Error()
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+	}
+	for _, e := range expired {
+		k, err := m.deleteKeyIn(tx, e.Key, e.Namespace...)
+		if err != nil {
+			return err
+		}
+		err = tx.Delete(k, &opt.WriteOptions{Sync: true})
+		if err != nil {
+			return fmt.Errorf("%v: %w", err, kv.ErrInternalError)
+		}
+		err = m.setExpiry(tx, time.Time{}, e.Key, e.Namespace...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
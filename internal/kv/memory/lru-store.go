@@ -3,8 +3,10 @@ package memory
 import (
 	"container/list"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/haostudio/golinks/internal/kv"
 )
@@ -19,11 +21,15 @@ type lruStore struct {
 	cap int                      // capacity
 	l   *list.List               // doubly linked list
 	m   map[string]*list.Element // hash table for checking if list node exists
+
+	// expiring is the number of the values with expiry.
+	expiring int
 }
 
 type pair struct {
-	key   string
-	value []byte
+	key       string
+	value     []byte
+	expiresAt time.Time
 }
 
 func newLRUStore(capacity int) store {
@@ -36,49 +42,60 @@ func newLRUStore(capacity int) store {
 
 func (s *lruStore) key(keys ...string) string {
 	elems := []string{lruStoreKeyPrefix}
-	elems = append(elems, keys...)
+	for _, key := range keys {
+		elems = append(elems, kv.EscapeKey(key))
+	}
 	return strings.Join(elems, lruStoreKeySeparator)
 }
 
-func (s *lruStore) get(keys ...string) ([]byte, bool) {
+func (s *lruStore) get(keys ...string) ([]byte, time.Time, bool) {
 	key := s.key(keys...)
-	s.RLock()
-	defer s.RUnlock()
+	// moving the node is a write.
+	s.Lock()
+	defer s.Unlock()
 	// check if list node exists
 	if node, ok := s.m[key]; ok {
-		val := node.Value.(pair).value
+		p := node.Value.(pair)
+		if expired(p.expiresAt, time.Now()) {
+			s.removeNoLock(node)
+			return nil, time.Time{}, false
+		}
 		// move node to front
 		s.l.MoveToFront(node)
-		cloned := append(val[:0:0], val...)
-		return cloned, true
+		cloned := append(p.value[:0:0], p.value...)
+		return cloned, p.expiresAt, true
 	}
-	return nil, false
+	return nil, time.Time{}, false
 }
 
-func (s *lruStore) set(value []byte, keys ...string) {
+func (s *lruStore) set(value []byte, expiresAt time.Time, keys ...string) {
 	key := s.key(keys...)
 	s.Lock()
 	defer s.Unlock()
 
 	// update the existing one.
 	if node, ok := s.m[key]; ok {
-		s.l.MoveToFront(node)
-		node.Value = pair{key: key, value: value}
-		return
+		s.removeNoLock(node)
 	}
 
-	// delete the last list node if the list is full
+	// evict the expired nodes first, and then the last list node if the list is
+	// full
+	if s.l.Len() == s.cap && s.expiring > 0 {
+		s.expireNoLock(time.Now())
+	}
 	if s.l.Len() == s.cap {
-		idx := s.l.Back().Value.(pair).key
-		delete(s.m, idx)
-		s.l.Remove(s.l.Back())
+		s.removeNoLock(s.l.Back())
 	}
 	// push the new list node into the list
 	ptr := s.l.PushFront(pair{
-		key:   key,
-		value: value,
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
 	})
 	s.m[key] = ptr
+	if !expiresAt.IsZero() {
+		s.expiring++
+	}
 }
 
 func (s *lruStore) del(keys ...string) {
@@ -89,8 +106,33 @@ func (s *lruStore) del(keys ...string) {
 	if !ok {
 		return
 	}
+	s.removeNoLock(node)
+}
+
+// expire removes the values expired at now.
+func (s *lruStore) expire(now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	s.expireNoLock(now)
+}
+
+func (s *lruStore) expireNoLock(now time.Time) {
+	for node := s.l.Back(); node != nil; {
+		prev := node.Prev()
+		if expired(node.Value.(pair).expiresAt, now) {
+			s.removeNoLock(node)
+		}
+		node = prev
+	}
+}
+
+func (s *lruStore) removeNoLock(node *list.Element) {
+	p := node.Value.(pair)
+	if !p.expiresAt.IsZero() {
+		s.expiring--
+	}
 	s.l.Remove(node)
-	delete(s.m, key)
+	delete(s.m, p.key)
 }
 
 func (s *lruStore) iter(
@@ -99,6 +141,46 @@ func (s *lruStore) iter(
 	return false, kv.ErrNotSupport
 }
 
+func (s *lruStore) scan(prefix, start string,
+	f func(key string, val []byte) (next bool), keys ...string,
+) (exists bool, err error) {
+	nsPrefix := s.key(keys...) + lruStoreKeySeparator
+	s.RLock()
+	var matched []string
+	for k := range s.m {
+		if !strings.HasPrefix(k, nsPrefix) {
+			continue
+		}
+		key := k[len(nsPrefix):]
+		if strings.Contains(key, lruStoreKeySeparator) {
+			// key in a child namespace
+			continue
+		}
+		key = kv.UnescapeKey(key)
+		if strings.HasPrefix(key, prefix) && key >= start {
+			matched = append(matched, key)
+		}
+	}
+	s.RUnlock()
+	sort.Strings(matched)
+	for _, key := range matched {
+		k := append(keys[:0:0], keys...)
+		value, _, ok := s.get(append(k, key)...)
+		if !ok {
+			// evicted or expired after the keys are collected
+			continue
+		}
+		if !f(key, value) {
+			break
+		}
+	}
+	return true, nil
+}
+
+func (s *lruStore) namespaces(keys ...string) ([]string, bool, error) {
+	return nil, false, kv.ErrNotSupport
+}
+
 func (s *lruStore) drop(keys ...string) {
 	prefix := s.key(keys...) + lruStoreKeySeparator
 	s.Lock()
@@ -107,8 +189,7 @@ func (s *lruStore) drop(keys ...string) {
 		if !strings.HasPrefix(k, prefix) {
 			continue
 		}
-		s.l.Remove(node)
-		delete(s.m, k)
+		s.removeNoLock(node)
 	}
 }
 func (s *lruStore) String() string {
@@ -1,10 +1,14 @@
 package memory
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/haostudio/golinks/internal/kv"
 	"github.com/haostudio/golinks/internal/kv/kvtest"
 )
 
@@ -14,6 +18,13 @@ func TestLRULogic(t *testing.T) {
 		require.NoError(t, store.Close())
 	}()
 	kvtest.StoreLogicTest(t, store)
+	kvtest.StoreUpdateTest(t, store)
+	kvtest.StoreCompareAndSetTest(t, store)
+	kvtest.StoreIterateFromTest(t, store)
+	kvtest.StoreTTLTest(t, store)
+	kvtest.StoreSlashKeyTest(t, store)
+	_, err := store.In().Namespaces(context.Background())
+	require.True(t, errors.Is(err, kv.ErrNotSupport))
 }
 
 func TestLRUConcurrent(t *testing.T) {
@@ -21,3 +32,22 @@ func TestLRUConcurrent(t *testing.T) {
 	kvtest.StoreConcurrentTest(t, store, 1<<12, false)
 	require.NoError(t, store.Close())
 }
+
+func TestLRUExpire(t *testing.T) {
+	s := newLRUStore(2).(*lruStore)
+	now := time.Now()
+	s.set([]byte("v"), now.Add(-time.Second), "expired")
+	s.set([]byte("v"), now.Add(time.Hour), "recent")
+	// the expired value is evicted before the least recently used one
+	s.set([]byte("v"), time.Time{}, "new")
+	_, _, ok := s.get("recent")
+	require.True(t, ok)
+	_, _, ok = s.get("new")
+	require.True(t, ok)
+	require.Equal(t, 2, s.l.Len())
+	require.Equal(t, 1, s.expiring)
+
+	s.expire(now.Add(2 * time.Hour))
+	require.Equal(t, 1, s.l.Len())
+	require.Equal(t, 0, s.expiring)
+}
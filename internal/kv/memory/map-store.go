@@ -2,17 +2,28 @@ package memory
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 type entry struct {
 	value     []byte
+	expiresAt time.Time
 	namespace map[string]*entry
 }
 
+// live returns true if e holds a value not expired at now.
+func (e *entry) live(now time.Time) bool {
+	return e.value != nil && !expired(e.expiresAt, now)
+}
+
 type mapStore struct {
 	sync.RWMutex
 	m map[string]*entry
+	// nextExpire is when the expired values are removed on the next write.
+	nextExpire time.Time
 }
 
 func newMapStore() store {
@@ -21,28 +32,26 @@ func newMapStore() store {
 	}
 }
 
-func (s *mapStore) get(keys ...string) (b []byte, exists bool) {
+func (s *mapStore) get(keys ...string) (
+	b []byte, expiresAt time.Time, exists bool) {
 	s.RLock()
 	defer s.RUnlock()
-	v, exists := s.getNoLock(s.m, keys...)
-	if !exists {
-		return
+	e, exists := s.getNoLock(s.m, keys...)
+	if !exists || !e.live(time.Now()) {
+		return nil, time.Time{}, false
 	}
-	b = append(v[:0:0], v...) // nolint: gocritic
-	return b, true
+	b = append(e.value[:0:0], e.value...) // nolint: gocritic
+	return b, e.expiresAt, true
 }
 
 func (s *mapStore) getNoLock(m map[string]*entry, keys ...string) (
-	[]byte, bool) {
+	*entry, bool) {
 	e, ok := m[keys[0]]
 	if !ok {
 		return nil, false
 	}
 	if len(keys) == 1 {
-		if e.value == nil {
-			return nil, false
-		}
-		return e.value, true
+		return e, true
 	}
 	if e.namespace == nil {
 		return nil, false
@@ -50,14 +59,19 @@ func (s *mapStore) getNoLock(m map[string]*entry, keys ...string) (
 	return s.getNoLock(e.namespace, keys[1:]...)
 }
 
-func (s *mapStore) set(value []byte, keys ...string) {
+func (s *mapStore) set(value []byte, expiresAt time.Time, keys ...string) {
 	s.Lock()
 	defer s.Unlock()
-	s.setNoLock(s.m, value, keys...)
+	now := time.Now()
+	if !now.Before(s.nextExpire) {
+		s.expireNoLock(s.m, now)
+		s.nextExpire = now.Add(expireInterval)
+	}
+	s.setNoLock(s.m, value, expiresAt, keys...)
 }
 
-func (s *mapStore) setNoLock(
-	m map[string]*entry, value []byte, keys ...string) {
+func (s *mapStore) setNoLock(m map[string]*entry,
+	value []byte, expiresAt time.Time, keys ...string) {
 	_, ok := m[keys[0]]
 	if !ok {
 		m[keys[0]] = &entry{}
@@ -65,13 +79,35 @@ func (s *mapStore) setNoLock(
 
 	if len(keys) == 1 {
 		m[keys[0]].value = value
+		m[keys[0]].expiresAt = expiresAt
 		return
 	}
 
 	if m[keys[0]].namespace == nil {
 		m[keys[0]].namespace = make(map[string]*entry)
 	}
-	s.setNoLock(m[keys[0]].namespace, value, keys[1:]...)
+	s.setNoLock(m[keys[0]].namespace, value, expiresAt, keys[1:]...)
+}
+
+// expire removes the values expired at now.
+func (s *mapStore) expire(now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	s.expireNoLock(s.m, now)
+}
+
+func (s *mapStore) expireNoLock(m map[string]*entry, now time.Time) {
+	for k, e := range m {
+		if e.value != nil && !e.live(now) {
+			e.value = nil
+			e.expiresAt = time.Time{}
+		}
+		if e.namespace != nil {
+			s.expireNoLock(e.namespace, now)
+		} else if e.value == nil {
+			delete(m, k)
+		}
+	}
 }
 
 func (s *mapStore) del(keys ...string) {
@@ -87,6 +123,7 @@ func (s *mapStore) delNoLock(m map[string]*entry, keys ...string) {
 	}
 	if len(keys) == 1 {
 		m[keys[0]].value = nil
+		m[keys[0]].expiresAt = time.Time{}
 		return
 	}
 	if m[keys[0]].namespace == nil {
@@ -114,9 +151,10 @@ func (s *mapStore) iter(
 func (s *mapStore) getMapNoLock(m map[string]*entry, keys ...string) (
 	map[string][]byte, bool) {
 	if len(keys) == 0 {
+		now := time.Now()
 		clonedMap := make(map[string][]byte)
 		for k, e := range m {
-			if e.value == nil {
+			if !e.live(now) {
 				continue
 			}
 			cloned := make([]byte, len(e.value))
@@ -135,6 +173,64 @@ func (s *mapStore) getMapNoLock(m map[string]*entry, keys ...string) (
 	return s.getMapNoLock(e.namespace, keys[1:]...)
 }
 
+func (s *mapStore) scan(prefix, start string,
+	f func(key string, val []byte) (next bool), path ...string) (bool, error) {
+	s.RLock()
+	m, exists := s.entriesNoLock(path...)
+	now := time.Now()
+	var keys []string
+	for key, e := range m {
+		if e.live(now) && strings.HasPrefix(key, prefix) && key >= start {
+			keys = append(keys, key)
+		}
+	}
+	s.RUnlock()
+	if !exists {
+		return false, nil
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		k := append(path[:0:0], path...)
+		value, _, ok := s.get(append(k, key)...)
+		if !ok {
+			// deleted after the keys are collected
+			continue
+		}
+		if !f(key, value) {
+			break
+		}
+	}
+	return true, nil
+}
+
+func (s *mapStore) entriesNoLock(path ...string) (map[string]*entry, bool) {
+	m := s.m
+	for _, name := range path {
+		e, ok := m[name]
+		if !ok || e.namespace == nil {
+			return nil, false
+		}
+		m = e.namespace
+	}
+	return m, true
+}
+
+func (s *mapStore) namespaces(path ...string) ([]string, bool, error) {
+	s.RLock()
+	defer s.RUnlock()
+	m, exists := s.entriesNoLock(path...)
+	if !exists {
+		return nil, false, nil
+	}
+	var names []string
+	for name, e := range m {
+		if e.namespace != nil {
+			names = append(names, name)
+		}
+	}
+	return names, true, nil
+}
+
 func (s *mapStore) drop(path ...string) {
 	s.Lock()
 	defer s.Unlock()
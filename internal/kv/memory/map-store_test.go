@@ -2,6 +2,7 @@ package memory
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -14,7 +15,13 @@ func TestLogic(t *testing.T) {
 		require.NoError(t, store.Close())
 	}()
 	kvtest.StoreLogicTest(t, store)
+	kvtest.StoreUpdateTest(t, store)
+	kvtest.StoreCompareAndSetTest(t, store)
+	kvtest.StoreIterateFromTest(t, store)
+	kvtest.StoreTTLTest(t, store)
+	kvtest.StoreSlashKeyTest(t, store)
 	kvtest.StoreLogicTest(t, store)
+	kvtest.StoreWalkTest(t, store)
 }
 
 func TestConcurrent(t *testing.T) {
@@ -22,3 +29,17 @@ func TestConcurrent(t *testing.T) {
 	kvtest.StoreConcurrentTest(t, store, 1<<12, true)
 	require.NoError(t, store.Close())
 }
+
+func TestExpire(t *testing.T) {
+	s := newMapStore().(*mapStore)
+	now := time.Now()
+	s.set([]byte("v"), now.Add(time.Hour), "A", "expired")
+	s.set([]byte("v"), now.Add(3*time.Hour), "A", "kept")
+	s.set([]byte("v"), time.Time{}, "A", "forever")
+
+	s.expire(now.Add(2 * time.Hour))
+	m, exists := s.entriesNoLock("A")
+	require.True(t, exists)
+	require.Len(t, m, 2)
+	require.NotContains(t, m, "expired")
+}
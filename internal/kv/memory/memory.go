@@ -1,7 +1,9 @@
 package memory
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
 	"github.com/haostudio/golinks/internal/kv"
 )
@@ -23,7 +25,8 @@ func newStore(store store) kv.Store {
 }
 
 type container struct {
-	store store
+	store  store
+	txLock sync.RWMutex
 }
 
 // Close finalizes a kv.Store.
@@ -33,7 +36,13 @@ func (c *container) Close() error {
 
 // In returns the namespace instance with path.
 func (c *container) In(path ...string) kv.Namespace {
-	return &namespace{c.store, path}
+	return &namespace{store: c.store, path: path, txLock: &c.txLock}
+}
+
+// Update runs f in a transaction on the root namespace.
+func (c *container) Update(
+	ctx context.Context, f func(tx kv.Namespace) error) error {
+	return c.In().Update(ctx, f)
 }
 
 func (c *container) String() string {
@@ -3,6 +3,9 @@ package memory
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/haostudio/golinks/internal/kv"
 )
@@ -10,6 +13,10 @@ import (
 type namespace struct {
 	store store
 	path  []string
+	// txLock serializes the transactions with the other operations.
+	txLock *sync.RWMutex
+	// tx is the transaction the namespace is bound to, if any.
+	tx *transaction
 }
 
 // In returns the namespace instance with path.
@@ -19,35 +26,90 @@ func (n *namespace) In(path ...string) kv.Namespace {
 	}
 	p := append(n.path[:0:0], n.path...)
 	p = append(p, path...)
-	return &namespace{n.store, p}
+	return &namespace{n.store, p, n.txLock, n.tx}
 }
 
 func (n *namespace) Get(ctx context.Context, key string) ([]byte, error) {
+	defer n.rlock()()
 	keys := append(n.path[:0:0], n.path...)
 	keys = append(keys, key)
-	v, ok := n.store.get(keys...)
+	v, _, ok := n.store.get(keys...)
 	if !ok {
 		return nil, kv.ErrNotFound
 	}
 	return v, nil
 }
 
+// TTL returns the remaining time to live of the value with key.
+func (n *namespace) TTL(ctx context.Context, key string) (
+	time.Duration, error) {
+	defer n.rlock()()
+	keys := append(n.path[:0:0], n.path...)
+	keys = append(keys, key)
+	_, expiresAt, ok := n.store.get(keys...)
+	if !ok {
+		return 0, kv.ErrNotFound
+	}
+	if expiresAt.IsZero() {
+		return 0, nil
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return 0, kv.ErrNotFound
+	}
+	return ttl, nil
+}
+
 func (n *namespace) Set(ctx context.Context, key string, value []byte) error {
+	return n.set(key, value, time.Time{})
+}
+
+// SetWithTTL sets the value in the namespace with key, which expires after
+// ttl.
+func (n *namespace) SetWithTTL(ctx context.Context,
+	key string, value []byte, ttl time.Duration) error {
+	return n.set(key, value, time.Now().Add(ttl))
+}
+
+func (n *namespace) set(key string, value []byte, expiresAt time.Time) error {
+	defer n.lock()()
+	keys := append(n.path[:0:0], n.path...)
+	keys = append(keys, key)
+	n.tx.record(n.store, keys)
+	n.store.set(value, expiresAt, keys...)
+	return nil
+}
+
+// CompareAndSet sets the value with key if the current value equals old.
+func (n *namespace) CompareAndSet(
+	ctx context.Context, key string, old, value []byte) error {
+	defer n.lock()()
 	keys := append(n.path[:0:0], n.path...)
 	keys = append(keys, key)
-	n.store.set(value, keys...)
+	cur, _, ok := n.store.get(keys...)
+	if !ok {
+		cur = nil
+	}
+	if !kv.Equal(cur, old) {
+		return kv.ErrConflict
+	}
+	n.tx.record(n.store, keys)
+	n.store.set(value, time.Time{}, keys...)
 	return nil
 }
 
 func (n *namespace) Delete(ctx context.Context, key string) error {
+	defer n.lock()()
 	keys := append(n.path[:0:0], n.path...)
 	keys = append(keys, key)
+	n.tx.record(n.store, keys)
 	n.store.del(keys...)
 	return nil
 }
 
 func (n *namespace) Iterate(
 	ctx context.Context, f func(key string, value []byte) (next bool)) error {
+	defer n.rlock()()
 	exists, err := n.store.iter(f, n.path...)
 	if err != nil {
 		return err
@@ -58,12 +120,149 @@ func (n *namespace) Iterate(
 	return nil
 }
 
+// IterateFrom iterates the values with keys having prefix and not less than
+// start in the key order.
+func (n *namespace) IterateFrom(ctx context.Context, prefix, start string,
+	f func(key string, value []byte) (next bool)) error {
+	defer n.rlock()()
+	exists, err := n.store.scan(prefix, start, f, n.path...)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return kv.ErrNotFound
+	}
+	return nil
+}
+
+// Namespaces returns the sorted names of the child namespaces.
+func (n *namespace) Namespaces(ctx context.Context) ([]string, error) {
+	defer n.rlock()()
+	names, exists, err := n.store.namespaces(n.path...)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, kv.ErrNotFound
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // Drop drops all the data in the namespace.
 func (n *namespace) Drop(ctx context.Context) error {
+	defer n.lock()()
+	if n.tx != nil {
+		err := n.tx.recordAll(n.store, n.path)
+		if err != nil {
+			return err
+		}
+	}
 	n.store.drop(n.path...)
 	return nil
 }
 
+// Update runs f in a transaction emulated by locking the whole store. The
+// changes are reverted if f fails. Note that the values evicted by a lru store
+// in the transaction are not restored.
+func (n *namespace) Update(
+	ctx context.Context, f func(tx kv.Namespace) error) (err error) {
+	if n.tx != nil {
+		// already in a transaction
+		return f(n)
+	}
+	n.txLock.Lock()
+	defer n.txLock.Unlock()
+	tx := &transaction{}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			tx.rollback()
+			panic(recovered)
+		}
+	}()
+	err = f(&namespace{n.store, n.path, n.txLock, tx})
+	if err != nil {
+		tx.rollback()
+	}
+	return
+}
+
+func (n *namespace) rlock() (unlock func()) {
+	if n.tx != nil {
+		// the transaction holds the lock
+		return func() {}
+	}
+	n.txLock.RLock()
+	return n.txLock.RUnlock
+}
+
+func (n *namespace) lock() (unlock func()) {
+	if n.tx != nil {
+		// the transaction holds the lock
+		return func() {}
+	}
+	n.txLock.Lock()
+	return n.txLock.Unlock
+}
+
 func (n *namespace) String() string {
 	return fmt.Sprintf("memory.namespace(%s)", n.store)
 }
+
+// transaction records how to revert the changes of a transaction.
+type transaction struct {
+	undo []func()
+}
+
+// record records the value with keys before it changes. It's a no-op on a nil
+// transaction.
+func (tx *transaction) record(s store, keys []string) {
+	if tx == nil {
+		return
+	}
+	prev, expiresAt, exists := s.get(keys...)
+	tx.undo = append(tx.undo, func() {
+		if exists {
+			s.set(prev, expiresAt, keys...)
+			return
+		}
+		s.del(keys...)
+	})
+}
+
+// recordAll records the values in the namespace with path and its child
+// namespaces before they are dropped.
+func (tx *transaction) recordAll(s store, path []string) error {
+	var keys []string
+	_, err := s.iter(func(key string, val []byte) bool {
+		keys = append(keys, key)
+		return true
+	}, path...)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		k := append(path[:0:0], path...)
+		tx.record(s, append(k, key))
+	}
+	names, _, err := s.namespaces(path...)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		p := append(path[:0:0], path...)
+		err = tx.recordAll(s, append(p, name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollback reverts the recorded changes in the reverse order.
+func (tx *transaction) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+	tx.undo = nil
+}
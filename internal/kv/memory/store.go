@@ -1,10 +1,24 @@
 package memory
 
+import "time"
+
+// store defines the memory store interface. A zero expiresAt means the value
+// never expires.
 type store interface {
-	get(keys ...string) (value []byte, exists bool)
-	set(value []byte, keys ...string)
+	get(keys ...string) (value []byte, expiresAt time.Time, exists bool)
+	set(value []byte, expiresAt time.Time, keys ...string)
 	del(key ...string)
 	iter(f func(key string, val []byte) (next bool), key ...string) (
 		exists bool, err error)
+	scan(prefix, start string, f func(key string, val []byte) (next bool),
+		key ...string) (exists bool, err error)
 	drop(key ...string)
+	namespaces(key ...string) (names []string, exists bool, err error)
+}
+
+const expireInterval = time.Minute
+
+// expired returns true if a value expiring at expiresAt has expired at now.
+func expired(expiresAt time.Time, now time.Time) bool {
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
 }
@@ -0,0 +1,237 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/haostudio/golinks/internal/kv"
+)
+
+// Exported errors.
+var (
+	ErrVerifyFailed = errors.New("migrated namespace verification failed")
+)
+
+// Config defines the migration config.
+type Config struct {
+	Source      kv.Store
+	Destination kv.Store
+	// Paths are the paths of the namespaces to migrate.
+	Paths [][]string
+	// Checkpoint records the migrated namespaces so that a restarted
+	// migration skips the ones already verified. Optional.
+	Checkpoint kv.Namespace
+}
+
+// Result defines the result of a migrated namespace.
+type Result struct {
+	Path     []string
+	Count    int
+	Checksum string
+	// Skipped is true if the namespace was migrated by a previous run.
+	Skipped bool
+}
+
+// Run copies the namespaces in conf.Paths from the source to the destination
+// and verifies the counts and checksums. The keys in the destination that do
+// not exist in the source are deleted. progress, if not nil, is called after
+// each namespace is migrated.
+func Run(ctx context.Context, conf Config, progress func(Result)) (
+	[]Result, error) {
+	results := make([]Result, 0, len(conf.Paths))
+	for _, path := range conf.Paths {
+		res, err := migrate(ctx, conf, path)
+		if err != nil {
+			return results, fmt.Errorf("failed to migrate %s. %w",
+				strings.Join(path, "/"), err)
+		}
+		results = append(results, res)
+		if progress != nil {
+			progress(res)
+		}
+	}
+	return results, nil
+}
+
+// Paths walks store and returns the paths of all its namespaces, including
+// the root. The top level namespaces named in exclude are skipped.
+func Paths(ctx context.Context, store kv.Store, exclude ...string) (
+	[][]string, error) {
+	var paths [][]string
+	err := kv.Walk(ctx, store.In(), func(path []string, _ kv.Namespace) error {
+		if len(path) == 1 {
+			for _, name := range exclude {
+				if path[0] == name {
+					return kv.SkipNamespace
+				}
+			}
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// Checksum returns the number of keys and the sha256 checksum of the keys and
+// values in ns, which is independent of the iteration order.
+func Checksum(ctx context.Context, ns kv.Namespace) (
+	count int, checksum string, err error) {
+	sums, err := entrySums(ctx, ns)
+	if err != nil {
+		return
+	}
+	keys := make([]string, 0, len(sums))
+	for key := range sums {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		sum := sums[key]
+		_, _ = h.Write(sum[:])
+	}
+	count = len(keys)
+	checksum = hex.EncodeToString(h.Sum(nil))
+	return
+}
+
+func migrate(ctx context.Context, conf Config, path []string) (
+	res Result, err error) {
+	res.Path = path
+	src := conf.Source.In(path...)
+	dst := conf.Destination.In(path...)
+	res.Count, res.Checksum, err = Checksum(ctx, src)
+	if err != nil {
+		return
+	}
+
+	// skip the namespace verified in a previous run
+	checkpointKey := strings.Join(path, "/")
+	if conf.Checkpoint != nil {
+		var done []byte
+		done, err = conf.Checkpoint.Get(ctx, checkpointKey)
+		if err == nil && string(done) == res.Checksum {
+			res.Skipped, err = verify(ctx, dst, res)
+			if err != nil || res.Skipped {
+				return
+			}
+		} else if err != nil && !errors.Is(err, kv.ErrNotFound) {
+			return
+		}
+	}
+
+	err = copyNamespace(ctx, src, dst)
+	if err != nil {
+		return
+	}
+	ok, err := verify(ctx, dst, res)
+	if err != nil {
+		return
+	}
+	if !ok {
+		err = ErrVerifyFailed
+		return
+	}
+	if conf.Checkpoint != nil {
+		err = conf.Checkpoint.Set(ctx, checkpointKey, []byte(res.Checksum))
+	}
+	return
+}
+
+func copyNamespace(ctx context.Context, src, dst kv.Namespace) error {
+	// read all values before writing so that no write happens inside a read
+	// transaction in case the source and destination share a database.
+	entries := make(map[string][]byte)
+	err := src.Iterate(ctx, func(key string, value []byte) bool {
+		entries[key] = append(value[:0:0], value...)
+		return true
+	})
+	if err != nil && !errors.Is(err, kv.ErrNotFound) {
+		return err
+	}
+	for key, value := range entries {
+		// keep the expiry of the values set with TTL
+		ttl, err := src.TTL(ctx, key)
+		if errors.Is(err, kv.ErrNotFound) {
+			// expired after read
+			delete(entries, key)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if ttl > 0 {
+			err = dst.SetWithTTL(ctx, key, value, ttl)
+		} else {
+			err = dst.Set(ctx, key, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// delete the keys not in the source
+	var stale []string
+	err = dst.Iterate(ctx, func(key string, value []byte) bool {
+		if _, ok := entries[key]; !ok {
+			stale = append(stale, key)
+		}
+		return true
+	})
+	if err != nil && !errors.Is(err, kv.ErrNotFound) {
+		return err
+	}
+	for _, key := range stale {
+		err = dst.Delete(ctx, key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func verify(ctx context.Context, dst kv.Namespace, src Result) (
+	bool, error) {
+	count, checksum, err := Checksum(ctx, dst)
+	if err != nil {
+		return false, err
+	}
+	return count == src.Count && checksum == src.Checksum, nil
+}
+
+func entrySums(ctx context.Context, ns kv.Namespace) (
+	map[string][sha256.Size]byte, error) {
+	sums := make(map[string][sha256.Size]byte)
+	err := ns.Iterate(ctx, func(key string, value []byte) bool {
+		// length-prefixed key and value
+		b := make([]byte, 0, 16+len(key)+len(value))
+		b = appendUvarint(b, uint64(len(key)))
+		b = append(b, key...)
+		b = appendUvarint(b, uint64(len(value)))
+		b = append(b, value...)
+		sums[key] = sha256.Sum256(b)
+		return true
+	})
+	if errors.Is(err, kv.ErrNotFound) {
+		return sums, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+func appendUvarint(b []byte, x uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], x)
+	return append(b, buf[:n]...)
+}
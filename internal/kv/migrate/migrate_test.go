@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/kv"
+	"github.com/haostudio/golinks/internal/kv/bolt"
+	"github.com/haostudio/golinks/internal/kv/memory"
+)
+
+func fill(t *testing.T, store kv.Store) [][]string {
+	ctx := context.Background()
+	paths := [][]string{{"a"}, {"b", "c"}, {"empty"}}
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("key_%d", i)
+		value := []byte(fmt.Sprintf("value_%d", i))
+		require.NoError(t, store.In("a").Set(ctx, key, value))
+		require.NoError(t, store.In("b", "c").Set(ctx, key, value))
+	}
+	return paths
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	src := memory.New()
+	paths := fill(t, src)
+
+	// Prepare DB path
+	dir, err := os.Getwd()
+	require.NoError(t, err)
+	dbPath := filepath.Join(dir,
+		fmt.Sprintf("migrate_test_%d", time.Now().UnixNano()))
+	dst, err := bolt.New(dbPath, "test.db", "test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, dst.Close())
+		require.NoError(t, os.RemoveAll(dbPath))
+	}()
+	// stale key in destination
+	require.NoError(t, dst.In("a").Set(ctx, "stale", []byte("stale")))
+
+	conf := Config{
+		Source:      src,
+		Destination: dst,
+		Paths:       paths,
+		Checkpoint:  dst.In("_migrate"),
+	}
+	var progress int
+	results, err := Run(ctx, conf, func(Result) { progress++ })
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, 3, progress)
+	for i, res := range results {
+		require.Equal(t, paths[i], res.Path)
+		require.False(t, res.Skipped)
+		count, checksum, err := Checksum(ctx, dst.In(paths[i]...))
+		require.NoError(t, err)
+		require.Equal(t, res.Count, count)
+		require.Equal(t, res.Checksum, checksum)
+	}
+	require.Equal(t, 10, results[0].Count)
+	require.Equal(t, 0, results[2].Count)
+	_, err = dst.In("a").Get(ctx, "stale")
+	require.Equal(t, kv.ErrNotFound, err)
+
+	// restart skips the verified namespaces
+	require.NoError(t, src.In("b", "c").Set(ctx, "new", []byte("new")))
+	require.NoError(t, src.In("b", "c").SetWithTTL(
+		ctx, "ttl", []byte("ttl"), time.Hour))
+	results, err = Run(ctx, conf, nil)
+	require.NoError(t, err)
+	require.True(t, results[0].Skipped)
+	require.False(t, results[1].Skipped)
+	require.Equal(t, 12, results[1].Count)
+	v, err := dst.In("b", "c").Get(ctx, "new")
+	require.NoError(t, err)
+	require.Equal(t, []byte("new"), v)
+	ttl, err := dst.In("b", "c").TTL(ctx, "ttl")
+	require.NoError(t, err)
+	require.True(t, ttl > 0 && ttl <= time.Hour)
+
+	// changed destination is migrated again
+	require.NoError(t, dst.In("a").Set(ctx, "key_0", []byte("changed")))
+	results, err = Run(ctx, conf, nil)
+	require.NoError(t, err)
+	require.False(t, results[0].Skipped)
+	v, err = dst.In("a").Get(ctx, "key_0")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value_0"), v)
+}
+
+func TestChecksum(t *testing.T) {
+	ctx := context.Background()
+	s1 := memory.New()
+	fill(t, s1)
+	_, sum1, err := Checksum(ctx, s1.In("a"))
+	require.NoError(t, err)
+	_, sum2, err := Checksum(ctx, s1.In("b", "c"))
+	require.NoError(t, err)
+	require.Equal(t, sum1, sum2)
+
+	require.NoError(t, s1.In("a").Set(ctx, "key_0", []byte("changed")))
+	_, sum3, err := Checksum(ctx, s1.In("a"))
+	require.NoError(t, err)
+	require.NotEqual(t, sum1, sum3)
+}
+
+func TestPaths(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	fill(t, store)
+	require.NoError(t, store.In("_migrate").Set(ctx, "a", []byte("sum")))
+	paths, err := Paths(ctx, store, "_migrate")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{nil, {"a"}, {"b"}, {"b", "c"}}, paths)
+}
@@ -3,6 +3,7 @@ package kv
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
 // Store defines a key-value store interface.
@@ -13,6 +14,8 @@ type Store interface {
 	Close() error
 	// In returns the namespace instance with path.
 	In(path ...string) Namespace
+	// Update runs f in a read-write transaction on the root namespace.
+	Update(ctx context.Context, f func(tx Namespace) error) error
 }
 
 // Namespace defines a namespace in a kv.Store.
@@ -25,11 +28,33 @@ type Namespace interface {
 	Get(ctx context.Context, key string) ([]byte, error)
 	// Set sets the value in the namespace with key.
 	Set(ctx context.Context, key string, value []byte) error
+	// SetWithTTL sets the value in the namespace with key, which expires after
+	// ttl. Expired values are treated as not found and removed eventually. The
+	// values set by Set and CompareAndSet never expire.
+	SetWithTTL(ctx context.Context, key string, value []byte,
+		ttl time.Duration) error
+	// TTL returns the remaining time to live of the value with key, or 0 if it
+	// never expires.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// CompareAndSet sets the value with key only if the current value equals
+	// old, or doesn't exist if old is nil. ErrConflict is returned otherwise.
+	CompareAndSet(ctx context.Context, key string, old, value []byte) error
 	// Delete deletes the value in the namespace with key.
 	Delete(ctx context.Context, key string) error
 	// Iterate iterates the values in the namespace.
 	Iterate(
 		ctx context.Context, f func(key string, value []byte) (next bool)) error
+	// IterateFrom iterates the values with keys having prefix and not less
+	// than start in the key order.
+	IterateFrom(ctx context.Context, prefix, start string,
+		f func(key string, value []byte) (next bool)) error
 	// Drop drops all the values in the namespace.
 	Drop(ctx context.Context) error
+	// Namespaces returns the sorted names of the child namespaces.
+	Namespaces(ctx context.Context) ([]string, error)
+	// Update runs f in a read-write transaction. The changes made through tx
+	// and the namespaces derived from it are committed atomically if f returns
+	// nil, and discarded otherwise. f must not use the namespaces outside tx
+	// for writes, which may deadlock.
+	Update(ctx context.Context, f func(tx Namespace) error) error
 }
@@ -3,6 +3,7 @@ package traced
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.opencensus.io/trace"
 
@@ -19,7 +20,12 @@ type namespace struct {
 func (n *namespace) In(path ...string) kv.Namespace {
 	p := append(n.root[:0:0], n.root...)
 	p = append(p, path...)
-	return n.store.In(p...)
+	// derive from ns to keep the transaction it may be bound to
+	return &namespace{
+		store: n.store,
+		root:  p,
+		ns:    n.ns.In(path...),
+	}
 }
 
 // Get returns the value in the namespace with key.
@@ -45,6 +51,43 @@ func (n *namespace) Set(ctx context.Context, key string, value []byte) (
 	return n.ns.Set(ctx, key, value)
 }
 
+// SetWithTTL sets the value in the namespace with key, which expires after
+// ttl.
+func (n *namespace) SetWithTTL(ctx context.Context,
+	key string, value []byte, ttl time.Duration) error {
+	ctx, span := trace.StartSpan(ctx, "namespace.SetWithTTL")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("type", "kv_store"))
+	span.AddAttributes(trace.StringAttribute("store", n.store.store.String()))
+	span.AddAttributes(trace.StringAttribute("namespace", n.ns.String()))
+	span.AddAttributes(trace.StringAttribute("kv_key", key))
+	return n.ns.SetWithTTL(ctx, key, value, ttl)
+}
+
+// TTL returns the remaining time to live of the value with key.
+func (n *namespace) TTL(ctx context.Context, key string) (
+	time.Duration, error) {
+	ctx, span := trace.StartSpan(ctx, "namespace.TTL")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("type", "kv_store"))
+	span.AddAttributes(trace.StringAttribute("store", n.store.store.String()))
+	span.AddAttributes(trace.StringAttribute("namespace", n.ns.String()))
+	span.AddAttributes(trace.StringAttribute("kv_key", key))
+	return n.ns.TTL(ctx, key)
+}
+
+// CompareAndSet sets the value with key if the current value equals old.
+func (n *namespace) CompareAndSet(
+	ctx context.Context, key string, old, value []byte) (err error) {
+	ctx, span := trace.StartSpan(ctx, "namespace.CompareAndSet")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("type", "kv_store"))
+	span.AddAttributes(trace.StringAttribute("store", n.store.store.String()))
+	span.AddAttributes(trace.StringAttribute("namespace", n.ns.String()))
+	span.AddAttributes(trace.StringAttribute("kv_key", key))
+	return n.ns.CompareAndSet(ctx, key, old, value)
+}
+
 // Delete deletes the value in the namespace with key.
 func (n *namespace) Delete(ctx context.Context, key string) (err error) {
 	ctx, span := trace.StartSpan(ctx, "namespace.Delete")
@@ -67,6 +110,48 @@ func (n *namespace) Iterate(ctx context.Context,
 	return n.ns.Iterate(ctx, f)
 }
 
+// IterateFrom iterates the values with keys having prefix and not less than
+// start in the key order.
+func (n *namespace) IterateFrom(ctx context.Context, prefix, start string,
+	f func(key string, value []byte) (next bool)) (err error) {
+	ctx, span := trace.StartSpan(ctx, "namespace.IterateFrom")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("type", "kv_store"))
+	span.AddAttributes(trace.StringAttribute("store", n.store.store.String()))
+	span.AddAttributes(trace.StringAttribute("namespace", n.ns.String()))
+	span.AddAttributes(trace.StringAttribute("kv_prefix", prefix))
+	span.AddAttributes(trace.StringAttribute("kv_start", start))
+	return n.ns.IterateFrom(ctx, prefix, start, f)
+}
+
+// Namespaces returns the sorted names of the child namespaces.
+func (n *namespace) Namespaces(ctx context.Context) (names []string,
+	err error) {
+	ctx, span := trace.StartSpan(ctx, "namespace.Namespaces")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("type", "kv_store"))
+	span.AddAttributes(trace.StringAttribute("store", n.store.store.String()))
+	span.AddAttributes(trace.StringAttribute("namespace", n.ns.String()))
+	return n.ns.Namespaces(ctx)
+}
+
+// Update runs f in a read-write transaction.
+func (n *namespace) Update(
+	ctx context.Context, f func(tx kv.Namespace) error) (err error) {
+	ctx, span := trace.StartSpan(ctx, "namespace.Update")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("type", "kv_store"))
+	span.AddAttributes(trace.StringAttribute("store", n.store.store.String()))
+	span.AddAttributes(trace.StringAttribute("namespace", n.ns.String()))
+	return n.ns.Update(ctx, func(tx kv.Namespace) error {
+		return f(&namespace{
+			store: n.store,
+			root:  n.root,
+			ns:    tx,
+		})
+	})
+}
+
 // Drop drops all the values in the namespace.
 func (n *namespace) Drop(ctx context.Context) (err error) {
 	ctx, span := trace.StartSpan(ctx, "namespace.Drop")
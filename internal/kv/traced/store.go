@@ -1,6 +1,7 @@
 package traced
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/haostudio/golinks/internal/kv"
@@ -33,6 +34,12 @@ func (s *store) In(path ...string) kv.Namespace {
 	}
 }
 
+// Update runs f in a read-write transaction on the root namespace.
+func (s *store) Update(
+	ctx context.Context, f func(tx kv.Namespace) error) error {
+	return s.In().Update(ctx, f)
+}
+
 func (s *store) String() string {
 	return fmt.Sprintf("traced(%s)", s.store)
 }
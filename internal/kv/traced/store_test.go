@@ -10,6 +10,12 @@ import (
 func TestLogic(t *testing.T) {
 	store := New(memory.New())
 	kvtest.StoreLogicTest(t, store)
+	kvtest.StoreUpdateTest(t, store)
+	kvtest.StoreCompareAndSetTest(t, store)
+	kvtest.StoreIterateFromTest(t, store)
+	kvtest.StoreTTLTest(t, store)
+	kvtest.StoreSlashKeyTest(t, store)
+	kvtest.StoreWalkTest(t, store)
 }
 
 func TestConcurrent(t *testing.T) {
@@ -0,0 +1,46 @@
+package kv
+
+import (
+	"context"
+	"errors"
+)
+
+// SkipNamespace is returned by a WalkFunc to skip the child namespaces of the
+// visited namespace. It is not returned as an error by Walk.
+var SkipNamespace = errors.New("skip this namespace")
+
+// WalkFunc is called by Walk for each namespace with its path relative to the
+// walked namespace.
+type WalkFunc func(path []string, ns Namespace) error
+
+// Walk walks ns and its child namespaces recursively in depth-first, lexical
+// order. f is called for ns itself with an empty path first. A namespace that
+// disappears during the walk is skipped.
+func Walk(ctx context.Context, ns Namespace, f WalkFunc) error {
+	return walk(ctx, nil, ns, f)
+}
+
+func walk(ctx context.Context, path []string, ns Namespace, f WalkFunc) error {
+	err := f(path, ns)
+	if errors.Is(err, SkipNamespace) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	names, err := ns.Namespaces(ctx)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		sub := append(path[:len(path):len(path)], name)
+		err = walk(ctx, sub, ns.In(name), f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
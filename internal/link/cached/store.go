@@ -2,6 +2,7 @@ package cached
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -100,6 +101,13 @@ func (s *store) GetLinks(ctx context.Context, org string) (
 	return
 }
 
+// ScanLinks returns the links from the canonical store, since the pages are
+// not cached.
+func (s *store) ScanLinks(ctx context.Context, org string, prefix string,
+	cursor string, limit int) (link.Page, error) {
+	return s.canonical.ScanLinks(ctx, org, prefix, cursor, limit)
+}
+
 func (s *store) UpdateLink(
 	ctx context.Context, org string, key string, ln link.Link) error {
 	cacheKey := s.cacheKey(org, key)
@@ -118,6 +126,30 @@ func (s *store) UpdateLink(
 	return nil
 }
 
+func (s *store) UpdateLinkIf(ctx context.Context,
+	org string, key string, rev int64, ln link.Link) error {
+	cacheKey := s.cacheKey(org, key)
+	err := s.canonical.UpdateLinkIf(ctx, org, key, rev, ln)
+	if errors.Is(err, link.ErrConflict) {
+		// best effort to delete the stale cache
+		_ = s.cache.kv.Delete(ctx, cacheKey)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	// best effort to update cache
+	ln.Meta.Revision = rev + 1
+	b, err := s.cache.enc.Encode(ln)
+	if err != nil {
+		// ignore this error
+		return nil
+	}
+	// ignore this error
+	_ = s.cache.kv.Set(ctx, cacheKey, b)
+	return nil
+}
+
 func (s *store) DeleteLink(ctx context.Context, org string, key string) error {
 	cacheKey := s.cacheKey(org, key)
 	err := s.canonical.DeleteLink(ctx, org, key)
@@ -130,6 +162,20 @@ func (s *store) DeleteLink(ctx context.Context, org string, key string) error {
 	return nil
 }
 
+func (s *store) DeleteLinkIf(
+	ctx context.Context, org string, key string, rev int64) error {
+	err := s.canonical.DeleteLinkIf(ctx, org, key, rev)
+	// best effort to delete the deleted or stale cache
+	// ignore this error
+	_ = s.cache.kv.Delete(ctx, s.cacheKey(org, key))
+	return err
+}
+
+// GetOrgs returns the orgs from the canonical store.
+func (s *store) GetOrgs(ctx context.Context) ([]string, error) {
+	return s.canonical.GetOrgs(ctx)
+}
+
 func (s *store) cacheKey(org, key string) string {
 	return strings.Join([]string{cachePrefix, org, key}, ".")
 }
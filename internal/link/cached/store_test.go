@@ -15,4 +15,10 @@ func TestStoreLogic(t *testing.T) {
 	canonical := kv.New(kvStore.In("test"), enc)
 	store := New(canonical, kvStore.In("cache"), enc)
 	linktest.StoreLogicTest(t, store)
+	linktest.StoreUpdateLinkIfTest(t, store)
+	linktest.StoreDeleteLinkIfTest(t, store)
+	linktest.StoreScanLinksTest(t, store)
+	linktest.StoreGetOrgsTest(t, store)
+	linktest.StoreLookupTest(t, store)
+	linktest.StoreAliasTest(t, store)
 }
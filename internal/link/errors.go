@@ -1,10 +1,34 @@
 package link
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Exported errors.
 var (
 	ErrVersionNotSupport = errors.New("link version is not support")
 	ErrInvalidParams     = errors.New("invalid parameters passed")
 	ErrNotFound          = errors.New("link not found")
+	ErrConflict          = errors.New("link has been changed")
+	ErrInvalidPayload    = errors.New("invalid link payload")
+	ErrNotActive         = errors.New("link is not active yet")
+	ErrAlias             = errors.New("link is an alias")
+	ErrAliasLoop         = errors.New("link aliases form a loop")
+	ErrNotOwner          = errors.New("user is not the link owner")
 )
+
+// MissingParamError defines the error of a link resolved without the value of
+// a parameter. It unwraps to ErrInvalidParams.
+type MissingParamError struct {
+	Name string
+}
+
+func (e *MissingParamError) Error() string {
+	return fmt.Sprintf("%v: missing %s", ErrInvalidParams, e.Name)
+}
+
+// Unwrap returns ErrInvalidParams.
+func (e *MissingParamError) Unwrap() error {
+	return ErrInvalidParams
+}
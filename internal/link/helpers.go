@@ -1,6 +1,14 @@
 package link
 
-import "strings"
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// MaxKeyDepth is the maximum number of segments of the keys Lookup matches.
+const MaxKeyDepth = 8
 
 // Parse parses the url and returns the key and param.
 // key: the key of the link record without leading and trailing "/".
@@ -24,3 +32,52 @@ func Pop(str string, sep string) (left string, remain string) {
 	}
 	return
 }
+
+// Lookup returns the link of org with the longest key matching the leading
+// segments of url, and the remaining segments as the param.
+// "/team/infra/oncall" -> ("team/infra", "oncall") if "team/infra" exists
+// ErrNotFound is returned with the first segment as the key and the rest as
+// the param if no key matches, as Parse.
+func Lookup(ctx context.Context, store Store, org string, url string) (
+	key string, param string, ln Link, err error) {
+	path := strings.Trim(url, "/")
+	segments := strings.Split(path, "/")
+	if len(segments) > MaxKeyDepth {
+		segments = segments[:MaxKeyDepth]
+	}
+	for i := len(segments); i > 0; i-- {
+		key = strings.Join(segments[:i], "/")
+		ln, err = store.GetLink(ctx, org, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		param = strings.TrimPrefix(strings.TrimPrefix(path, key), "/")
+		return
+	}
+	key, param = Parse(path)
+	return key, param, Link{}, ErrNotFound
+}
+
+// ValidKey returns true if key is a valid link key, which is not empty and
+// doesn't have empty segments.
+func ValidKey(key string) bool {
+	if len(key) == 0 {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if len(segment) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// EscapeKey escapes key as a single url path segment, e.g. "team/infra" ->
+// "team%2Finfra".
+func EscapeKey(key string) string {
+	// "+" is unescaped as a space in the path parameters.
+	return strings.Replace(url.PathEscape(key), "+", "%2B", -1)
+}
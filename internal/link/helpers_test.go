@@ -0,0 +1,22 @@
+package link
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidKey(t *testing.T) {
+	for _, key := range []string{"abc", "team/infra", "a.b-c/d_e"} {
+		require.True(t, ValidKey(key), key)
+	}
+	for _, key := range []string{"", "/abc", "abc/", "team//infra"} {
+		require.False(t, ValidKey(key), key)
+	}
+}
+
+func TestEscapeKey(t *testing.T) {
+	require.Equal(t, "abc", EscapeKey("abc"))
+	require.Equal(t, "team%2Finfra", EscapeKey("team/infra"))
+	require.Equal(t, "c%2B%2B%20faq", EscapeKey("c++ faq"))
+}
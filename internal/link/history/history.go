@@ -0,0 +1,52 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/haostudio/golinks/internal/link"
+)
+
+// Exported errors.
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+// Revision defines an immutable snapshot of a link before it was changed.
+type Revision struct {
+	ID int
+	// Link is the link before the change.
+	Link link.Link
+	// Deleted is true if the link was deleted by the change.
+	Deleted bool
+	// Editor and Time describe who made the change and when.
+	Editor string
+	Time   time.Time
+}
+
+// Store defines a link store that records the revisions of links.
+type Store interface {
+	link.Store
+
+	// GetRevisions returns the revisions of the link with key in org, ordered
+	// from the oldest to the latest.
+	GetRevisions(ctx context.Context, org string, key string) (
+		[]Revision, error)
+	// Restore restores the link with key in org to the revision with id.
+	Restore(ctx context.Context, org string, key string, id int) error
+}
+
+type editorKey struct{}
+
+// WithEditor returns a copy of ctx carrying the email of the editor, which is
+// recorded in the revisions.
+func WithEditor(ctx context.Context, email string) context.Context {
+	return context.WithValue(ctx, editorKey{}, email)
+}
+
+// Editor returns the email of the editor carried in ctx.
+func Editor(ctx context.Context) string {
+	email, _ := ctx.Value(editorKey{}).(string)
+	return email
+}
@@ -0,0 +1,254 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/haostudio/golinks/internal/encoding"
+	"github.com/haostudio/golinks/internal/kv"
+	"github.com/haostudio/golinks/internal/link"
+)
+
+// New returns a store recording the revisions of the links in store to the
+// namespace at path of root, the root namespace of store. A change and its
+// revision are made in one transaction of root.
+func New(store link.TxStore, root kv.Namespace, path string,
+	enc encoding.Binary) Store {
+	return &historyStore{
+		Store: store,
+		links: store,
+		root:  root,
+		path:  path,
+		kv:    root.In(path),
+		enc:   enc,
+	}
+}
+
+type historyStore struct {
+	link.Store
+	// serializes the changes so that revision ids are sequential.
+	sync.Mutex
+	links link.TxStore
+	root  kv.Namespace
+	path  string
+	kv    kv.Namespace
+	enc   encoding.Binary
+}
+
+func (s *historyStore) UpdateLink(
+	ctx context.Context, org string, key string, ln link.Link) error {
+	s.Lock()
+	defer s.Unlock()
+	editor := Editor(ctx)
+	if editor == "" {
+		editor = ln.Meta.UpdatedBy
+	}
+	return s.change(ctx, org, key, editor, false,
+		func(links link.Store, _ kv.Namespace) error {
+			return links.UpdateLink(ctx, org, key, ln)
+		},
+	)
+}
+
+func (s *historyStore) UpdateLinkIf(ctx context.Context,
+	org string, key string, rev int64, ln link.Link) error {
+	s.Lock()
+	defer s.Unlock()
+	editor := Editor(ctx)
+	if editor == "" {
+		editor = ln.Meta.UpdatedBy
+	}
+	return s.change(ctx, org, key, editor, false,
+		func(links link.Store, _ kv.Namespace) error {
+			return links.UpdateLinkIf(ctx, org, key, rev, ln)
+		},
+	)
+}
+
+func (s *historyStore) DeleteLink(
+	ctx context.Context, org string, key string) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.change(ctx, org, key, Editor(ctx), true,
+		func(links link.Store, _ kv.Namespace) error {
+			return links.DeleteLink(ctx, org, key)
+		},
+	)
+}
+
+func (s *historyStore) DeleteLinkIf(
+	ctx context.Context, org string, key string, rev int64) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.change(ctx, org, key, Editor(ctx), true,
+		func(links link.Store, _ kv.Namespace) error {
+			return links.DeleteLinkIf(ctx, org, key, rev)
+		},
+	)
+}
+
+func (s *historyStore) GetRevisions(
+	ctx context.Context, org string, key string) ([]Revision, error) {
+	var revs []Revision
+	err := s.kv.In(org).In(key).Iterate(ctx,
+		func(id string, value []byte) bool {
+			if id == lastIDKey {
+				return true
+			}
+			var rev Revision
+			iterErr := s.enc.Decode(value, &rev)
+			if iterErr != nil {
+				return true
+			}
+			revs = append(revs, rev)
+			return true
+		},
+	)
+	if errors.Is(err, kv.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(revs, func(i, j int) bool {
+		return revs[i].ID < revs[j].ID
+	})
+	return revs, nil
+}
+
+func (s *historyStore) Restore(
+	ctx context.Context, org string, key string, id int) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.change(ctx, org, key, Editor(ctx), false,
+		func(links link.Store, revs kv.Namespace) error {
+			b, err := revs.Get(ctx, revisionKey(id))
+			if errors.Is(err, kv.ErrNotFound) {
+				return ErrRevisionNotFound
+			}
+			if err != nil {
+				return err
+			}
+			var rev Revision
+			err = s.enc.Decode(b, &rev)
+			if err != nil {
+				return err
+			}
+			ln := rev.Link
+			prev, err := getLink(ctx, links, org, key)
+			if err != nil {
+				return err
+			}
+			ln.Touch(Editor(ctx), time.Now(), prev)
+			return links.UpdateLink(ctx, org, key, ln)
+		},
+	)
+}
+
+func (s *historyStore) String() string {
+	return fmt.Sprintf("history.store(%s|%s/%s)", s.Store, s.kv, s.enc)
+}
+
+// change applies the change and appends the previous link, if any, as a new
+// revision in one transaction. apply is given the links and the revisions of
+// the link in the transaction. It must be called with the lock held.
+func (s *historyStore) change(ctx context.Context, org string, key string,
+	editor string, deleted bool,
+	apply func(links link.Store, revs kv.Namespace) error) error {
+	return s.root.Update(ctx, func(tx kv.Namespace) error {
+		links := s.links.WithTx(tx)
+		revs := tx.In(s.path).In(org).In(key)
+		prev, err := getLink(ctx, links, org, key)
+		if err != nil {
+			return err
+		}
+		err = apply(links, revs)
+		if err != nil {
+			return err
+		}
+		if prev == nil {
+			// nothing to record for a new link.
+			return nil
+		}
+		id, err := nextID(ctx, revs)
+		if err != nil {
+			return err
+		}
+		b, err := s.enc.Encode(Revision{
+			ID:      id,
+			Link:    *prev,
+			Deleted: deleted,
+			Editor:  editor,
+			Time:    time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		err = revs.Set(ctx, revisionKey(id), b)
+		if err != nil {
+			return err
+		}
+		return revs.Set(ctx, lastIDKey, []byte(strconv.Itoa(id)))
+	})
+}
+
+func getLink(ctx context.Context, links link.Store, org string, key string) (
+	*link.Link, error) {
+	ln, err := links.GetLink(ctx, org, key)
+	if errors.Is(err, link.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ln, nil
+}
+
+// nextID returns the id of the next revision in revs.
+func nextID(ctx context.Context, revs kv.Namespace) (int, error) {
+	b, err := revs.Get(ctx, lastIDKey)
+	if errors.Is(err, kv.ErrNotFound) {
+		// the revisions were recorded before the last id was.
+		return scanNextID(ctx, revs)
+	}
+	if err != nil {
+		return 0, err
+	}
+	last, err := strconv.Atoi(string(b))
+	if err != nil {
+		return 0, fmt.Errorf("invalid last revision id %q. %v", b, err)
+	}
+	return last + 1, nil
+}
+
+// scanNextID returns the id of the next revision by iterating revs.
+func scanNextID(ctx context.Context, revs kv.Namespace) (int, error) {
+	var last int
+	err := revs.Iterate(ctx,
+		func(id string, value []byte) bool {
+			i, parseErr := strconv.Atoi(id)
+			if parseErr == nil && i > last {
+				last = i
+			}
+			return true
+		},
+	)
+	if err != nil && !errors.Is(err, kv.ErrNotFound) {
+		return 0, err
+	}
+	return last + 1, nil
+}
+
+// lastIDKey is the key of the id of the latest revision of a link, which
+// doesn't collide with the zero-padded revision keys.
+const lastIDKey = "last"
+
+// revisionKey returns the zero-padded id so that the keys sort in order.
+func revisionKey(id int) string {
+	return fmt.Sprintf("%010d", id)
+}
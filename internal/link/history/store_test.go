@@ -0,0 +1,168 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+	"github.com/haostudio/golinks/internal/kv"
+	"github.com/haostudio/golinks/internal/kv/memory"
+	"github.com/haostudio/golinks/internal/link"
+	lnkv "github.com/haostudio/golinks/internal/link/kv"
+	"github.com/haostudio/golinks/internal/link/linktest"
+)
+
+func newTestStore() Store {
+	kvStore := memory.New()
+	enc := gob.New()
+	return New(lnkv.NewIn(kvStore.In(), "link", enc), kvStore.In(), "history", enc)
+}
+
+func TestStoreLogic(t *testing.T) {
+	linktest.StoreLogicTest(t, newTestStore())
+	linktest.StoreUpdateLinkIfTest(t, newTestStore())
+	linktest.StoreDeleteLinkIfTest(t, newTestStore())
+	linktest.StoreScanLinksTest(t, newTestStore())
+	linktest.StoreGetOrgsTest(t, newTestStore())
+	linktest.StoreLookupTest(t, newTestStore())
+	linktest.StoreAliasTest(t, newTestStore())
+}
+
+func TestRevisions(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore()
+	org := "ORG"
+	key := "LINK"
+
+	revs, err := store.GetRevisions(ctx, org, key)
+	require.NoError(t, err)
+	require.Len(t, revs, 0)
+
+	// creation records nothing
+	v1 := link.V0("http://v1")
+	require.NoError(t, store.UpdateLink(WithEditor(ctx, "a"), org, key, v1))
+	revs, err = store.GetRevisions(ctx, org, key)
+	require.NoError(t, err)
+	require.Len(t, revs, 0)
+
+	// update records the previous link
+	v2 := link.V0("http://v2")
+	require.NoError(t, store.UpdateLink(WithEditor(ctx, "b"), org, key, v2))
+	// delete records the last link
+	require.NoError(t, store.DeleteLink(WithEditor(ctx, "c"), org, key))
+	revs, err = store.GetRevisions(ctx, org, key)
+	require.NoError(t, err)
+	require.Len(t, revs, 2)
+	require.Equal(t, 1, revs[0].ID)
+	require.Equal(t, v1, revs[0].Link)
+	require.Equal(t, "b", revs[0].Editor)
+	require.False(t, revs[0].Deleted)
+	require.Equal(t, 2, revs[1].ID)
+	require.Equal(t, v2, revs[1].Link)
+	require.Equal(t, "c", revs[1].Editor)
+	require.True(t, revs[1].Deleted)
+
+	// restore the deleted link
+	require.NoError(t, store.Restore(WithEditor(ctx, "d"), org, key, 1))
+	ln, err := store.GetLink(ctx, org, key)
+	require.NoError(t, err)
+	require.Equal(t, v1.Blob, ln.Blob)
+	require.Equal(t, "d", ln.Meta.UpdatedBy)
+	revs, err = store.GetRevisions(ctx, org, key)
+	require.NoError(t, err)
+	require.Len(t, revs, 2)
+
+	// restore the existing link records a revision
+	require.NoError(t, store.Restore(ctx, org, key, 2))
+	ln, err = store.GetLink(ctx, org, key)
+	require.NoError(t, err)
+	require.Equal(t, v2.Blob, ln.Blob)
+	revs, err = store.GetRevisions(ctx, org, key)
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+	require.Equal(t, v1.Blob, revs[2].Link.Blob)
+
+	require.Equal(t, ErrRevisionNotFound,
+		store.Restore(ctx, org, key, 10))
+}
+
+// failingNamespace fails to set the values in the "history" namespace.
+type failingNamespace struct {
+	kv.Namespace
+	fail bool
+}
+
+var errSetFailed = errors.New("set failed")
+
+func (n *failingNamespace) In(path ...string) kv.Namespace {
+	fail := n.fail || (len(path) > 0 && path[0] == "history")
+	return &failingNamespace{Namespace: n.Namespace.In(path...), fail: fail}
+}
+
+func (n *failingNamespace) Set(
+	ctx context.Context, key string, value []byte) error {
+	if n.fail {
+		return errSetFailed
+	}
+	return n.Namespace.Set(ctx, key, value)
+}
+
+func (n *failingNamespace) Update(
+	ctx context.Context, f func(tx kv.Namespace) error) error {
+	return n.Namespace.Update(ctx, func(tx kv.Namespace) error {
+		return f(&failingNamespace{Namespace: tx, fail: n.fail})
+	})
+}
+
+func TestChangeRollback(t *testing.T) {
+	ctx := context.Background()
+	root := &failingNamespace{Namespace: memory.New().In()}
+	enc := gob.New()
+	store := New(lnkv.NewIn(root, "link", enc), root, "history", enc)
+	org := "ORG"
+	key := "LINK"
+
+	v1 := link.V0("http://v1")
+	require.NoError(t, store.UpdateLink(ctx, org, key, v1))
+
+	// the change is reverted if its revision fails to record
+	err := store.UpdateLink(ctx, org, key, link.V0("http://v2"))
+	require.True(t, errors.Is(err, errSetFailed), err)
+	ln, err := store.GetLink(ctx, org, key)
+	require.NoError(t, err)
+	require.Equal(t, v1, ln)
+	err = store.DeleteLink(ctx, org, key)
+	require.True(t, errors.Is(err, errSetFailed), err)
+	ln, err = store.GetLink(ctx, org, key)
+	require.NoError(t, err)
+	require.Equal(t, v1, ln)
+}
+
+func TestRevisionIDs(t *testing.T) {
+	ctx := context.Background()
+	kvStore := memory.New()
+	enc := gob.New()
+	store := New(
+		lnkv.NewIn(kvStore.In(), "link", enc), kvStore.In(), "history", enc)
+	org := "ORG"
+	key := "LINK"
+
+	// a revision recorded before the last id was
+	b, err := enc.Encode(Revision{ID: 5, Link: link.V0("http://v0")})
+	require.NoError(t, err)
+	require.NoError(t, kvStore.In("history", org, key).Set(
+		ctx, revisionKey(5), b))
+
+	require.NoError(t, store.UpdateLink(ctx, org, key, link.V0("http://v1")))
+	require.NoError(t, store.UpdateLink(ctx, org, key, link.V0("http://v2")))
+	require.NoError(t, store.UpdateLink(ctx, org, key, link.V0("http://v3")))
+	revs, err := store.GetRevisions(ctx, org, key)
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+	require.Equal(t, 5, revs[0].ID)
+	require.Equal(t, 6, revs[1].ID)
+	require.Equal(t, 7, revs[2].ID)
+}
@@ -10,17 +10,42 @@ import (
 	"github.com/haostudio/golinks/internal/link"
 )
 
-// New returns a new store with kv and enc.
-func New(kv kv.Namespace, enc encoding.Binary) link.Store {
+// New returns a new store with kv and enc. The root namespace of the store
+// is kv.
+func New(kv kv.Namespace, enc encoding.Binary) link.TxStore {
 	return &store{
-		kv:  kv,
-		enc: enc,
+		root: kv,
+		kv:   kv,
+		enc:  enc,
+	}
+}
+
+// NewIn returns a new store with the namespace at path of root and enc. The
+// root namespace of the store is root.
+func NewIn(root kv.Namespace, path string, enc encoding.Binary) link.TxStore {
+	return &store{
+		root: root,
+		path: []string{path},
+		kv:   root.In(path),
+		enc:  enc,
 	}
 }
 
 type store struct {
-	kv  kv.Namespace
-	enc encoding.Binary
+	root kv.Namespace
+	path []string
+	kv   kv.Namespace
+	enc  encoding.Binary
+}
+
+// WithTx returns the store with the namespace at the same path of tx.
+func (s *store) WithTx(tx kv.Namespace) link.Store {
+	return &store{
+		root: tx,
+		path: s.path,
+		kv:   tx.In(s.path...),
+		enc:  s.enc,
+	}
 }
 
 func (s *store) GetLink(ctx context.Context, org string, key string) (
@@ -61,6 +86,31 @@ func (s *store) GetLinks(ctx context.Context, org string) (
 	return links, nil
 }
 
+func (s *store) ScanLinks(ctx context.Context, org string, prefix string,
+	cursor string, limit int) (page link.Page, err error) {
+	page.Links = make(map[string]link.Link)
+	err = s.kv.In(org).IterateFrom(ctx, prefix, cursor,
+		func(key string, value []byte) bool {
+			if limit > 0 && len(page.Keys) == limit {
+				page.Next = key
+				return false
+			}
+			// Decode
+			var ln link.Link
+			iterErr := s.enc.Decode(value, &ln)
+			if iterErr != nil {
+				return true
+			}
+			page.Keys = append(page.Keys, key)
+			page.Links[key] = ln
+			return true
+		})
+	if errors.Is(err, kv.ErrNotFound) {
+		err = nil
+	}
+	return
+}
+
 func (s *store) UpdateLink(
 	ctx context.Context, org string, key string, ln link.Link) error {
 	blob, err := s.enc.Encode(ln)
@@ -70,10 +120,71 @@ func (s *store) UpdateLink(
 	return s.kv.In(org).Set(ctx, key, blob)
 }
 
+func (s *store) UpdateLinkIf(ctx context.Context,
+	org string, key string, rev int64, ln link.Link) error {
+	ns := s.kv.In(org)
+	old, err := ns.Get(ctx, key)
+	if errors.Is(err, kv.ErrNotFound) {
+		old = nil
+	} else if err != nil {
+		return err
+	}
+	var cur link.Link
+	if old != nil {
+		err = s.enc.Decode(old, &cur)
+		if err != nil {
+			return err
+		}
+	}
+	if cur.Meta.Revision != rev {
+		return link.ErrConflict
+	}
+	ln.Meta.Revision = rev + 1
+	blob, err := s.enc.Encode(ln)
+	if err != nil {
+		return err
+	}
+	err = ns.CompareAndSet(ctx, key, old, blob)
+	if errors.Is(err, kv.ErrConflict) {
+		return fmt.Errorf("%v: %w", err, link.ErrConflict)
+	}
+	return err
+}
+
 func (s *store) DeleteLink(ctx context.Context, org string, key string) error {
 	return s.kv.In(org).Delete(ctx, key)
 }
 
+func (s *store) DeleteLinkIf(
+	ctx context.Context, org string, key string, rev int64) error {
+	return s.kv.In(org).Update(ctx, func(tx kv.Namespace) error {
+		b, err := tx.Get(ctx, key)
+		if errors.Is(err, kv.ErrNotFound) {
+			return link.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var cur link.Link
+		err = s.enc.Decode(b, &cur)
+		if err != nil {
+			return err
+		}
+		if cur.Meta.Revision != rev {
+			return link.ErrConflict
+		}
+		return tx.Delete(ctx, key)
+	})
+}
+
+func (s *store) GetOrgs(ctx context.Context) ([]string, error) {
+	orgs, err := s.kv.Namespaces(ctx)
+	if errors.Is(err, kv.ErrNotFound) {
+		return nil, nil
+	}
+	return orgs, err
+}
+
 func (s *store) String() string {
 	return fmt.Sprintf("kv.store(%s/%s)", s.kv, s.enc)
 }
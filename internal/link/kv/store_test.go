@@ -12,4 +12,10 @@ func TestStoreLogic(t *testing.T) {
 	kvStore := memory.New()
 	store := New(kvStore.In("test"), gob.New())
 	linktest.StoreLogicTest(t, store)
+	linktest.StoreUpdateLinkIfTest(t, store)
+	linktest.StoreDeleteLinkIfTest(t, store)
+	linktest.StoreScanLinksTest(t, store)
+	linktest.StoreGetOrgsTest(t, store)
+	linktest.StoreLookupTest(t, store)
+	linktest.StoreAliasTest(t, store)
 }
@@ -10,6 +10,10 @@ var (
 		0: &v0{},
 		1: &v1{},
 		2: &v2{},
+		3: &v3{},
+		4: &v4{},
+		5: &v5{},
+		6: &v6{},
 	}
 )
 
@@ -18,12 +22,15 @@ type Version interface {
 	New(payload string) (blob []byte, err error)
 	Resolve([]byte, string) (string, error)
 	Describe([]byte) (string, error)
+	// Payload returns the payload that New creates the blob from.
+	Payload([]byte) (string, error)
 }
 
 // Link defines the link struct
 type Link struct {
 	Version int
 	Blob    []byte
+	Meta    Meta
 }
 
 // New returns a new link of ver with payload.
@@ -51,6 +58,20 @@ func (l *Link) GetRedirectLink(param string) (string, error) {
 	return version.Resolve(l.Blob, param)
 }
 
+// Resolve returns the target redirect link of l for req. The versions not
+// resolving with the request only get req.Param as GetRedirectLink.
+func (l *Link) Resolve(req Request) (string, error) {
+	version, ok := versions[l.Version]
+	if !ok {
+		return "", ErrVersionNotSupport
+	}
+	rv, ok := version.(requestVersion)
+	if !ok {
+		return version.Resolve(l.Blob, req.Param)
+	}
+	return rv.ResolveRequest(l.Blob, req)
+}
+
 // Description returns the description string of l.
 func (l *Link) Description() (string, error) {
 	version, ok := versions[l.Version]
@@ -63,3 +84,12 @@ func (l *Link) Description() (string, error) {
 	}
 	return fmt.Sprintf("v%d|%s", l.Version, desc), nil
 }
+
+// Payload returns the payload string of l, which creates l with New.
+func (l *Link) Payload() (string, error) {
+	version, ok := versions[l.Version]
+	if !ok {
+		return "", ErrVersionNotSupport
+	}
+	return version.Payload(l.Blob)
+}
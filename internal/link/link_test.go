@@ -0,0 +1,28 @@
+package link
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayload(t *testing.T) {
+	cases := map[int]string{
+		0: "https://github.com",
+		1: "https://github.com/{}/issues",
+		2: "https://github.com/{0}/{1}",
+		4: "https://jira/{ticket}?page={page=1}",
+		3: "https://draft\n2020-06-01T00:00:00Z https://github.com/{0}",
+	}
+	for ver, payload := range cases {
+		ln, err := New(ver, payload)
+		require.NoError(t, err)
+		p, err := ln.Payload()
+		require.NoError(t, err)
+		require.Equal(t, payload, p)
+	}
+
+	ln := Link{Version: -1}
+	_, err := ln.Payload()
+	require.Equal(t, ErrVersionNotSupport, err)
+}
@@ -2,6 +2,8 @@ package linktest
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -54,6 +56,111 @@ func StoreLogicTest(t *testing.T, store link.Store) {
 	require.Error(t, link.ErrNotFound, err)
 }
 
+// StoreScanLinksTest test the link.Store prefix scans and pagination.
+func StoreScanLinksTest(t *testing.T, store link.Store) {
+	ctx := context.Background()
+
+	org := "ORG_SCAN"
+	keys := []string{"eng-c", "eng-a", "ops", "eng-b"}
+	for _, key := range keys {
+		require.NoError(t, store.UpdateLink(ctx, org, key, link.V0(key)))
+	}
+	defer func() {
+		for _, key := range keys {
+			require.NoError(t, store.DeleteLink(ctx, org, key))
+		}
+	}()
+
+	page, err := store.ScanLinks(ctx, org, "eng-", "", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"eng-a", "eng-b"}, page.Keys)
+	require.Equal(t, link.V0("eng-a"), page.Links["eng-a"])
+	require.Equal(t, "eng-c", page.Next)
+	page, err = store.ScanLinks(ctx, org, "eng-", page.Next, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"eng-c"}, page.Keys)
+	require.Empty(t, page.Next)
+
+	page, err = store.ScanLinks(ctx, org, "", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"eng-a", "eng-b", "eng-c", "ops"}, page.Keys)
+	page, err = store.ScanLinks(ctx, "ORG_NONE", "", "", 0)
+	require.NoError(t, err)
+	require.Empty(t, page.Keys)
+}
+
+// StoreGetOrgsTest test the link.Store org listing.
+func StoreGetOrgsTest(t *testing.T, store link.Store) {
+	ctx := context.Background()
+
+	key := "LINK"
+	ln := link.V0("http://test")
+	orgs := []string{"ORG_LIST_B", "ORG_LIST_A"}
+	for _, org := range orgs {
+		require.NoError(t, store.UpdateLink(ctx, org, key, ln))
+	}
+	defer func() {
+		for _, org := range orgs {
+			require.NoError(t, store.DeleteLink(ctx, org, key))
+		}
+	}()
+
+	got, err := store.GetOrgs(ctx)
+	require.NoError(t, err)
+	require.True(t, sort.StringsAreSorted(got))
+	for _, org := range orgs {
+		require.Contains(t, got, org)
+	}
+}
+
+// StoreUpdateLinkIfTest test the link.Store conditional updates.
+func StoreUpdateLinkIfTest(t *testing.T, store link.Store) {
+	ctx := context.Background()
+
+	org := "ORG_CAS"
+	key := "LINK"
+	ln := link.V0("http://test")
+	defer func() {
+		require.NoError(t, store.DeleteLink(ctx, org, key))
+	}()
+
+	// create if not exists
+	require.NoError(t, store.UpdateLinkIf(ctx, org, key, 0, ln))
+	l, err := store.GetLink(ctx, org, key)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), l.Meta.Revision)
+	err = store.UpdateLinkIf(ctx, org, key, 0, ln)
+	require.True(t, errors.Is(err, link.ErrConflict))
+
+	// update the latest revision only
+	require.NoError(t, store.UpdateLinkIf(ctx, org, key, 1, ln))
+	err = store.UpdateLinkIf(ctx, org, key, 1, ln)
+	require.True(t, errors.Is(err, link.ErrConflict))
+	l, err = store.GetLink(ctx, org, key)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), l.Meta.Revision)
+}
+
+// StoreDeleteLinkIfTest test the link.Store conditional deletes.
+func StoreDeleteLinkIfTest(t *testing.T, store link.Store) {
+	ctx := context.Background()
+
+	org := "ORG_CAD"
+	key := "LINK"
+	err := store.DeleteLinkIf(ctx, org, key, 0)
+	require.True(t, errors.Is(err, link.ErrNotFound))
+
+	require.NoError(t, store.UpdateLinkIf(ctx, org, key, 0, link.V0("http://a")))
+	// delete the latest revision only
+	err = store.DeleteLinkIf(ctx, org, key, 0)
+	require.True(t, errors.Is(err, link.ErrConflict))
+	_, err = store.GetLink(ctx, org, key)
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteLinkIf(ctx, org, key, 1))
+	_, err = store.GetLink(ctx, org, key)
+	require.True(t, errors.Is(err, link.ErrNotFound))
+}
+
 /*
 // StoreConcurrentTest test set/get/delete/iterate concurrently.
 func StoreConcurrentTest(t *testing.T, store link.Store, count int) {
@@ -145,3 +252,78 @@ func storeConcurrentTest(
 	wg.Wait()
 }
 */
+
+// StoreLookupTest test the longest-prefix lookup of multi-segment keys.
+func StoreLookupTest(t *testing.T, store link.Store) {
+	ctx := context.Background()
+	org := "ORG_LOOKUP"
+
+	require.NoError(t, store.UpdateLink(ctx, org, "team",
+		link.V0("http://team")))
+	require.NoError(t, store.UpdateLink(ctx, org, "team/infra",
+		link.V0("http://infra")))
+
+	cases := []struct {
+		url    string
+		key    string
+		param  string
+		target string
+	}{
+		{"/team", "team", "", "http://team"},
+		{"/team/web/", "team", "web", "http://team/web"},
+		{"/team/infra", "team/infra", "", "http://infra"},
+		{"/team/infra/oncall/now", "team/infra", "oncall/now",
+			"http://infra/oncall/now"},
+	}
+	for _, c := range cases {
+		key, param, ln, err := link.Lookup(ctx, store, org, c.url)
+		require.NoError(t, err, c.url)
+		require.Equal(t, c.key, key, c.url)
+		require.Equal(t, c.param, param, c.url)
+		target, err := ln.GetRedirectLink(param)
+		require.NoError(t, err, c.url)
+		require.Equal(t, c.target, target, c.url)
+	}
+
+	// the first segment is the key of a missing link
+	key, param, _, err := link.Lookup(ctx, store, org, "/infra/oncall")
+	require.True(t, errors.Is(err, link.ErrNotFound))
+	require.Equal(t, "infra", key)
+	require.Equal(t, "oncall", param)
+}
+
+// StoreAliasTest test following the aliases to the canonical links.
+func StoreAliasTest(t *testing.T, store link.Store) {
+	ctx := context.Background()
+	org := "ORG_ALIAS"
+
+	oncall := link.V0("http://oncall")
+	require.NoError(t, store.UpdateLink(ctx, org, "oncall", oncall))
+	require.NoError(t, store.UpdateLink(ctx, org, "on-call",
+		link.Alias("oncall")))
+	require.NoError(t, store.UpdateLink(ctx, org, "pager",
+		link.Alias("on-call")))
+
+	key, ln, err := link.Canonical(ctx, store, org, "pager",
+		link.Alias("on-call"))
+	require.NoError(t, err)
+	require.Equal(t, "oncall", key)
+	require.Equal(t, oncall, ln)
+	key, ln, err = link.Canonical(ctx, store, org, "oncall", oncall)
+	require.NoError(t, err)
+	require.Equal(t, "oncall", key)
+	require.Equal(t, oncall, ln)
+
+	// loop
+	_, _, err = link.Canonical(ctx, store, org, "oncall",
+		link.Alias("pager"))
+	require.True(t, errors.Is(err, link.ErrAliasLoop))
+	_, _, err = link.Canonical(ctx, store, org, "self", link.Alias("self"))
+	require.True(t, errors.Is(err, link.ErrAliasLoop))
+
+	// missing canonical link
+	key, _, err = link.Canonical(ctx, store, org, "pager",
+		link.Alias("missing"))
+	require.True(t, errors.Is(err, link.ErrNotFound))
+	require.Equal(t, "missing", key)
+}
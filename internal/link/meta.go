@@ -0,0 +1,85 @@
+package link
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Meta defines the metadata of a link.
+type Meta struct {
+	CreatedBy   string
+	CreatedAt   time.Time
+	UpdatedBy   string
+	UpdatedAt   time.Time
+	Description string
+	Tags        []string
+	// Revision is increased every time the link is saved.
+	Revision int64
+	// ExpiresAt is the time after which the link stops redirecting. Zero means
+	// the link never expires.
+	ExpiresAt time.Time
+	// Owners are the emails of the users owning the link, the creator first.
+	Owners []string
+	// Protected links can only be changed by the owners and the org admins.
+	Protected bool
+	// OwnershipRequests are the emails of the users requesting the ownership.
+	OwnershipRequests []string
+}
+
+// Expired returns true if l has an expiry at or before now.
+func (l *Link) Expired(now time.Time) bool {
+	return !l.Meta.ExpiresAt.IsZero() && !now.Before(l.Meta.ExpiresAt)
+}
+
+// Touch updates the metadata of l for being saved by editor at t. The creation
+// and ownership info is inherited from prev if the link already exists, or the
+// editor becomes the owner of a new link without owners.
+func (l *Link) Touch(editor string, t time.Time, prev *Link) {
+	if prev != nil {
+		l.Meta.CreatedBy = prev.Meta.CreatedBy
+		l.Meta.CreatedAt = prev.Meta.CreatedAt
+		l.Meta.Revision = prev.Meta.Revision + 1
+		l.Meta.Owners = prev.Meta.Owners
+		l.Meta.Protected = prev.Meta.Protected
+		l.Meta.OwnershipRequests = prev.Meta.OwnershipRequests
+	} else {
+		l.Meta.CreatedBy = editor
+		l.Meta.CreatedAt = t
+		l.Meta.Revision = 1
+		if len(l.Meta.Owners) == 0 && editor != "" {
+			l.Meta.Owners = []string{editor}
+		}
+	}
+	l.Meta.UpdatedBy = editor
+	l.Meta.UpdatedAt = t
+}
+
+// ParseTags parses the comma or space separated tags into a sorted set of
+// lower-cased tags.
+func ParseTags(str string) []string {
+	return NormalizeTags(strings.FieldsFunc(str, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	}))
+}
+
+// NormalizeTags returns the sorted set of lower-cased, non-empty tags.
+func NormalizeTags(tags []string) []string {
+	set := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if len(tag) == 0 {
+			continue
+		}
+		set[tag] = struct{}{}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	normalized := make([]string, 0, len(set))
+	for tag := range set {
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
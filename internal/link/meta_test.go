@@ -0,0 +1,68 @@
+package link
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+)
+
+func TestParseTags(t *testing.T) {
+	cases := map[string][]string{
+		"":                   nil,
+		" , ":                nil,
+		"oncall":             {"oncall"},
+		"OnCall, infra  sre": {"infra", "oncall", "sre"},
+		"b,a,b":              {"a", "b"},
+	}
+	for str, tags := range cases {
+		require.Equal(t, tags, ParseTags(str), str)
+	}
+}
+
+func TestTouch(t *testing.T) {
+	created := time.Unix(1000, 0)
+	updated := time.Unix(2000, 0)
+
+	ln := V0("https://github.com")
+	ln.Touch("creator@golinks", created, nil)
+	require.Equal(t, "creator@golinks", ln.Meta.CreatedBy)
+	require.Equal(t, created, ln.Meta.CreatedAt)
+	require.Equal(t, "creator@golinks", ln.Meta.UpdatedBy)
+	require.Equal(t, created, ln.Meta.UpdatedAt)
+
+	next := V0("https://gitlab.com")
+	next.Touch("editor@golinks", updated, &ln)
+	require.Equal(t, "creator@golinks", next.Meta.CreatedBy)
+	require.Equal(t, created, next.Meta.CreatedAt)
+	require.Equal(t, "editor@golinks", next.Meta.UpdatedBy)
+	require.Equal(t, updated, next.Meta.UpdatedAt)
+	require.Equal(t, []string{"creator@golinks"}, next.Meta.Owners)
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	ln := V0("https://github.com")
+	require.False(t, ln.Expired(now))
+	ln.Meta.ExpiresAt = now.Add(time.Second)
+	require.False(t, ln.Expired(now))
+	ln.Meta.ExpiresAt = now
+	require.True(t, ln.Expired(now))
+}
+
+func TestDecodeLegacyLink(t *testing.T) {
+	// links stored before the metadata was introduced.
+	type legacyLink struct {
+		Version int
+		Blob    []byte
+	}
+	enc := gob.New()
+	b, err := enc.Encode(legacyLink{Version: 0, Blob: []byte("https://go")})
+	require.NoError(t, err)
+	var ln Link
+	require.NoError(t, enc.Decode(b, &ln))
+	require.Equal(t, V0("https://go"), ln)
+}
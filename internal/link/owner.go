@@ -0,0 +1,95 @@
+package link
+
+// IsOwner returns if the user with email owns l.
+func (l *Link) IsOwner(email string) bool {
+	return email != "" && contains(l.Meta.Owners, email)
+}
+
+// Editable returns if the user with email can change or delete l. admin is
+// true for the org admins, who can change any link.
+func (l *Link) Editable(email string, admin bool) bool {
+	return !l.Meta.Protected || admin || l.IsOwner(email)
+}
+
+// RequestOwnership records the ownership request of the user with email. The
+// user becomes the owner right away if l is neither owned nor protected.
+func (l *Link) RequestOwnership(email string) {
+	if email == "" || l.IsOwner(email) {
+		return
+	}
+	if len(l.Meta.Owners) == 0 && !l.Meta.Protected {
+		l.AddOwner(email)
+		return
+	}
+	if !contains(l.Meta.OwnershipRequests, email) {
+		l.Meta.OwnershipRequests = append(
+			copyStrings(l.Meta.OwnershipRequests), email)
+	}
+}
+
+// AddOwner adds the user with email to the owners of l, granting the
+// ownership request of the user if any.
+func (l *Link) AddOwner(email string) {
+	if email == "" {
+		return
+	}
+	l.Meta.OwnershipRequests = remove(l.Meta.OwnershipRequests, email)
+	if !contains(l.Meta.Owners, email) {
+		l.Meta.Owners = append(copyStrings(l.Meta.Owners), email)
+	}
+}
+
+// RemoveOwner removes the user with email from the owners of l, declining the
+// ownership request of the user if any.
+func (l *Link) RemoveOwner(email string) {
+	l.Meta.OwnershipRequests = remove(l.Meta.OwnershipRequests, email)
+	l.Meta.Owners = remove(l.Meta.Owners, email)
+}
+
+// TransferOwnership transfers the ownership of l from the owner with email
+// from to the user with email to.
+func (l *Link) TransferOwnership(from, to string) error {
+	if !l.IsOwner(from) {
+		return ErrNotOwner
+	}
+	if to == "" {
+		return ErrInvalidParams
+	}
+	if from == to {
+		return nil
+	}
+	l.AddOwner(to)
+	l.RemoveOwner(from)
+	return nil
+}
+
+func contains(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+// remove returns a copy of strs without str. The copy keeps strs unchanged as
+// the slice may be shared with the previous link.
+func remove(strs []string, str string) []string {
+	if !contains(strs, str) {
+		return strs
+	}
+	removed := make([]string, 0, len(strs)-1)
+	for _, s := range strs {
+		if s != str {
+			removed = append(removed, s)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	return removed
+}
+
+func copyStrings(strs []string) []string {
+	return append(make([]string, 0, len(strs)+1), strs...)
+}
@@ -0,0 +1,62 @@
+package link
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditable(t *testing.T) {
+	ln := V0("https://github.com")
+	ln.Touch("owner@golinks", time.Now(), nil)
+	require.True(t, ln.IsOwner("owner@golinks"))
+	require.False(t, ln.IsOwner(""))
+	require.True(t, ln.Editable("other@golinks", false))
+
+	ln.Meta.Protected = true
+	require.True(t, ln.Editable("owner@golinks", false))
+	require.True(t, ln.Editable("admin@golinks", true))
+	require.False(t, ln.Editable("other@golinks", false))
+	require.False(t, ln.Editable("", false))
+
+	next := V0("https://gitlab.com")
+	next.Touch("admin@golinks", time.Now(), &ln)
+	require.True(t, next.Meta.Protected)
+	require.Equal(t, []string{"owner@golinks"}, next.Meta.Owners)
+}
+
+func TestOwnership(t *testing.T) {
+	ln := V0("https://github.com")
+	// the first request of a link without owner is granted.
+	ln.RequestOwnership("a@golinks")
+	require.Equal(t, []string{"a@golinks"}, ln.Meta.Owners)
+
+	ln.RequestOwnership("b@golinks")
+	ln.RequestOwnership("b@golinks")
+	ln.RequestOwnership("a@golinks")
+	require.Equal(t, []string{"a@golinks"}, ln.Meta.Owners)
+	require.Equal(t, []string{"b@golinks"}, ln.Meta.OwnershipRequests)
+
+	prev := ln
+	ln.AddOwner("b@golinks")
+	require.Equal(t, []string{"a@golinks", "b@golinks"}, ln.Meta.Owners)
+	require.Nil(t, ln.Meta.OwnershipRequests)
+	require.Equal(t, []string{"a@golinks"}, prev.Meta.Owners)
+	require.Equal(t, []string{"b@golinks"}, prev.Meta.OwnershipRequests)
+
+	err := ln.TransferOwnership("c@golinks", "d@golinks")
+	require.True(t, errors.Is(err, ErrNotOwner))
+	require.NoError(t, ln.TransferOwnership("a@golinks", "c@golinks"))
+	require.Equal(t, []string{"b@golinks", "c@golinks"}, ln.Meta.Owners)
+
+	ln.RemoveOwner("b@golinks")
+	ln.RemoveOwner("c@golinks")
+	require.Nil(t, ln.Meta.Owners)
+
+	ln.Meta.Protected = true
+	ln.RequestOwnership("a@golinks")
+	require.Nil(t, ln.Meta.Owners)
+	require.Equal(t, []string{"a@golinks"}, ln.Meta.OwnershipRequests)
+}
@@ -0,0 +1,75 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/haostudio/golinks/internal/encoding"
+	"github.com/haostudio/golinks/internal/kv"
+	"github.com/haostudio/golinks/internal/link/pattern"
+)
+
+// New returns a new pattern store with kv and enc.
+func New(kv kv.Namespace, enc encoding.Binary) pattern.Store {
+	return &store{
+		kv:  kv,
+		enc: enc,
+	}
+}
+
+type store struct {
+	kv  kv.Namespace
+	enc encoding.Binary
+}
+
+func (s *store) GetPatterns(ctx context.Context, org string) (
+	[]pattern.Pattern, error) {
+	var patterns []pattern.Pattern
+	err := s.kv.In(org).Iterate(ctx, func(key string, value []byte) bool {
+		var p pattern.Pattern
+		iterErr := s.enc.Decode(value, &p)
+		if iterErr != nil {
+			return true
+		}
+		patterns = append(patterns, p)
+		return true
+	})
+	if errors.Is(err, kv.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pattern.Sort(patterns)
+	return patterns, nil
+}
+
+func (s *store) UpdatePattern(
+	ctx context.Context, org string, p pattern.Pattern) error {
+	err := p.Validate()
+	if err != nil {
+		return err
+	}
+	b, err := s.enc.Encode(p)
+	if err != nil {
+		return err
+	}
+	return s.kv.In(org).Set(ctx, p.Name, b)
+}
+
+func (s *store) DeletePattern(
+	ctx context.Context, org string, name string) error {
+	_, err := s.kv.In(org).Get(ctx, name)
+	if errors.Is(err, kv.ErrNotFound) {
+		return pattern.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.kv.In(org).Delete(ctx, name)
+}
+
+func (s *store) String() string {
+	return fmt.Sprintf("kv.store(%s/%s)", s.kv, s.enc)
+}
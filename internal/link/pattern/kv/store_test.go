@@ -0,0 +1,56 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+	"github.com/haostudio/golinks/internal/kv/memory"
+	"github.com/haostudio/golinks/internal/link/pattern"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New().In("test"), gob.New())
+
+	patterns, err := store.GetPatterns(ctx, "org")
+	require.NoError(t, err)
+	require.Empty(t, patterns)
+
+	jira := pattern.Pattern{
+		Name:     "jira",
+		Expr:     `^JIRA-(\d+)$`,
+		Target:   "https://jira/browse/JIRA-$1",
+		Priority: 2,
+	}
+	pr := pattern.Pattern{
+		Name:     "pr",
+		Expr:     `^pr(\d+)$`,
+		Target:   "https://github.com/haostudio/golinks/pull/$1",
+		Priority: 1,
+	}
+	require.NoError(t, store.UpdatePattern(ctx, "org", jira))
+	require.NoError(t, store.UpdatePattern(ctx, "org", pr))
+	patterns, err = store.GetPatterns(ctx, "org")
+	require.NoError(t, err)
+	require.Equal(t, []pattern.Pattern{pr, jira}, patterns)
+
+	patterns, err = store.GetPatterns(ctx, "other")
+	require.NoError(t, err)
+	require.Empty(t, patterns)
+
+	invalid := pr
+	invalid.Expr = "pr(\\d+"
+	err = store.UpdatePattern(ctx, "org", invalid)
+	require.True(t, errors.Is(err, pattern.ErrInvalid))
+
+	require.NoError(t, store.DeletePattern(ctx, "org", "pr"))
+	err = store.DeletePattern(ctx, "org", "pr")
+	require.True(t, errors.Is(err, pattern.ErrNotFound))
+	patterns, err = store.GetPatterns(ctx, "org")
+	require.NoError(t, err)
+	require.Equal(t, []pattern.Pattern{jira}, patterns)
+}
@@ -0,0 +1,70 @@
+package pattern
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Exported errors.
+var (
+	ErrNotFound = errors.New("pattern not found")
+	ErrInvalid  = errors.New("invalid pattern")
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Pattern defines a pattern route, which redirects the paths matching Expr
+// to Target when no link matches.
+type Pattern struct {
+	Name string
+	// Expr is the regular expression matching the path without leading and
+	// trailing "/", e.g. `^JIRA-(\d+)$`.
+	Expr string
+	// Target is the redirect link with $1 or ${name} replaced by the
+	// submatches of Expr, e.g. "https://jira/browse/JIRA-$1".
+	Target string
+	// Priority is the order to evaluate the patterns of an org. The lower one
+	// is evaluated first.
+	Priority    int
+	Description string
+}
+
+// Validate returns ErrInvalid if p is not a valid pattern.
+func (p Pattern) Validate() error {
+	if !namePattern.MatchString(p.Name) {
+		return fmt.Errorf("invalid name \"%s\": %w", p.Name, ErrInvalid)
+	}
+	if len(p.Target) == 0 {
+		return fmt.Errorf("empty target: %w", ErrInvalid)
+	}
+	_, err := regexp.Compile(p.Expr)
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, ErrInvalid)
+	}
+	return nil
+}
+
+// Sort sorts patterns in the order of evaluation, which is by priority and
+// then name.
+func Sort(patterns []Pattern) {
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].Priority != patterns[j].Priority {
+			return patterns[i].Priority < patterns[j].Priority
+		}
+		return patterns[i].Name < patterns[j].Name
+	})
+}
+
+// Store defines the pattern store interface.
+type Store interface {
+	fmt.Stringer
+
+	// GetPatterns returns the patterns of org in the order of evaluation.
+	GetPatterns(ctx context.Context, org string) ([]Pattern, error)
+	// UpdatePattern creates or replaces the pattern of the same name.
+	UpdatePattern(ctx context.Context, org string, p Pattern) error
+	DeletePattern(ctx context.Context, org string, name string) error
+}
@@ -0,0 +1,110 @@
+package pattern
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// NewRouter returns a router matching paths to the patterns in store. The
+// patterns are compiled and cached per org, so they should be updated
+// through the router.
+func NewRouter(store Store) *Router {
+	return &Router{
+		Store:       store,
+		compiled:    make(map[string][]compiled),
+		generations: make(map[string]uint64),
+	}
+}
+
+// Router defines the pattern router.
+type Router struct {
+	Store
+	mu       sync.RWMutex
+	compiled map[string][]compiled
+	// generations counts the invalidations of each org, so that the patterns
+	// loaded before an invalidation are not cached.
+	generations map[string]uint64
+}
+
+type compiled struct {
+	Pattern
+	re *regexp.Regexp
+}
+
+// Match returns the target of the first pattern of org matching path, and
+// false if none of them matches.
+func (r *Router) Match(ctx context.Context, org string, path string) (
+	target string, matched Pattern, ok bool, err error) {
+	patterns, err := r.get(ctx, org)
+	if err != nil {
+		return
+	}
+	for _, p := range patterns {
+		idx := p.re.FindStringSubmatchIndex(path)
+		if idx == nil {
+			continue
+		}
+		target = string(p.re.ExpandString(nil, p.Target, path, idx))
+		matched = p.Pattern
+		ok = true
+		return
+	}
+	return
+}
+
+// UpdatePattern updates the pattern in store and invalidates the cache of org.
+func (r *Router) UpdatePattern(
+	ctx context.Context, org string, p Pattern) error {
+	defer r.invalidate(org)
+	return r.Store.UpdatePattern(ctx, org, p)
+}
+
+// DeletePattern deletes the pattern in store and invalidates the cache of org.
+func (r *Router) DeletePattern(
+	ctx context.Context, org string, name string) error {
+	defer r.invalidate(org)
+	return r.Store.DeletePattern(ctx, org, name)
+}
+
+func (r *Router) String() string {
+	return fmt.Sprintf("pattern.router(%s)", r.Store)
+}
+
+func (r *Router) get(ctx context.Context, org string) ([]compiled, error) {
+	r.mu.RLock()
+	patterns, ok := r.compiled[org]
+	generation := r.generations[org]
+	r.mu.RUnlock()
+	if ok {
+		return patterns, nil
+	}
+
+	stored, err := r.Store.GetPatterns(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	patterns = make([]compiled, 0, len(stored))
+	for _, p := range stored {
+		re, err := regexp.Compile(p.Expr)
+		if err != nil {
+			// invalid patterns are rejected on update.
+			continue
+		}
+		patterns = append(patterns, compiled{Pattern: p, re: re})
+	}
+	r.mu.Lock()
+	if r.generations[org] == generation {
+		r.compiled[org] = patterns
+	}
+	r.mu.Unlock()
+	return patterns, nil
+}
+
+func (r *Router) invalidate(org string) {
+	r.mu.Lock()
+	delete(r.compiled, org)
+	r.generations[org]++
+	r.mu.Unlock()
+}
@@ -0,0 +1,117 @@
+package pattern_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+	"github.com/haostudio/golinks/internal/kv/memory"
+	. "github.com/haostudio/golinks/internal/link/pattern"
+	"github.com/haostudio/golinks/internal/link/pattern/kv"
+)
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	router := NewRouter(kv.New(memory.New().In("test"), gob.New()))
+
+	_, _, ok, err := router.Match(ctx, "org", "JIRA-123")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, router.UpdatePattern(ctx, "org", Pattern{
+		Name:   "jira",
+		Expr:   `^JIRA-(\d+)$`,
+		Target: "https://jira/browse/JIRA-$1",
+	}))
+	require.NoError(t, router.UpdatePattern(ctx, "org", Pattern{
+		Name:     "any",
+		Expr:     `^(?P<key>.+)$`,
+		Target:   "https://search?q=${key}",
+		Priority: 1,
+	}))
+	target, p, ok, err := router.Match(ctx, "org", "JIRA-123")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "jira", p.Name)
+	require.Equal(t, "https://jira/browse/JIRA-123", target)
+	target, _, ok, err = router.Match(ctx, "org", "golinks")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "https://search?q=golinks", target)
+	_, _, ok, err = router.Match(ctx, "other", "JIRA-123")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// the cache is invalidated on update
+	require.NoError(t, router.UpdatePattern(ctx, "org", Pattern{
+		Name:     "any",
+		Expr:     `^(?P<key>.+)$`,
+		Target:   "https://search?q=${key}",
+		Priority: -1,
+	}))
+	target, _, ok, err = router.Match(ctx, "org", "JIRA-123")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "https://search?q=JIRA-123", target)
+	require.NoError(t, router.DeletePattern(ctx, "org", "any"))
+	target, _, ok, err = router.Match(ctx, "org", "JIRA-123")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "https://jira/browse/JIRA-123", target)
+}
+
+// changingStore calls change after getting the patterns, as if the patterns
+// were changed while loading them.
+type changingStore struct {
+	Store
+	change func()
+}
+
+func (s *changingStore) GetPatterns(ctx context.Context, org string) (
+	[]Pattern, error) {
+	patterns, err := s.Store.GetPatterns(ctx, org)
+	if s.change != nil {
+		change := s.change
+		s.change = nil
+		change()
+	}
+	return patterns, err
+}
+
+func TestRouterChangedWhileLoading(t *testing.T) {
+	ctx := context.Background()
+	store := &changingStore{Store: kv.New(memory.New().In("test"), gob.New())}
+	router := NewRouter(store)
+	jira := Pattern{
+		Name:   "jira",
+		Expr:   `^JIRA-(\d+)$`,
+		Target: "https://jira/browse/JIRA-$1",
+	}
+	store.change = func() {
+		require.NoError(t, router.UpdatePattern(ctx, "org", jira))
+	}
+	_, _, ok, err := router.Match(ctx, "org", "JIRA-123")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// the patterns loaded before the update are not cached
+	target, _, ok, err := router.Match(ctx, "org", "JIRA-123")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "https://jira/browse/JIRA-123", target)
+}
+
+func TestValidate(t *testing.T) {
+	valid := Pattern{Name: "a.b-c_1", Expr: "^a$", Target: "https://a"}
+	require.NoError(t, valid.Validate())
+	for _, p := range []Pattern{
+		{Name: "a/b", Expr: "^a$", Target: "https://a"},
+		{Name: "", Expr: "^a$", Target: "https://a"},
+		{Name: "a", Expr: "^a($", Target: "https://a"},
+		{Name: "a", Expr: "^a$"},
+	} {
+		require.Error(t, p.Validate())
+	}
+}
@@ -0,0 +1,25 @@
+package link
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// Request defines the request a link is resolved for.
+type Request struct {
+	// Param is the param passed in the url after the key.
+	Param  string
+	Query  url.Values
+	Header http.Header
+	// UserEmail is the email of the request user, or an empty string if it's
+	// unknown.
+	UserEmail string
+	ClientIP  net.IP
+}
+
+// requestVersion defines the versions resolving with the whole request
+// instead of the param only.
+type requestVersion interface {
+	ResolveRequest(blob []byte, req Request) (string, error)
+}
@@ -0,0 +1,141 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/haostudio/golinks/internal/encoding"
+	"github.com/haostudio/golinks/internal/kv"
+	"github.com/haostudio/golinks/internal/link/share"
+)
+
+// namespaces of the shares indexed by the orgs shared with and from.
+const (
+	toNamespace   = "to"
+	fromNamespace = "from"
+)
+
+// New returns a new share store with kv and enc.
+func New(kv kv.Namespace, enc encoding.Binary) share.Store {
+	return &store{
+		kv:  kv,
+		enc: enc,
+	}
+}
+
+type store struct {
+	kv  kv.Namespace
+	enc encoding.Binary
+}
+
+func (s *store) GetShare(ctx context.Context, org string, key string) (
+	sh share.Share, err error) {
+	b, err := s.kv.In(toNamespace, org).Get(ctx, key)
+	if errors.Is(err, kv.ErrNotFound) {
+		err = share.ErrNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+	err = s.enc.Decode(b, &sh)
+	return
+}
+
+func (s *store) GetShares(ctx context.Context, org string) (
+	[]share.Share, error) {
+	var shares []share.Share
+	err := s.kv.In(toNamespace, org).Iterate(ctx,
+		func(key string, value []byte) bool {
+			var sh share.Share
+			iterErr := s.enc.Decode(value, &sh)
+			if iterErr != nil {
+				return true
+			}
+			shares = append(shares, sh)
+			return true
+		})
+	if errors.Is(err, kv.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].Key < shares[j].Key
+	})
+	return shares, nil
+}
+
+func (s *store) GetSharedOrgs(ctx context.Context, from string, key string) (
+	[]string, error) {
+	var orgs []string
+	err := s.kv.In(fromNamespace, from, key).Iterate(ctx,
+		func(org string, value []byte) bool {
+			orgs = append(orgs, org)
+			return true
+		})
+	if errors.Is(err, kv.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(orgs)
+	return orgs, nil
+}
+
+func (s *store) UpdateShare(ctx context.Context, sh share.Share) error {
+	if len(sh.Org) == 0 || len(sh.Key) == 0 || len(sh.From) == 0 {
+		return fmt.Errorf("invalid share %v", sh)
+	}
+	return s.kv.Update(ctx, func(tx kv.Namespace) error {
+		return (&store{kv: tx, enc: s.enc}).updateShare(ctx, sh)
+	})
+}
+
+func (s *store) updateShare(ctx context.Context, sh share.Share) error {
+	prev, err := s.GetShare(ctx, sh.Org, sh.Key)
+	if err == nil && prev.From != sh.From {
+		return fmt.Errorf("%s is shared with %s from %s. %w",
+			sh.Key, sh.Org, prev.From, share.ErrConflict)
+	}
+	if err != nil && !errors.Is(err, share.ErrNotFound) {
+		return err
+	}
+	b, err := s.enc.Encode(sh)
+	if err != nil {
+		return err
+	}
+	err = s.kv.In(fromNamespace, sh.From, sh.Key).Set(ctx, sh.Org, b)
+	if err != nil {
+		return err
+	}
+	return s.kv.In(toNamespace, sh.Org).Set(ctx, sh.Key, b)
+}
+
+func (s *store) DeleteShare(
+	ctx context.Context, org string, key string) error {
+	return s.kv.Update(ctx, func(tx kv.Namespace) error {
+		return (&store{kv: tx, enc: s.enc}).deleteShare(ctx, org, key)
+	})
+}
+
+func (s *store) deleteShare(
+	ctx context.Context, org string, key string) error {
+	sh, err := s.GetShare(ctx, org, key)
+	if err != nil {
+		return err
+	}
+	err = s.kv.In(fromNamespace, sh.From, sh.Key).Delete(ctx, sh.Org)
+	if err != nil && !errors.Is(err, kv.ErrNotFound) {
+		return err
+	}
+	return s.kv.In(toNamespace, org).Delete(ctx, key)
+}
+
+func (s *store) String() string {
+	return fmt.Sprintf("kv.store(%s/%s)", s.kv, s.enc)
+}
@@ -0,0 +1,65 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+	"github.com/haostudio/golinks/internal/kv/memory"
+	"github.com/haostudio/golinks/internal/link/share"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := New(memory.New().In("test"), gob.New())
+
+	_, err := store.GetShare(ctx, "team", "benefits")
+	require.True(t, errors.Is(err, share.ErrNotFound))
+	shares, err := store.GetShares(ctx, "team")
+	require.NoError(t, err)
+	require.Empty(t, shares)
+
+	benefits := share.Share{Org: "team", Key: "benefits", From: "hr"}
+	handbook := share.Share{Org: "team", Key: "hr/handbook", From: "hr"}
+	require.NoError(t, store.UpdateShare(ctx, benefits))
+	require.NoError(t, store.UpdateShare(ctx, handbook))
+	require.NoError(t, store.UpdateShare(ctx, share.Share{
+		Org: "infra", Key: "benefits", From: "hr",
+	}))
+	s, err := store.GetShare(ctx, "team", "benefits")
+	require.NoError(t, err)
+	require.Equal(t, benefits, s)
+	shares, err = store.GetShares(ctx, "team")
+	require.NoError(t, err)
+	require.Equal(t, []share.Share{benefits, handbook}, shares)
+	orgs, err := store.GetSharedOrgs(ctx, "hr", "benefits")
+	require.NoError(t, err)
+	require.Equal(t, []string{"infra", "team"}, orgs)
+
+	// the key shared from another org is kept
+	err = store.UpdateShare(ctx, share.Share{
+		Org: "team", Key: "benefits", From: "finance",
+	})
+	require.True(t, errors.Is(err, share.ErrConflict), err)
+	s, err = store.GetShare(ctx, "team", "benefits")
+	require.NoError(t, err)
+	require.Equal(t, benefits, s)
+	orgs, err = store.GetSharedOrgs(ctx, "finance", "benefits")
+	require.NoError(t, err)
+	require.Empty(t, orgs)
+
+	require.NoError(t, store.DeleteShare(ctx, "team", "benefits"))
+	err = store.DeleteShare(ctx, "team", "benefits")
+	require.True(t, errors.Is(err, share.ErrNotFound))
+	orgs, err = store.GetSharedOrgs(ctx, "hr", "benefits")
+	require.NoError(t, err)
+	require.Equal(t, []string{"infra"}, orgs)
+	shares, err = store.GetShares(ctx, "team")
+	require.NoError(t, err)
+	require.Equal(t, []share.Share{handbook}, shares)
+
+	require.Error(t, store.UpdateShare(ctx, share.Share{Key: "benefits"}))
+}
@@ -0,0 +1,169 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/haostudio/golinks/internal/link"
+)
+
+// NewResolver returns a resolver of the links in links. A link missing in an
+// org falls back to the link of the same key shared with the org in shares,
+// and then to the link in the fallback orgs in order. shares is optional.
+func NewResolver(
+	links link.Store, shares Store, fallback ...string) *Resolver {
+	return &Resolver{
+		links:    links,
+		shares:   shares,
+		fallback: fallback,
+	}
+}
+
+// Resolver defines the link resolver across orgs.
+type Resolver struct {
+	links    link.Store
+	shares   Store
+	fallback []string
+}
+
+// Resolved defines a link resolved by Resolver.
+type Resolved struct {
+	// Org is the org of the link, which is not the requesting org if the link
+	// is shared or from a fallback org.
+	Org   string
+	Key   string
+	Param string
+	Link  link.Link
+}
+
+// Lookup returns the link of org with the longest key matching url as
+// link.Lookup, or the shared link with the longest key if no link of org
+// matches, or the link of the first fallback org matching url. ErrNotFound
+// is returned with the key and param of link.Lookup if no link matches.
+func (r *Resolver) Lookup(ctx context.Context, org string, url string) (
+	resolved Resolved, err error) {
+	resolved.Org = org
+	resolved.Key, resolved.Param, resolved.Link, err = link.Lookup(
+		ctx, r.links, org, url)
+	if !errors.Is(err, link.ErrNotFound) {
+		return
+	}
+	notFound := resolved
+
+	if r.shares != nil {
+		resolved, err = r.lookupShares(ctx, org, url)
+		if !errors.Is(err, link.ErrNotFound) {
+			return
+		}
+	}
+	for _, fallback := range r.fallback {
+		if fallback == org {
+			continue
+		}
+		resolved.Org = fallback
+		resolved.Key, resolved.Param, resolved.Link, err = link.Lookup(
+			ctx, r.links, fallback, url)
+		if !errors.Is(err, link.ErrNotFound) {
+			return
+		}
+	}
+	return notFound, link.ErrNotFound
+}
+
+func (r *Resolver) lookupShares(ctx context.Context, org string, url string) (
+	resolved Resolved, err error) {
+	path := strings.Trim(url, "/")
+	segments := strings.Split(path, "/")
+	if len(segments) > link.MaxKeyDepth {
+		segments = segments[:link.MaxKeyDepth]
+	}
+	for i := len(segments); i > 0; i-- {
+		key := strings.Join(segments[:i], "/")
+		var s Share
+		s, err = r.shares.GetShare(ctx, org, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		resolved.Link, err = r.links.GetLink(ctx, s.From, key)
+		if errors.Is(err, link.ErrNotFound) {
+			// the shared link has been deleted.
+			continue
+		}
+		if err != nil {
+			return
+		}
+		resolved.Org = s.From
+		resolved.Key = key
+		resolved.Param = strings.TrimPrefix(strings.TrimPrefix(path, key), "/")
+		return
+	}
+	return resolved, link.ErrNotFound
+}
+
+// SharedLinks returns the links shared with org and the links of the fallback
+// orgs, in the order of key. The links of org, which shadow the others with
+// the same keys, are excluded.
+func (r *Resolver) SharedLinks(ctx context.Context, org string,
+	links map[string]link.Link) ([]Resolved, error) {
+	seen := make(map[string]struct{})
+	for key := range links {
+		seen[key] = struct{}{}
+	}
+	var resolved []Resolved
+	if r.shares != nil {
+		shares, err := r.shares.GetShares(ctx, org)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range shares {
+			if _, ok := seen[s.Key]; ok {
+				continue
+			}
+			ln, err := r.links.GetLink(ctx, s.From, s.Key)
+			if errors.Is(err, link.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			seen[s.Key] = struct{}{}
+			resolved = append(resolved, Resolved{
+				Org:  s.From,
+				Key:  s.Key,
+				Link: ln,
+			})
+		}
+	}
+	for _, fallback := range r.fallback {
+		if fallback == org {
+			continue
+		}
+		fallbackLinks, err := r.links.GetLinks(ctx, fallback)
+		if errors.Is(err, link.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for key, ln := range fallbackLinks {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			resolved = append(resolved, Resolved{
+				Org:  fallback,
+				Key:  key,
+				Link: ln,
+			})
+		}
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].Key < resolved[j].Key
+	})
+	return resolved, nil
+}
@@ -0,0 +1,93 @@
+package share_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+	"github.com/haostudio/golinks/internal/kv/memory"
+	"github.com/haostudio/golinks/internal/link"
+	linkkv "github.com/haostudio/golinks/internal/link/kv"
+	. "github.com/haostudio/golinks/internal/link/share"
+	"github.com/haostudio/golinks/internal/link/share/kv"
+)
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+	kvStore := memory.New()
+	links := linkkv.New(kvStore.In("links"), gob.New())
+	shares := kv.New(kvStore.In("shares"), gob.New())
+	resolver := NewResolver(links, shares, "global")
+
+	require.NoError(t, links.UpdateLink(ctx, "team", "wiki",
+		link.V0("http://team/wiki")))
+	require.NoError(t, links.UpdateLink(ctx, "global", "wiki",
+		link.V0("http://global/wiki")))
+	require.NoError(t, links.UpdateLink(ctx, "global", "benefits",
+		link.V0("http://global/benefits")))
+	require.NoError(t, links.UpdateLink(ctx, "hr", "benefits",
+		link.V0("http://hr/benefits")))
+	require.NoError(t, links.UpdateLink(ctx, "hr", "hr/handbook",
+		link.V0("http://hr/handbook")))
+
+	cases := []struct {
+		org   string
+		url   string
+		from  string
+		key   string
+		param string
+	}{
+		{"team", "/wiki", "team", "wiki", ""},
+		{"team", "/benefits/dental", "global", "benefits", "dental"},
+		{"other", "/wiki", "global", "wiki", ""},
+		{"global", "/wiki", "global", "wiki", ""},
+	}
+	for _, c := range cases {
+		resolved, err := resolver.Lookup(ctx, c.org, c.url)
+		require.NoError(t, err, c.url)
+		require.Equal(t, c.from, resolved.Org, c.url)
+		require.Equal(t, c.key, resolved.Key, c.url)
+		require.Equal(t, c.param, resolved.Param, c.url)
+	}
+
+	// explicit shares take precedence over the fallback orgs
+	require.NoError(t, shares.UpdateShare(ctx, Share{
+		Org: "team", Key: "benefits", From: "hr",
+	}))
+	require.NoError(t, shares.UpdateShare(ctx, Share{
+		Org: "team", Key: "hr/handbook", From: "hr",
+	}))
+	resolved, err := resolver.Lookup(ctx, "team", "/benefits")
+	require.NoError(t, err)
+	require.Equal(t, "hr", resolved.Org)
+	require.Equal(t, link.V0("http://hr/benefits"), resolved.Link)
+	resolved, err = resolver.Lookup(ctx, "team", "/hr/handbook/leave")
+	require.NoError(t, err)
+	require.Equal(t, "hr", resolved.Org)
+	require.Equal(t, "hr/handbook", resolved.Key)
+	require.Equal(t, "leave", resolved.Param)
+
+	resolved, err = resolver.Lookup(ctx, "team", "/missing/page")
+	require.True(t, errors.Is(err, link.ErrNotFound))
+	require.Equal(t, "team", resolved.Org)
+	require.Equal(t, "missing", resolved.Key)
+	require.Equal(t, "page", resolved.Param)
+
+	own, err := links.GetLinks(ctx, "team")
+	require.NoError(t, err)
+	shared, err := resolver.SharedLinks(ctx, "team", own)
+	require.NoError(t, err)
+	require.Len(t, shared, 2)
+	require.Equal(t, "benefits", shared[0].Key)
+	require.Equal(t, "hr", shared[0].Org)
+	require.Equal(t, "hr/handbook", shared[1].Key)
+	require.Equal(t, "hr", shared[1].Org)
+
+	// without shares
+	resolver = NewResolver(links, nil)
+	_, err = resolver.Lookup(ctx, "team", "/benefits")
+	require.True(t, errors.Is(err, link.ErrNotFound))
+}
@@ -0,0 +1,40 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Exported errors.
+var (
+	ErrNotFound = errors.New("share not found")
+	ErrConflict = errors.New("shared from another org")
+)
+
+// Share defines a link shared with another org.
+type Share struct {
+	// Org is the org the link is shared with.
+	Org string
+	Key string
+	// From is the org of the link.
+	From string
+}
+
+// Store defines the share store interface.
+type Store interface {
+	fmt.Stringer
+
+	// GetShare returns the share of key with org.
+	GetShare(ctx context.Context, org string, key string) (Share, error)
+	// GetShares returns the shares with org.
+	GetShares(ctx context.Context, org string) ([]Share, error)
+	// GetSharedOrgs returns the orgs the link of key in from is shared with.
+	GetSharedOrgs(ctx context.Context, from string, key string) (
+		[]string, error)
+	// UpdateShare creates or replaces the share of s.Key with s.Org.
+	// ErrConflict is returned if the key is shared with s.Org from another
+	// org.
+	UpdateShare(ctx context.Context, s Share) error
+	DeleteShare(ctx context.Context, org string, key string) error
+}
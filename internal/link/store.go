@@ -3,14 +3,49 @@ package link
 import (
 	"context"
 	"fmt"
+
+	"github.com/haostudio/golinks/internal/kv"
 )
 
+// Page defines a page of links in the key order.
+type Page struct {
+	Keys  []string
+	Links map[string]Link
+	// Next is the cursor of the next page, or empty if it's the last page.
+	Next string
+}
+
 // Store defines the link store interface.
 type Store interface {
 	fmt.Stringer
 
 	GetLink(ctx context.Context, org string, key string) (Link, error)
 	GetLinks(ctx context.Context, org string) (map[string]Link, error)
+	// ScanLinks returns up to limit links with keys having prefix from cursor
+	// in the key order. limit 0 means no limit.
+	ScanLinks(ctx context.Context, org string, prefix string, cursor string,
+		limit int) (Page, error)
 	UpdateLink(ctx context.Context, org string, key string, ln Link) error
+	// UpdateLinkIf updates the link only if the stored link is at revision rev.
+	// Revision 0 matches a link that doesn't exist or was saved before links
+	// had revisions. ErrConflict is returned otherwise.
+	UpdateLinkIf(
+		ctx context.Context, org string, key string, rev int64, ln Link) error
 	DeleteLink(ctx context.Context, org string, key string) error
+	// DeleteLinkIf deletes the link only if the stored link is at revision
+	// rev. ErrConflict is returned otherwise, or ErrNotFound if the link
+	// doesn't exist.
+	DeleteLinkIf(ctx context.Context, org string, key string, rev int64) error
+	// GetOrgs returns the sorted names of the orgs having links.
+	GetOrgs(ctx context.Context) ([]string, error)
+}
+
+// TxStore defines the link store which can be bound to a transaction of its
+// root kv namespace, so that the links change atomically with the other data
+// in the namespace.
+type TxStore interface {
+	Store
+	// WithTx returns the store reading and writing the links through tx, a
+	// transaction of the root namespace of the store.
+	WithTx(tx kv.Namespace) Store
 }
@@ -0,0 +1,278 @@
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/haostudio/golinks/internal/kv"
+	"github.com/haostudio/golinks/internal/link"
+)
+
+// scanLimit is the page size of loading the keys of an org.
+const scanLimit = 1000
+
+// New returns a link.Store indexing the keys of store per org for Suggest.
+// The keys of an org are loaded on the first Suggest of the org and kept up
+// to date with the changes through the returned store, so it should wrap
+// store before the other stores writing to it, e.g. history.
+func New(store link.TxStore) *Index {
+	return &Index{
+		TxStore: store,
+		keys:    make(map[string]map[string]struct{}),
+		loading: make(map[string][]*load),
+	}
+}
+
+// Index defines the link store with the per-org key index.
+type Index struct {
+	link.TxStore
+	mu   sync.RWMutex
+	keys map[string]map[string]struct{}
+	// loading are the loads of the orgs not loaded yet.
+	loading map[string][]*load
+}
+
+// load records the changes of the keys of an org made while loading them,
+// which the loaded keys may miss. true for an added key and false for a
+// removed one.
+type load struct {
+	changes map[string]bool
+}
+
+// Suggest returns up to limit keys of org similar to key, the most similar
+// first.
+func (i *Index) Suggest(ctx context.Context, org string, key string,
+	limit int) ([]string, error) {
+	keys, err := i.get(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	type candidate struct {
+		key   string
+		score int
+	}
+	var candidates []candidate
+	for _, k := range keys {
+		score, ok := Score(key, k)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, candidate{key: k, score: score})
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].score != candidates[b].score {
+			return candidates[a].score < candidates[b].score
+		}
+		return candidates[a].key < candidates[b].key
+	})
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	suggestions := make([]string, len(candidates))
+	for idx, c := range candidates {
+		suggestions[idx] = c.key
+	}
+	return suggestions, nil
+}
+
+// UpdateLink updates the link in store and adds key to the index.
+func (i *Index) UpdateLink(
+	ctx context.Context, org string, key string, ln link.Link) error {
+	err := i.TxStore.UpdateLink(ctx, org, key, ln)
+	if err != nil {
+		return err
+	}
+	i.add(org, key)
+	return nil
+}
+
+// UpdateLinkIf updates the link in store and adds key to the index.
+func (i *Index) UpdateLinkIf(ctx context.Context,
+	org string, key string, rev int64, ln link.Link) error {
+	err := i.TxStore.UpdateLinkIf(ctx, org, key, rev, ln)
+	if err != nil {
+		return err
+	}
+	i.add(org, key)
+	return nil
+}
+
+// DeleteLink deletes the link in store and removes key from the index.
+func (i *Index) DeleteLink(ctx context.Context, org string, key string) error {
+	err := i.TxStore.DeleteLink(ctx, org, key)
+	if err != nil {
+		return err
+	}
+	i.remove(org, key)
+	return nil
+}
+
+// DeleteLinkIf deletes the link in store and removes key from the index.
+func (i *Index) DeleteLinkIf(
+	ctx context.Context, org string, key string, rev int64) error {
+	err := i.TxStore.DeleteLinkIf(ctx, org, key, rev)
+	if err != nil {
+		return err
+	}
+	i.remove(org, key)
+	return nil
+}
+
+// WithTx returns the store bound to tx which keeps the index up to date with
+// the changes through it.
+func (i *Index) WithTx(tx kv.Namespace) link.Store {
+	return &txIndex{Store: i.TxStore.WithTx(tx), index: i}
+}
+
+func (i *Index) String() string {
+	return fmt.Sprintf("suggest.index(%s)", i.TxStore)
+}
+
+func (i *Index) add(org string, key string) {
+	i.change(org, key, true)
+}
+
+func (i *Index) remove(org string, key string) {
+	i.change(org, key, false)
+}
+
+func (i *Index) change(org string, key string, added bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, l := range i.loading[org] {
+		l.changes[key] = added
+	}
+	// the keys are read on loading if org is not loaded yet.
+	keys, ok := i.keys[org]
+	if !ok {
+		return
+	}
+	if added {
+		keys[key] = struct{}{}
+	} else {
+		delete(keys, key)
+	}
+}
+
+// loaded stops recording the changes of org for l.
+func (i *Index) loaded(org string, l *load) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var loads []*load
+	for _, other := range i.loading[org] {
+		if other != l {
+			loads = append(loads, other)
+		}
+	}
+	if len(loads) == 0 {
+		delete(i.loading, org)
+	} else {
+		i.loading[org] = loads
+	}
+}
+
+func (i *Index) get(ctx context.Context, org string) ([]string, error) {
+	i.mu.RLock()
+	keys, ok := i.keys[org]
+	if ok {
+		list := make([]string, 0, len(keys))
+		for key := range keys {
+			list = append(list, key)
+		}
+		i.mu.RUnlock()
+		return list, nil
+	}
+	i.mu.RUnlock()
+
+	// load the keys of org, recording the changes made meanwhile.
+	l := &load{changes: make(map[string]bool)}
+	i.mu.Lock()
+	i.loading[org] = append(i.loading[org], l)
+	i.mu.Unlock()
+	defer i.loaded(org, l)
+	loaded := make(map[string]struct{})
+	var cursor string
+	for {
+		page, err := i.TxStore.ScanLinks(ctx, org, "", cursor, scanLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range page.Keys {
+			loaded[key] = struct{}{}
+		}
+		if len(page.Next) == 0 {
+			break
+		}
+		cursor = page.Next
+	}
+	i.mu.Lock()
+	if keys, ok := i.keys[org]; ok {
+		// loaded concurrently
+		loaded = keys
+	} else {
+		for key, added := range l.changes {
+			if added {
+				loaded[key] = struct{}{}
+			} else {
+				delete(loaded, key)
+			}
+		}
+		i.keys[org] = loaded
+	}
+	list := make([]string, 0, len(loaded))
+	for key := range loaded {
+		list = append(list, key)
+	}
+	i.mu.Unlock()
+	return list, nil
+}
+
+// txIndex defines the store bound to a transaction updating the index. The
+// index is updated as the changes are made, so the keys of a transaction
+// rolled back later may be suggested until the org is reloaded.
+type txIndex struct {
+	link.Store
+	index *Index
+}
+
+func (t *txIndex) UpdateLink(
+	ctx context.Context, org string, key string, ln link.Link) error {
+	err := t.Store.UpdateLink(ctx, org, key, ln)
+	if err != nil {
+		return err
+	}
+	t.index.add(org, key)
+	return nil
+}
+
+func (t *txIndex) UpdateLinkIf(ctx context.Context,
+	org string, key string, rev int64, ln link.Link) error {
+	err := t.Store.UpdateLinkIf(ctx, org, key, rev, ln)
+	if err != nil {
+		return err
+	}
+	t.index.add(org, key)
+	return nil
+}
+
+func (t *txIndex) DeleteLink(
+	ctx context.Context, org string, key string) error {
+	err := t.Store.DeleteLink(ctx, org, key)
+	if err != nil {
+		return err
+	}
+	t.index.remove(org, key)
+	return nil
+}
+
+func (t *txIndex) DeleteLinkIf(
+	ctx context.Context, org string, key string, rev int64) error {
+	err := t.Store.DeleteLinkIf(ctx, org, key, rev)
+	if err != nil {
+		return err
+	}
+	t.index.remove(org, key)
+	return nil
+}
@@ -0,0 +1,90 @@
+package suggest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+	"github.com/haostudio/golinks/internal/kv/memory"
+	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/kv"
+	"github.com/haostudio/golinks/internal/link/linktest"
+)
+
+func TestStoreLogic(t *testing.T) {
+	store := New(kv.New(memory.New().In("test"), gob.New()))
+	linktest.StoreLogicTest(t, store)
+	linktest.StoreUpdateLinkIfTest(t, store)
+	linktest.StoreDeleteLinkIfTest(t, store)
+	linktest.StoreScanLinksTest(t, store)
+	linktest.StoreGetOrgsTest(t, store)
+	linktest.StoreLookupTest(t, store)
+	linktest.StoreAliasTest(t, store)
+}
+
+func TestSuggest(t *testing.T) {
+	ctx := context.Background()
+	base := kv.New(memory.New().In("test"), gob.New())
+	index := New(base)
+
+	for _, key := range []string{"dashboard", "dash", "docs", "team/infra"} {
+		require.NoError(t, base.UpdateLink(ctx, "org", key, link.V0("http://x")))
+	}
+	keys, err := index.Suggest(ctx, "org", "dashbaord", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"dashboard", "dash"}, keys)
+	keys, err = index.Suggest(ctx, "org", "dashbaord", 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"dashboard"}, keys)
+	keys, err = index.Suggest(ctx, "other", "dashbaord", 0)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	// kept up to date with the changes through the index
+	require.NoError(t, index.UpdateLink(ctx, "org", "dashboards",
+		link.V0("http://x")))
+	require.NoError(t, index.UpdateLinkIf(ctx, "org", "dashbord", 0,
+		link.V0("http://x")))
+	require.NoError(t, index.DeleteLink(ctx, "org", "dash"))
+	keys, err = index.Suggest(ctx, "org", "dashbaord", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"dashboard", "dashbord", "dashboards"}, keys)
+}
+
+// changingStore calls change after scanning the first page of links, as if
+// the links were changed while loading the keys.
+type changingStore struct {
+	link.TxStore
+	change func()
+}
+
+func (s *changingStore) ScanLinks(ctx context.Context, org string,
+	prefix string, cursor string, limit int) (link.Page, error) {
+	page, err := s.TxStore.ScanLinks(ctx, org, prefix, cursor, limit)
+	if s.change != nil {
+		s.change()
+		s.change = nil
+	}
+	return page, err
+}
+
+func TestSuggestChangedWhileLoading(t *testing.T) {
+	ctx := context.Background()
+	base := &changingStore{
+		TxStore: kv.New(memory.New().In("test"), gob.New()),
+	}
+	index := New(base)
+	for _, key := range []string{"dashboard", "dash"} {
+		require.NoError(t, base.UpdateLink(ctx, "org", key, link.V0("http://x")))
+	}
+	base.change = func() {
+		require.NoError(t, index.UpdateLink(ctx, "org", "dashbord",
+			link.V0("http://x")))
+		require.NoError(t, index.DeleteLink(ctx, "org", "dash"))
+	}
+	keys, err := index.Suggest(ctx, "org", "dashbaord", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"dashboard", "dashbord"}, keys)
+}
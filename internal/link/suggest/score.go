@@ -0,0 +1,70 @@
+package suggest
+
+import (
+	"strings"
+)
+
+// minPrefix is the minimum length of the common prefix of a similar key
+// beyond the edit distance threshold.
+const minPrefix = 3
+
+// Score returns the similarity score of key to query, the lower the more
+// similar, and false if key is not similar to query. The score is based on
+// the case-insensitive edit distance, with adjacent transpositions as one
+// edit, and the length of the common prefix.
+func Score(query string, key string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	k := []rune(strings.ToLower(key))
+	prefix := commonPrefix(q, k)
+	dist := distance(q, k)
+	// allow an edit every 3 characters
+	threshold := len(q)/3 + 1
+	if dist > threshold && prefix < minPrefix && prefix < len(q) {
+		return 0, false
+	}
+	return 2*dist - prefix, true
+}
+
+func commonPrefix(a []rune, b []rune) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// distance returns the optimal string alignment distance of a and b.
+func distance(a []rune, b []rune) int {
+	// d[i][j] is the distance of a[:i] and b[:j]
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
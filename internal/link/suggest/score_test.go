@@ -0,0 +1,53 @@
+package suggest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScore(t *testing.T) {
+	similar := []struct {
+		query string
+		key   string
+	}{
+		{"dashbaord", "dashboard"},
+		{"Dashboard", "dashboard"},
+		{"dash", "dashboard"},
+		{"dashboards", "dash"},
+		{"team/infr", "team/infra"},
+		{"oncal", "oncall"},
+	}
+	for _, c := range similar {
+		_, ok := Score(c.query, c.key)
+		require.True(t, ok, "%s %s", c.query, c.key)
+	}
+	for _, key := range []string{"docs", "wiki", "team/infra"} {
+		_, ok := Score("dashbaord", key)
+		require.False(t, ok, key)
+	}
+
+	// transposition is an edit
+	typo, _ := Score("dashbaord", "dashboard")
+	prefix, _ := Score("dashbaord", "dash")
+	require.Less(t, typo, prefix)
+	exact, _ := Score("dashboard", "dashboard")
+	require.Less(t, exact, typo)
+}
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		d    int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"ab", "ba", 1},
+		{"dashbaord", "dashboard", 1},
+		{"ca", "abc", 3},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.d, distance([]rune(c.a), []rune(c.b)), c.a+" "+c.b)
+	}
+}
@@ -0,0 +1,130 @@
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/popodidi/log"
+
+	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/history"
+)
+
+// Editor is the editor recorded for the links removed by the sweeper.
+const Editor = "golinks-sweeper"
+
+const defaultInterval = time.Minute
+
+// Config defines the sweeper config.
+type Config struct {
+	// Store is the store to remove the expired links from. The removed links
+	// are archived if it's a history.Store.
+	Store link.Store
+	// Interval is the period between sweeps. Default to a minute.
+	Interval time.Duration // optional
+	// Grace is how long an expired link is kept, and shown as expired, before
+	// it's removed.
+	Grace time.Duration // optional
+}
+
+// New returns a sweeper removing the expired links from conf.Store
+// periodically in background until it's closed.
+func New(conf Config) *Sweeper {
+	if conf.Interval <= 0 {
+		conf.Interval = defaultInterval
+	}
+	s := &Sweeper{
+		store:    conf.Store,
+		interval: conf.Interval,
+		grace:    conf.Grace,
+		logger:   log.New("sweeper"),
+		done:     make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.loop()
+	return s
+}
+
+// Sweeper defines the expired link sweeper.
+type Sweeper struct {
+	store    link.Store
+	interval time.Duration
+	grace    time.Duration
+	logger   log.Logger
+	done     chan struct{}
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+// Sweep removes the links of all orgs that expired longer than the grace
+// period before now, and returns the number of removed links.
+func (s *Sweeper) Sweep(ctx context.Context, now time.Time) (int, error) {
+	ctx = history.WithEditor(ctx, Editor)
+	orgs, err := s.store.GetOrgs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for _, org := range orgs {
+		links, err := s.store.GetLinks(ctx, org)
+		if errors.Is(err, link.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return count, err
+		}
+		for key, ln := range links {
+			if !s.expired(ln, now) {
+				continue
+			}
+			// the link may have been renewed or removed since it was listed.
+			err = s.store.DeleteLinkIf(ctx, org, key, ln.Meta.Revision)
+			if errors.Is(err, link.ErrConflict) ||
+				errors.Is(err, link.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return count, err
+			}
+			s.logger.Debug("removed %s/%s expired at %s",
+				org, key, ln.Meta.ExpiresAt)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Close stops the sweeper and waits for the running sweep to finish.
+func (s *Sweeper) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Sweeper) expired(ln link.Link, now time.Time) bool {
+	return ln.Expired(now.Add(-s.grace))
+}
+
+func (s *Sweeper) loop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			count, err := s.Sweep(context.Background(), now)
+			if err != nil {
+				s.logger.Error("failed to sweep expired links. err: %v", err)
+			}
+			if count > 0 {
+				s.logger.Info("removed %d expired links", count)
+			}
+		}
+	}
+}
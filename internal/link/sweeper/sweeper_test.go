@@ -0,0 +1,117 @@
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+	"github.com/haostudio/golinks/internal/kv/memory"
+	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/history"
+	lnkv "github.com/haostudio/golinks/internal/link/kv"
+)
+
+func TestSweep(t *testing.T) {
+	ctx := context.Background()
+	kvStore := memory.New()
+	enc := gob.New()
+	store := history.New(
+		lnkv.NewIn(kvStore.In(), "link", enc), kvStore.In(), "history", enc)
+	now := time.Unix(10000, 0)
+
+	links := map[string]time.Time{
+		"never":   {},
+		"expired": now.Add(-time.Hour),
+		"grace":   now.Add(-time.Minute),
+		"future":  now.Add(time.Hour),
+	}
+	for _, org := range []string{"ORG_1", "ORG_2"} {
+		for key, expiresAt := range links {
+			ln := link.V0("http://" + key)
+			ln.Meta.ExpiresAt = expiresAt
+			require.NoError(t, store.UpdateLink(ctx, org, key, ln))
+		}
+	}
+
+	s := New(Config{
+		Store:    store,
+		Interval: time.Hour,
+		Grace:    10 * time.Minute,
+	})
+	defer s.Close()
+	count, err := s.Sweep(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	for _, org := range []string{"ORG_1", "ORG_2"} {
+		_, err = store.GetLink(ctx, org, "expired")
+		require.True(t, errors.Is(err, link.ErrNotFound))
+		for _, key := range []string{"never", "grace", "future"} {
+			_, err = store.GetLink(ctx, org, key)
+			require.NoError(t, err)
+		}
+		// the removed link is archived
+		revs, err := store.GetRevisions(ctx, org, "expired")
+		require.NoError(t, err)
+		require.Len(t, revs, 1)
+		require.True(t, revs[0].Deleted)
+		require.Equal(t, Editor, revs[0].Editor)
+	}
+
+	// nothing left to sweep
+	count, err = s.Sweep(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+}
+
+// renewingStore renews the link with key after listing the links, as if it
+// was renewed during the sweep.
+type renewingStore struct {
+	link.Store
+	key string
+}
+
+func (s *renewingStore) GetLinks(ctx context.Context, org string) (
+	map[string]link.Link, error) {
+	links, err := s.Store.GetLinks(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	ln := links[s.key]
+	ln.Meta.ExpiresAt = time.Time{}
+	return links, s.Store.UpdateLinkIf(ctx, org, s.key, ln.Meta.Revision, ln)
+}
+
+func TestSweepRenewed(t *testing.T) {
+	ctx := context.Background()
+	store := lnkv.New(memory.New().In("link"), gob.New())
+	now := time.Unix(10000, 0)
+	ln := link.V0("http://expired")
+	ln.Meta.ExpiresAt = now.Add(-time.Hour)
+	require.NoError(t, store.UpdateLinkIf(ctx, "ORG", "expired", 0, ln))
+
+	s := New(Config{
+		Store:    &renewingStore{Store: store, key: "expired"},
+		Interval: time.Hour,
+	})
+	defer s.Close()
+	count, err := s.Sweep(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+	_, err = store.GetLink(ctx, "ORG", "expired")
+	require.NoError(t, err)
+}
+
+func TestClose(t *testing.T) {
+	s := New(Config{
+		Store:    lnkv.New(memory.New().In("link"), gob.New()),
+		Interval: time.Millisecond,
+	})
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, s.Close())
+	require.NoError(t, s.Close())
+}
@@ -36,6 +36,14 @@ func (s *store) GetLinks(ctx context.Context, org string) (
 	return s.store.GetLinks(ctx, org)
 }
 
+func (s *store) ScanLinks(ctx context.Context, org string, prefix string,
+	cursor string, limit int) (link.Page, error) {
+	ctx, span := trace.StartSpan(ctx, "store.ScanLinks")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("store", s.store.String()))
+	return s.store.ScanLinks(ctx, org, prefix, cursor, limit)
+}
+
 func (s *store) UpdateLink(
 	ctx context.Context, org string, key string, ln link.Link) error {
 	ctx, span := trace.StartSpan(ctx, "store.UpdateLink")
@@ -44,6 +52,14 @@ func (s *store) UpdateLink(
 	return s.store.UpdateLink(ctx, org, key, ln)
 }
 
+func (s *store) UpdateLinkIf(ctx context.Context,
+	org string, key string, rev int64, ln link.Link) error {
+	ctx, span := trace.StartSpan(ctx, "store.UpdateLinkIf")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("store", s.store.String()))
+	return s.store.UpdateLinkIf(ctx, org, key, rev, ln)
+}
+
 func (s *store) DeleteLink(ctx context.Context, org string, key string) error {
 	ctx, span := trace.StartSpan(ctx, "store.DeleteLink")
 	defer span.End()
@@ -51,6 +67,21 @@ func (s *store) DeleteLink(ctx context.Context, org string, key string) error {
 	return s.store.DeleteLink(ctx, org, key)
 }
 
+func (s *store) DeleteLinkIf(
+	ctx context.Context, org string, key string, rev int64) error {
+	ctx, span := trace.StartSpan(ctx, "store.DeleteLinkIf")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("store", s.store.String()))
+	return s.store.DeleteLinkIf(ctx, org, key, rev)
+}
+
+func (s *store) GetOrgs(ctx context.Context) ([]string, error) {
+	ctx, span := trace.StartSpan(ctx, "store.GetOrgs")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("store", s.store.String()))
+	return s.store.GetOrgs(ctx)
+}
+
 func (s *store) String() string {
 	return fmt.Sprintf("traced(%s)", s.store)
 }
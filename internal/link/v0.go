@@ -30,3 +30,7 @@ func (v *v0) Resolve(blob []byte, param string) (target string, err error) {
 func (v *v0) Describe(blob []byte) (string, error) {
 	return string(blob), nil
 }
+
+func (v *v0) Payload(blob []byte) (string, error) {
+	return string(blob), nil
+}
@@ -27,3 +27,7 @@ func (v *v1) Resolve(blob []byte, param string) (target string, err error) {
 func (v *v1) Describe(blob []byte) (string, error) {
 	return string(blob), nil
 }
+
+func (v *v1) Payload(blob []byte) (string, error) {
+	return string(blob), nil
+}
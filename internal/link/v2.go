@@ -47,12 +47,15 @@ func (v *v2) Resolve(blob []byte, param string) (target string, err error) {
 	if err != nil {
 		return
 	}
+	return resolveV2(payload, param)
+}
 
+func resolveV2(payload v2payload, param string) (target string, err error) {
 	params := make([]string, 0, payload.VariableNum)
 	for i := 0; i < payload.VariableNum; i++ {
 		if len(param) == 0 {
 			// Invalid number of parameters
-			err = ErrInvalidParams
+			err = &MissingParamError{Name: fmt.Sprintf("{%d}", i)}
 			return
 		}
 		var elem string
@@ -76,6 +79,15 @@ func (v *v2) Describe(blob []byte) (string, error) {
 	return fmt.Sprintf("var_num:%d|%s", payload.VariableNum, payload.Format), nil
 }
 
+func (v *v2) Payload(blob []byte) (string, error) {
+	var payload v2payload
+	err := v2enc.Decode(blob, &payload)
+	if err != nil {
+		return "", err
+	}
+	return payload.Format, nil
+}
+
 func parseV2NumVar(url string) int {
 	var i int
 	for i < 1<<10 {
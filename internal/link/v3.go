@@ -0,0 +1,165 @@
+package link
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+)
+
+// ScheduleEntry defines an entry of a v3 link, which redirects to Target
+// from ActiveFrom until the next entry becomes active. Target is formatted as
+// a v2 link.
+type ScheduleEntry struct {
+	ActiveFrom time.Time
+	Target     string
+}
+
+// V3 returns a v3 link with the schedule entries.
+func V3(entries ...ScheduleEntry) (ln Link, err error) {
+	err = validateV3Entries(entries)
+	if err != nil {
+		return
+	}
+	blob, err := v3enc.Encode(v3payload{Entries: entries})
+	if err != nil {
+		return
+	}
+	ln = Link{
+		Version: 3,
+		Blob:    blob,
+	}
+	return
+}
+
+// Schedule returns the schedule entries of a v3 link.
+func (l *Link) Schedule() ([]ScheduleEntry, error) {
+	if l.Version != 3 {
+		return nil, ErrVersionNotSupport
+	}
+	var payload v3payload
+	err := v3enc.Decode(l.Blob, &payload)
+	if err != nil {
+		return nil, err
+	}
+	return payload.Entries, nil
+}
+
+// ActiveEntry returns the index of the entry in entries active at now, or -1
+// if none of them is active yet.
+func ActiveEntry(entries []ScheduleEntry, now time.Time) int {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !now.Before(entries[i].ActiveFrom) {
+			return i
+		}
+	}
+	return -1
+}
+
+type v3 struct{}
+
+type v3payload struct {
+	Entries []ScheduleEntry
+}
+
+var v3enc = gob.New()
+
+// New parses the payload of whitespace separated entries, e.g.
+// "https://draft 2020-06-01T00:00:00Z https://public". An entry is a target
+// with an RFC3339 active time, except the first one, which is active from the
+// beginning if the time is omitted.
+func (v *v3) New(str string) ([]byte, error) {
+	fields := strings.Fields(str)
+	entries := make([]ScheduleEntry, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		var entry ScheduleEntry
+		t, err := time.Parse(time.RFC3339, fields[i])
+		switch {
+		case err == nil && i+1 < len(fields):
+			entry.ActiveFrom = t
+			i++
+		case err == nil:
+			return nil, fmt.Errorf(
+				"missing target after %s: %w", fields[i], ErrInvalidPayload)
+		case len(entries) > 0:
+			return nil, fmt.Errorf(
+				"missing active time before %s: %w", fields[i], ErrInvalidPayload)
+		}
+		entry.Target = fields[i]
+		entries = append(entries, entry)
+	}
+	err := validateV3Entries(entries)
+	if err != nil {
+		return nil, err
+	}
+	return v3enc.Encode(v3payload{Entries: entries})
+}
+
+func (v *v3) Resolve(blob []byte, param string) (target string, err error) {
+	var payload v3payload
+	err = v3enc.Decode(blob, &payload)
+	if err != nil {
+		return
+	}
+	idx := ActiveEntry(payload.Entries, time.Now())
+	if idx < 0 {
+		err = ErrNotActive
+		return
+	}
+	format := payload.Entries[idx].Target
+	return resolveV2(v2payload{
+		VariableNum: parseV2NumVar(format),
+		Format:      format,
+	}, param)
+}
+
+func (v *v3) Describe(blob []byte) (string, error) {
+	var payload v3payload
+	err := v3enc.Decode(blob, &payload)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("entries:%d|%s",
+		len(payload.Entries), formatV3Entries(payload.Entries, " ")), nil
+}
+
+func (v *v3) Payload(blob []byte) (string, error) {
+	var payload v3payload
+	err := v3enc.Decode(blob, &payload)
+	if err != nil {
+		return "", err
+	}
+	return formatV3Entries(payload.Entries, "\n"), nil
+}
+
+func validateV3Entries(entries []ScheduleEntry) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("empty schedule: %w", ErrInvalidPayload)
+	}
+	for i, entry := range entries {
+		if len(entry.Target) == 0 {
+			return fmt.Errorf("empty target: %w", ErrInvalidPayload)
+		}
+		if i > 0 && !entries[i-1].ActiveFrom.Before(entry.ActiveFrom) {
+			return fmt.Errorf(
+				"entries are not in the order of active time: %w",
+				ErrInvalidPayload,
+			)
+		}
+	}
+	return nil
+}
+
+func formatV3Entries(entries []ScheduleEntry, sep string) string {
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.ActiveFrom.IsZero() {
+			lines = append(lines, entry.Target)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s %s",
+			entry.ActiveFrom.Format(time.RFC3339), entry.Target))
+	}
+	return strings.Join(lines, sep)
+}
@@ -0,0 +1,73 @@
+package link
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestV3(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	ln, err := V3(
+		ScheduleEntry{Target: "https://draft"},
+		ScheduleEntry{ActiveFrom: now.Add(-time.Hour), Target: "https://{0}"},
+		ScheduleEntry{ActiveFrom: now.Add(time.Hour), Target: "https://later"},
+	)
+	require.NoError(t, err)
+	target, err := ln.GetRedirectLink("public/notes")
+	require.NoError(t, err)
+	require.Equal(t, "https://public", target)
+	_, err = ln.GetRedirectLink("")
+	require.True(t, errors.Is(err, ErrInvalidParams))
+
+	entries, err := ln.Schedule()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, 0, ActiveEntry(entries, now.Add(-2*time.Hour)))
+	require.Equal(t, 1, ActiveEntry(entries, now))
+	require.Equal(t, 2, ActiveEntry(entries, now.Add(time.Hour)))
+
+	// not active before the first entry
+	ln, err = V3(ScheduleEntry{
+		ActiveFrom: now.Add(time.Hour),
+		Target:     "https://later",
+	})
+	require.NoError(t, err)
+	_, err = ln.GetRedirectLink("")
+	require.Equal(t, ErrNotActive, err)
+
+	_, err = (&Link{Version: 2}).Schedule()
+	require.Equal(t, ErrVersionNotSupport, err)
+}
+
+func TestV3Payload(t *testing.T) {
+	ln, err := New(3,
+		" https://draft  2020-06-01T00:00:00+08:00\thttps://public\n")
+	require.NoError(t, err)
+	entries, err := ln.Schedule()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.True(t, entries[0].ActiveFrom.IsZero())
+	require.Equal(t, "https://draft", entries[0].Target)
+	require.True(t, entries[1].ActiveFrom.Equal(
+		time.Date(2020, 5, 31, 16, 0, 0, 0, time.UTC)))
+	require.Equal(t, "https://public", entries[1].Target)
+	payload, err := ln.Payload()
+	require.NoError(t, err)
+	require.Equal(t,
+		"https://draft\n2020-06-01T00:00:00+08:00 https://public", payload)
+
+	invalids := []string{
+		"",
+		"2020-06-01T00:00:00Z",
+		"https://draft https://public",
+		"2020-06-01T00:00:00Z https://a 2020-05-01T00:00:00Z https://b",
+		"2020-06-01T00:00:00Z https://a 2020-06-01T00:00:00Z https://b",
+	}
+	for _, payload := range invalids {
+		_, err = New(3, payload)
+		require.True(t, errors.Is(err, ErrInvalidPayload), payload)
+	}
+}
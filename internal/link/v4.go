@@ -0,0 +1,165 @@
+package link
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+)
+
+// v4 placeholders: {name} or {name=default}
+var v4placeholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(=[^{}]*)?\}`)
+
+// V4 returns a v4 link.
+func V4(format string) (ln Link, err error) {
+	blob, err := (&v4{}).New(format)
+	if err != nil {
+		return
+	}
+	ln = Link{
+		Version: 4,
+		Blob:    blob,
+	}
+	return
+}
+
+type v4 struct{}
+
+type v4payload struct {
+	Format string
+	// Vars are the placeholders in the order of their first appearance in
+	// Format.
+	Vars []v4var
+}
+
+type v4var struct {
+	Name       string
+	Default    string
+	HasDefault bool
+}
+
+var v4enc = gob.New()
+
+func (v *v4) New(str string) ([]byte, error) {
+	payload, err := parseV4(str)
+	if err != nil {
+		return nil, err
+	}
+	return v4enc.Encode(payload)
+}
+
+func parseV4(str string) (payload v4payload, err error) {
+	payload.Format = str
+	seen := make(map[string]v4var)
+	for _, match := range v4placeholder.FindAllStringSubmatch(str, -1) {
+		vr := v4var{
+			Name:       match[1],
+			Default:    strings.TrimPrefix(match[2], "="),
+			HasDefault: len(match[2]) > 0,
+		}
+		prev, ok := seen[vr.Name]
+		if !ok {
+			seen[vr.Name] = vr
+			payload.Vars = append(payload.Vars, vr)
+			continue
+		}
+		if prev != vr {
+			err = fmt.Errorf(
+				"conflicting defaults of {%s}: %w", vr.Name, ErrInvalidPayload)
+			return
+		}
+	}
+	return
+}
+
+func (v *v4) Resolve(blob []byte, param string) (string, error) {
+	return v.ResolveRequest(blob, Request{Param: param})
+}
+
+func (v *v4) ResolveRequest(blob []byte, req Request) (string, error) {
+	var payload v4payload
+	err := v4enc.Decode(blob, &payload)
+	if err != nil {
+		return "", err
+	}
+	return resolveV4(payload, req.Param, req.Query)
+}
+
+// resolveV4 resolves the value of each placeholder from the query parameter
+// of the same name, or the next "/" separated segment of param, or the
+// default value, in order.
+func resolveV4(payload v4payload, param string, query url.Values) (
+	target string, err error) {
+	values := make(map[string]string, len(payload.Vars))
+	for _, vr := range payload.Vars {
+		if _, ok := query[vr.Name]; ok {
+			values[vr.Name] = query.Get(vr.Name)
+			continue
+		}
+		if len(param) > 0 {
+			values[vr.Name], param = Pop(param, "/")
+			continue
+		}
+		if vr.HasDefault {
+			values[vr.Name] = vr.Default
+			continue
+		}
+		err = &MissingParamError{Name: vr.Name}
+		return
+	}
+
+	var (
+		sb   strings.Builder
+		last int
+	)
+	format := payload.Format
+	for _, loc := range v4placeholder.FindAllStringSubmatchIndex(format, -1) {
+		sb.WriteString(format[last:loc[0]])
+		value := values[format[loc[2]:loc[3]]]
+		if inV4Query(format, loc[0]) {
+			sb.WriteString(url.QueryEscape(value))
+		} else {
+			sb.WriteString(url.PathEscape(value))
+		}
+		last = loc[1]
+	}
+	sb.WriteString(format[last:])
+	target = sb.String()
+	return
+}
+
+// inV4Query returns true if pos of format is in the query string, where the
+// values are escaped as query components instead of path segments.
+func inV4Query(format string, pos int) bool {
+	query := strings.Index(format, "?")
+	if query < 0 || pos < query {
+		return false
+	}
+	fragment := strings.Index(format, "#")
+	return fragment < 0 || pos < fragment
+}
+
+func (v *v4) Describe(blob []byte) (string, error) {
+	var payload v4payload
+	err := v4enc.Decode(blob, &payload)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(payload.Vars))
+	for _, vr := range payload.Vars {
+		names = append(names, vr.Name)
+	}
+	return fmt.Sprintf("vars:%s|%s",
+		strings.Join(names, ","), payload.Format), nil
+}
+
+func (v *v4) Payload(blob []byte) (string, error) {
+	var payload v4payload
+	err := v4enc.Decode(blob, &payload)
+	if err != nil {
+		return "", err
+	}
+	return payload.Format, nil
+}
@@ -0,0 +1,73 @@
+package link
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestV4(t *testing.T) {
+	ln, err := V4("https://jira/browse/{ticket}?page={page=1}&q={q=}#{ticket}")
+	require.NoError(t, err)
+
+	cases := []struct {
+		param  string
+		query  url.Values
+		target string
+	}{
+		{
+			param:  "ABC-1",
+			target: "https://jira/browse/ABC-1?page=1&q=#ABC-1",
+		},
+		{
+			param:  "ABC-1/2/a b&c",
+			target: "https://jira/browse/ABC-1?page=2&q=a+b%26c#ABC-1",
+		},
+		{
+			param:  "a/b",
+			target: "https://jira/browse/a?page=b&q=#a",
+		},
+		{
+			query:  url.Values{"ticket": {"x/y"}},
+			target: "https://jira/browse/x%2Fy?page=1&q=#x%2Fy",
+		},
+		{
+			param:  "3",
+			query:  url.Values{"ticket": {"A"}},
+			target: "https://jira/browse/A?page=3&q=#A",
+		},
+	}
+	for _, c := range cases {
+		target, err := ln.Resolve(Request{Param: c.param, Query: c.query})
+		require.NoError(t, err)
+		require.Equal(t, c.target, target)
+	}
+
+	_, err = ln.GetRedirectLink("")
+	require.True(t, errors.Is(err, ErrInvalidParams))
+	var missing *MissingParamError
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, "ticket", missing.Name)
+
+	// literal braces
+	ln, err = V4("https://a/{0}/{ x }")
+	require.NoError(t, err)
+	target, err := ln.GetRedirectLink("b")
+	require.NoError(t, err)
+	require.Equal(t, "https://a/{0}/{ x }", target)
+
+	_, err = V4("https://a/{x=1}/{x=2}")
+	require.True(t, errors.Is(err, ErrInvalidPayload))
+}
+
+func TestResolve(t *testing.T) {
+	ln := V1("https://github.com/{}/issues")
+	target, err := ln.Resolve(Request{
+		Param: "golinks",
+		Query: url.Values{"q": {"x"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "https://github.com/golinks/issues", target)
+}
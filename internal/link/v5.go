@@ -0,0 +1,216 @@
+package link
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/haostudio/golinks/internal/encoding/gob"
+)
+
+// v5 condition kinds
+const (
+	v5Header = "header"
+	v5Query  = "query"
+	v5Domain = "domain"
+	v5IP     = "ip"
+)
+
+// v5 condition operators
+const (
+	v5Equal    = "="
+	v5Contains = "~"
+)
+
+// v5Fallback is the condition of the fallback rule.
+const v5Fallback = "*"
+
+type v5 struct{}
+
+type v5payload struct {
+	// Source is the normalized payload.
+	Source string
+	// Rules are in the order of evaluation with the fallback rule, which has
+	// no conditions, at last.
+	Rules []v5rule
+}
+
+type v5rule struct {
+	Conditions []v5condition
+	Target     v4payload
+}
+
+type v5condition struct {
+	Kind string
+	// Name is the header or query parameter name.
+	Name  string
+	Op    string
+	Value string
+}
+
+var v5enc = gob.New()
+
+// New parses the payload of a rule per line, e.g.
+// "query:env=staging https://staging" and "* https://prod". A rule is a list
+// of whitespace separated conditions, which all have to match, followed by
+// the target formatted as a v4 link. A condition matches a header or query
+// parameter equal to ("header:<name>=<value>") or containing
+// ("query:<name>~<value>") the case-insensitive and URL-unescaped value, the
+// user email domain ("domain:<domain>"), or the client IP in a CIDR
+// ("ip:<cidr>"). The last rule has to be the fallback rule "* <target>".
+func (v *v5) New(str string) ([]byte, error) {
+	var (
+		payload v5payload
+		lines   []string
+	)
+	for _, line := range strings.Split(str, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(payload.Rules) > 0 &&
+			len(payload.Rules[len(payload.Rules)-1].Conditions) == 0 {
+			return nil, fmt.Errorf(
+				"rules after the fallback rule: %w", ErrInvalidPayload)
+		}
+		if len(fields) == 1 {
+			return nil, fmt.Errorf(
+				"missing conditions of %s: %w", fields[0], ErrInvalidPayload)
+		}
+		var (
+			rule v5rule
+			err  error
+		)
+		conds := fields[:len(fields)-1]
+		if len(conds) > 1 || conds[0] != v5Fallback {
+			for _, cond := range conds {
+				var c v5condition
+				c, err = parseV5Condition(cond)
+				if err != nil {
+					return nil, err
+				}
+				rule.Conditions = append(rule.Conditions, c)
+			}
+		}
+		rule.Target, err = parseV4(fields[len(fields)-1])
+		if err != nil {
+			return nil, err
+		}
+		payload.Rules = append(payload.Rules, rule)
+		lines = append(lines, strings.Join(fields, " "))
+	}
+	if len(payload.Rules) == 0 ||
+		len(payload.Rules[len(payload.Rules)-1].Conditions) != 0 {
+		return nil, fmt.Errorf("missing fallback rule: %w", ErrInvalidPayload)
+	}
+	payload.Source = strings.Join(lines, "\n")
+	return v5enc.Encode(payload)
+}
+
+func parseV5Condition(str string) (cond v5condition, err error) {
+	var spec string
+	cond.Kind, spec = Pop(str, ":")
+	switch cond.Kind {
+	case v5Header, v5Query:
+		idx := strings.IndexAny(spec, v5Equal+v5Contains)
+		if idx <= 0 {
+			err = fmt.Errorf("invalid condition %s: %w", str, ErrInvalidPayload)
+			return
+		}
+		cond.Name = spec[:idx]
+		cond.Op = spec[idx : idx+1]
+		cond.Value, err = url.PathUnescape(spec[idx+1:])
+	case v5Domain:
+		cond.Value, err = url.PathUnescape(spec)
+	case v5IP:
+		cond.Value = spec
+		_, _, err = net.ParseCIDR(spec)
+	default:
+		err = fmt.Errorf("unknown condition %s", str)
+	}
+	if err != nil && !errors.Is(err, ErrInvalidPayload) {
+		err = fmt.Errorf("%v: %w", err, ErrInvalidPayload)
+	}
+	return
+}
+
+func (c v5condition) match(req Request) bool {
+	switch c.Kind {
+	case v5Header:
+		return matchV5Value(c.Op, req.Header.Get(c.Name), c.Value)
+	case v5Query:
+		values, ok := req.Query[c.Name]
+		if !ok {
+			return false
+		}
+		for _, value := range values {
+			if matchV5Value(c.Op, value, c.Value) {
+				return true
+			}
+		}
+		return false
+	case v5Domain:
+		idx := strings.LastIndex(req.UserEmail, "@")
+		return idx >= 0 && strings.EqualFold(req.UserEmail[idx+1:], c.Value)
+	case v5IP:
+		_, cidr, err := net.ParseCIDR(c.Value)
+		return err == nil && req.ClientIP != nil && cidr.Contains(req.ClientIP)
+	}
+	return false
+}
+
+func matchV5Value(op string, value string, expected string) bool {
+	if op == v5Contains {
+		return strings.Contains(
+			strings.ToLower(value), strings.ToLower(expected))
+	}
+	return strings.EqualFold(value, expected)
+}
+
+func (v *v5) Resolve(blob []byte, param string) (string, error) {
+	return v.ResolveRequest(blob, Request{Param: param})
+}
+
+// ResolveRequest resolves the target of the first rule matching req.
+func (v *v5) ResolveRequest(blob []byte, req Request) (string, error) {
+	var payload v5payload
+	err := v5enc.Decode(blob, &payload)
+	if err != nil {
+		return "", err
+	}
+	for _, rule := range payload.Rules {
+		matched := true
+		for _, cond := range rule.Conditions {
+			if !cond.match(req) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return resolveV4(rule.Target, req.Param, req.Query)
+		}
+	}
+	// the fallback rule always matches.
+	return "", fmt.Errorf("no rule matched: %w", ErrInvalidPayload)
+}
+
+func (v *v5) Describe(blob []byte) (string, error) {
+	var payload v5payload
+	err := v5enc.Decode(blob, &payload)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("rules:%d|%s", len(payload.Rules),
+		strings.Replace(payload.Source, "\n", "; ", -1)), nil
+}
+
+func (v *v5) Payload(blob []byte) (string, error) {
+	var payload v5payload
+	err := v5enc.Decode(blob, &payload)
+	if err != nil {
+		return "", err
+	}
+	return payload.Source, nil
+}
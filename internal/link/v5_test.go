@@ -0,0 +1,100 @@
+package link
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestV5(t *testing.T) {
+	ln, err := New(5, `
+header:User-Agent~windows   https://vpn/windows
+query:env=staging https://staging/{page=home}
+domain:corp.com ip:10.0.0.0/8 https://corp/{page=home}
+header:X-Team=golinks%20team https://team
+* https://prod/{page=home}
+`)
+	require.NoError(t, err)
+
+	cases := []struct {
+		req    Request
+		target string
+	}{
+		{
+			req: Request{
+				Header: http.Header{"User-Agent": {"Mozilla/5.0 (Windows NT)"}},
+			},
+			target: "https://vpn/windows",
+		},
+		{
+			req: Request{
+				Param: "a",
+				Query: url.Values{"env": {"prod", "Staging"}},
+			},
+			target: "https://staging/a",
+		},
+		{
+			req: Request{
+				UserEmail: "user@CORP.com",
+				ClientIP:  net.ParseIP("10.1.2.3"),
+			},
+			target: "https://corp/home",
+		},
+		{
+			req: Request{
+				UserEmail: "user@corp.com",
+				ClientIP:  net.ParseIP("192.168.1.1"),
+			},
+			target: "https://prod/home",
+		},
+		{
+			req: Request{
+				Header: http.Header{"X-Team": {"golinks team"}},
+			},
+			target: "https://team",
+		},
+		{
+			req:    Request{Query: url.Values{"env": {"stag"}}},
+			target: "https://prod/home",
+		},
+	}
+	for _, c := range cases {
+		target, err := ln.Resolve(c.req)
+		require.NoError(t, err)
+		require.Equal(t, c.target, target)
+	}
+
+	// without the request
+	target, err := ln.GetRedirectLink("x")
+	require.NoError(t, err)
+	require.Equal(t, "https://prod/x", target)
+
+	payload, err := ln.Payload()
+	require.NoError(t, err)
+	require.Equal(t, `header:User-Agent~windows https://vpn/windows
+query:env=staging https://staging/{page=home}
+domain:corp.com ip:10.0.0.0/8 https://corp/{page=home}
+header:X-Team=golinks%20team https://team
+* https://prod/{page=home}`, payload)
+}
+
+func TestV5Invalid(t *testing.T) {
+	invalids := []string{
+		"",
+		"query:env=staging https://staging",
+		"* https://prod\nquery:env=staging https://staging",
+		"https://prod",
+		"query:env https://staging\n* https://prod",
+		"ip:10.0.0.0 https://corp\n* https://prod",
+		"os:windows https://vpn\n* https://prod",
+		"* https://prod/{x=1}/{x=2}",
+	}
+	for _, payload := range invalids {
+		_, err := New(5, payload)
+		require.True(t, errors.Is(err, ErrInvalidPayload), payload)
+	}
+}
@@ -0,0 +1,86 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AliasVersion is the version of the alias links, whose payload is the key of
+// the canonical link in the same org.
+const AliasVersion = 6
+
+// MaxAliasDepth is the maximum number of aliases Canonical follows.
+const MaxAliasDepth = 8
+
+// Alias returns an alias link of the canonical key.
+func Alias(canonical string) Link {
+	return Link{
+		Version: AliasVersion,
+		Blob:    []byte(strings.Trim(canonical, "/")),
+	}
+}
+
+// AliasOf returns the canonical key of l, and false if l is not an alias.
+func (l *Link) AliasOf() (string, bool) {
+	if l.Version != AliasVersion {
+		return "", false
+	}
+	return string(l.Blob), true
+}
+
+// Canonical follows the aliases from the link ln of key in org, and returns
+// the key and link of the canonical link, which is ln itself if it's not an
+// alias. ErrAliasLoop is returned if the aliases form a loop or are deeper
+// than MaxAliasDepth. ErrNotFound is returned with the key of the missing
+// link if an alias refers to a link not in store.
+func Canonical(ctx context.Context, store Store, org string, key string,
+	ln Link) (string, Link, error) {
+	visited := map[string]struct{}{key: {}}
+	for depth := 0; ; depth++ {
+		canonical, ok := ln.AliasOf()
+		if !ok {
+			return key, ln, nil
+		}
+		if _, ok := visited[canonical]; ok || depth >= MaxAliasDepth {
+			return key, Link{}, fmt.Errorf(
+				"alias %s of %s: %w", key, canonical, ErrAliasLoop)
+		}
+		visited[canonical] = struct{}{}
+		next, err := store.GetLink(ctx, org, canonical)
+		if errors.Is(err, ErrNotFound) {
+			return canonical, Link{}, fmt.Errorf(
+				"canonical link %s of %s: %w", canonical, key, ErrNotFound)
+		}
+		if err != nil {
+			return key, Link{}, err
+		}
+		key, ln = canonical, next
+	}
+}
+
+type v6 struct{}
+
+// New returns the blob of the alias of the canonical key payload.
+func (v *v6) New(payload string) ([]byte, error) {
+	canonical := strings.Trim(strings.TrimSpace(payload), "/")
+	if !ValidKey(canonical) {
+		return nil, fmt.Errorf(
+			"invalid canonical key \"%s\": %w", payload, ErrInvalidPayload)
+	}
+	return []byte(canonical), nil
+}
+
+// Resolve returns ErrAlias since an alias is resolved by its canonical link.
+func (v *v6) Resolve(blob []byte, param string) (string, error) {
+	return "", fmt.Errorf("alias of %s: %w", blob, ErrAlias)
+}
+
+func (v *v6) Describe(blob []byte) (string, error) {
+	return fmt.Sprintf("alias|%s", blob), nil
+}
+
+func (v *v6) Payload(blob []byte) (string, error) {
+	return string(blob), nil
+}
@@ -0,0 +1,32 @@
+package link
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestV6(t *testing.T) {
+	ln, err := New(AliasVersion, " /team/oncall/ ")
+	require.NoError(t, err)
+	require.Equal(t, Alias("team/oncall"), *ln)
+	canonical, ok := ln.AliasOf()
+	require.True(t, ok)
+	require.Equal(t, "team/oncall", canonical)
+	payload, err := ln.Payload()
+	require.NoError(t, err)
+	require.Equal(t, "team/oncall", payload)
+	desc, err := ln.Description()
+	require.NoError(t, err)
+	require.Equal(t, "v6|alias|team/oncall", desc)
+	_, err = ln.GetRedirectLink("")
+	require.True(t, errors.Is(err, ErrAlias))
+
+	_, ok = (&Link{Version: 0}).AliasOf()
+	require.False(t, ok)
+	for _, payload := range []string{"", "a//b"} {
+		_, err = New(AliasVersion, payload)
+		require.True(t, errors.Is(err, ErrInvalidPayload), payload)
+	}
+}
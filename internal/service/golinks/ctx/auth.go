@@ -3,6 +3,7 @@ package ctx
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -34,14 +35,81 @@ func AuthRequired(onError func(*gin.Context, error)) gin.HandlerFunc {
 }
 
 // AuthSimple401 returns the auth required middleware based on the
-// "GOLINKS_TOKEN" cookie and returns 401 if the user is unauthorized.
-var AuthSimple401 = AuthRequired(func(ctx *gin.Context, err error) {
+// "GOLINKS_TOKEN" cookie or the personal API token in the
+// "Authorization: Bearer" header, and returns 401 if the user is
+// unauthorized.
+func AuthSimple401(ctx *gin.Context) {
+	err := authBearer(ctx)
+	if err != nil {
+		middlewares.GetLogger(ctx).Error(
+			"failed to verify api token. err: %v", err)
+		onAuthError401(ctx, err)
+		return
+	}
+	authRequired401(ctx)
+}
+
+var authRequired401 = AuthRequired(onAuthError401)
+
+func onAuthError401(ctx *gin.Context, err error) {
 	if errors.Is(err, ErrNotFound) {
 		ctx.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
 	ctx.AbortWithStatus(http.StatusInternalServerError)
-})
+}
+
+// authBearer authenticates the request user with the personal API token in
+// the "Authorization: Bearer" header if there's one.
+func authBearer(ctx *gin.Context) error {
+	header := ctx.GetHeader("Authorization")
+	if len(header) == 0 {
+		return nil
+	}
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) ||
+		!strings.EqualFold(header[:len(prefix)], prefix) {
+		return ErrNotFound
+	}
+	manager, ok := GetAuthManager(ctx)
+	if !ok {
+		return ErrNotFound
+	}
+	token, err := manager.VerifyAPIToken(
+		ctx.Request.Context(), strings.TrimSpace(header[len(prefix):]))
+	if errors.Is(err, auth.ErrInvalidToken) ||
+		errors.Is(err, auth.ErrTokenExpired) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return ErrInternal
+	}
+	user, err := manager.GetUser(ctx.Request.Context(), token.Email)
+	if errors.Is(err, auth.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return ErrInternal
+	}
+	// the token is no longer valid once the user left its org
+	if !user.IsMember(token.Org) {
+		return ErrNotFound
+	}
+	ctx.Set(userKey, user)
+	ctx.Set(apiTokenKey, token)
+	return nil
+}
+
+// GetAPIToken returns the personal API token authenticating the request, and
+// false if the request is not authenticated with one.
+func GetAPIToken(ctx *gin.Context) (token auth.APIToken, ok bool) {
+	val, ok := ctx.Get(apiTokenKey)
+	if !ok {
+		return
+	}
+	token = val.(auth.APIToken)
+	return
+}
 
 // OrgRequired returns the org required middleware based on the
 // "GOLINKS_TOKEN" cookie.
@@ -93,7 +93,23 @@ func GetUser(ctx *gin.Context) (user auth.User, err error) {
 	return
 }
 
-// GetOrg returns the org of the request.
+// GetUserEmail returns the email of the request user, or an empty string if
+// auth is disabled or the user is not found.
+func GetUserEmail(ctx *gin.Context) string {
+	if !IsAuthEnabled(ctx) {
+		return ""
+	}
+	user, err := GetUser(ctx)
+	if err != nil {
+		return ""
+	}
+	return user.Email
+}
+
+// GetOrg returns the org of the request, which is the org of the personal API
+// token, or the active org of the user if the user is a member of it, or the
+// default org of the user otherwise. ErrNotFound is returned if the user is no
+// longer a member of the org of the token.
 func GetOrg(ctx *gin.Context) (org auth.Organization, err error) {
 	// get cached value
 	val, ok := ctx.Get(orgKey)
@@ -107,7 +123,20 @@ func GetOrg(ctx *gin.Context) (org auth.Organization, err error) {
 		logger.Error("failed to get user. err: %v", err)
 		return
 	}
-	if user.Organization == "" {
+	name := user.DefaultOrg()
+	if active := GetActiveOrg(ctx); user.IsMember(active) {
+		name = active
+	}
+	if token, ok := GetAPIToken(ctx); ok {
+		if !user.IsMember(token.Org) {
+			logger.Error("%s is not a member of token org %s",
+				user.Email, token.Org)
+			err = ErrNotFound
+			return
+		}
+		name = token.Org
+	}
+	if name == "" {
 		err = ErrNotFound
 		return
 	}
@@ -117,7 +146,7 @@ func GetOrg(ctx *gin.Context) (org auth.Organization, err error) {
 		err = ErrNotFound
 		return
 	}
-	org, err = manager.GetOrg(ctx.Request.Context(), user.Organization)
+	org, err = manager.GetOrg(ctx.Request.Context(), name)
 	if err != nil {
 		logger.Error("failed to get org. err: %v", err)
 		return
@@ -4,6 +4,7 @@ import "errors"
 
 // Exported errors.
 var (
-	ErrNotFound = errors.New("not found")
-	ErrInternal = errors.New("internal")
+	ErrNotFound  = errors.New("not found")
+	ErrInternal  = errors.New("internal")
+	ErrForbidden = errors.New("forbidden")
 )
@@ -4,11 +4,14 @@ package ctx
 const (
 	ctxKey = "golinks.middlewares.ctx"
 
-	userKey = "golinks.middlewares.user"
-	orgKey  = "golinks.middlewares.org"
+	userKey     = "golinks.middlewares.user"
+	orgKey      = "golinks.middlewares.org"
+	apiTokenKey = "golinks.middlewares.api_token"
 )
 
 // keys for values to save in cookies.
 const (
-	tokenCookieKey = "GOLINKS_TOKEN"
+	tokenCookieKey     = "GOLINKS_TOKEN"
+	activeOrgCookieKey = "GOLINKS_ORG"
+	ssoCookieKey       = "GOLINKS_SSO"
 )
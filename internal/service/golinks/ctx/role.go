@@ -0,0 +1,88 @@
+package ctx
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/haostudio/golinks/internal/api/middlewares"
+	"github.com/haostudio/golinks/internal/auth"
+)
+
+// GetRole returns the role of the request user in the request org, limited
+// by the scopes of the personal API token if the request is authenticated
+// with one. Every request is granted the admin role if auth is disabled.
+func GetRole(ctx *gin.Context) (role auth.Role, err error) {
+	if !IsAuthEnabled(ctx) {
+		role = auth.RoleAdmin
+		return
+	}
+	user, err := GetUser(ctx)
+	if err != nil {
+		return
+	}
+	org, err := GetOrg(ctx)
+	if err != nil {
+		return
+	}
+	role = org.Role(user.Email)
+	if token, ok := GetAPIToken(ctx); ok && role.Allows(token.Role()) {
+		role = token.Role()
+	}
+	return
+}
+
+// RoleRequired returns the middleware requiring the request user to hold a
+// role allowing the required one in the request org.
+func RoleRequired(
+	required auth.Role, onError func(*gin.Context, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		logger := middlewares.GetLogger(ctx)
+		role, err := GetRole(ctx)
+		if err != nil {
+			logger.Error("failed to get role. err: %v", err)
+			onError(ctx, err)
+			return
+		}
+		if !role.Allows(required) {
+			logger.Warn("role %s is not allowed. required: %s", role, required)
+			onError(ctx, ErrForbidden)
+			return
+		}
+	}
+}
+
+// RoleSimple403 returns the role required middleware which returns 403 if
+// the user is not allowed.
+func RoleSimple403(required auth.Role) gin.HandlerFunc {
+	return RoleRequired(required, func(ctx *gin.Context, err error) {
+		switch {
+		case errors.Is(err, ErrForbidden):
+			ctx.AbortWithStatus(http.StatusForbidden)
+		case errors.Is(err, ErrNotFound):
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+		default:
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+		}
+	})
+}
+
+// IsAdmin returns if the request user is an admin of the request org.
+func IsAdmin(ctx *gin.Context) bool {
+	role, err := GetRole(ctx)
+	return err == nil && role.Allows(auth.RoleAdmin)
+}
+
+// ScopeSimple403 returns the middleware which returns 403 if the request is
+// authenticated with a personal API token without the required scope.
+func ScopeSimple403(required auth.Scope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := GetAPIToken(ctx)
+		if ok && !token.Allows(required) {
+			middlewares.GetLogger(ctx).Warn(
+				"api token %s is not allowed. required: %s", token.ID, required)
+			ctx.AbortWithStatus(http.StatusForbidden)
+		}
+	}
+}
@@ -18,3 +18,39 @@ func SetToken(ctx *gin.Context, token string, maxAge int) {
 func DeleteToken(ctx *gin.Context) {
 	ctx.SetCookie(tokenCookieKey, "", 0, "", "", false, false)
 }
+
+// GetActiveOrg returns the active org cookie, or an empty string if it is not
+// set.
+func GetActiveOrg(ctx *gin.Context) string {
+	org, err := ctx.Cookie(activeOrgCookieKey)
+	if err != nil {
+		return ""
+	}
+	return org
+}
+
+// SetActiveOrg sets the active org cookie.
+func SetActiveOrg(ctx *gin.Context, org string) {
+	ctx.SetCookie(activeOrgCookieKey, org, 0, "", "", false, false)
+}
+
+// DeleteActiveOrg deletes the active org cookie.
+func DeleteActiveOrg(ctx *gin.Context) {
+	ctx.SetCookie(activeOrgCookieKey, "", -1, "", "", false, false)
+}
+
+// GetSSOState returns the single sign-on state cookie.
+func GetSSOState(ctx *gin.Context) (state string, err error) {
+	return ctx.Cookie(ssoCookieKey)
+}
+
+// SetSSOState sets the single sign-on state cookie, which is not accessible
+// from scripts.
+func SetSSOState(ctx *gin.Context, state string, maxAge int) {
+	ctx.SetCookie(ssoCookieKey, state, maxAge, "", "", false, true)
+}
+
+// DeleteSSOState deletes the single sign-on state cookie.
+func DeleteSSOState(ctx *gin.Context) {
+	ctx.SetCookie(ssoCookieKey, "", -1, "", "", false, true)
+}
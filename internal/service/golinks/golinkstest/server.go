@@ -5,11 +5,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	analyticskv "github.com/haostudio/golinks/internal/analytics/kv"
 	"github.com/haostudio/golinks/internal/auth"
 	authkv "github.com/haostudio/golinks/internal/auth/kv"
 	"github.com/haostudio/golinks/internal/encoding/gob"
 	"github.com/haostudio/golinks/internal/kv/memory"
+	"github.com/haostudio/golinks/internal/link/history"
 	lnkv "github.com/haostudio/golinks/internal/link/kv"
+	"github.com/haostudio/golinks/internal/link/pattern"
+	patternkv "github.com/haostudio/golinks/internal/link/pattern/kv"
+	sharekv "github.com/haostudio/golinks/internal/link/share/kv"
+	"github.com/haostudio/golinks/internal/link/suggest"
 	"github.com/haostudio/golinks/internal/service"
 	"github.com/haostudio/golinks/internal/service/golinks"
 )
@@ -19,14 +25,22 @@ func NewTestServer() service.Service {
 	store := memory.New()
 	enc := gob.New()
 
-	lnStore := lnkv.New(store.In("link"), enc)
+	index := suggest.New(lnkv.NewIn(store.In(), "link", enc))
+	lnStore := history.New(index, store.In(), "history", enc)
 	authProvider := authkv.New(store.In("auth"), enc)
 
 	conf := golinks.Config{
-		Gin:       gin.Default(),
-		Address:   "0.0.0.0:8000",
-		Traced:    false,
-		LinkStore: lnStore,
+		Gin:         gin.Default(),
+		Address:     "0.0.0.0:8000",
+		Traced:      false,
+		LinkStore:   lnStore,
+		LinkHistory: lnStore,
+		Analytics:   analyticskv.New(store.In("analytics"), enc),
+		Patterns: pattern.NewRouter(
+			patternkv.New(store.In("pattern"), enc)),
+		Shares:       sharekv.New(store.In("share"), enc),
+		FallbackOrgs: []string{"global"},
+		Suggestions:  index,
 	}
 	conf.Auth.Enabled = true
 	conf.Auth.DefaultOrg = ""
@@ -34,6 +48,7 @@ func NewTestServer() service.Service {
 		Provider:         authProvider,
 		TokenExpieration: 1 * 24 * time.Hour,
 		TokenSecret:      []byte("token_secret"),
+		ReservedOrgs:     conf.FallbackOrgs,
 	})
 
 	gin.Default()
@@ -2,29 +2,59 @@ package authapi
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/haostudio/golinks/internal/api/middlewares"
 	"github.com/haostudio/golinks/internal/auth"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
 )
 
 // Register registers auth endpoints in router.
 func Register(router gin.IRouter, manager *auth.Manager) {
 	module := New(manager)
+	admin := []gin.HandlerFunc{
+		module.OrgParamRequired,
+		ctx.RoleSimple403(auth.RoleAdmin),
+	}
 	router.GET(
 		fmt.Sprintf("/:%s", module.PathParamOrgKey()),
 		module.GetOrg,
 	)
 	router.POST(
 		fmt.Sprintf("/:%s", module.PathParamOrgKey()),
+		ctx.ScopeSimple403(auth.ScopeAdmin),
 		module.SetOrg,
 	)
 	router.POST(
 		fmt.Sprintf("/:%s/user", module.PathParamOrgKey()),
-		module.SetOrgUser,
+		append(admin, module.SetOrgUser)...,
 	)
 	router.GET(
 		fmt.Sprintf("/:%s/users", module.PathParamOrgKey()),
 		module.GetOrgUsers,
 	)
+	router.PUT(
+		fmt.Sprintf("/:%s/users/:%s/role",
+			module.PathParamOrgKey(), module.PathParamUserKey()),
+		append(admin, module.SetOrgUserRole)...,
+	)
+}
+
+// OrgParamRequired aborts with 403 if the org path parameter is not the org of
+// the request user.
+func (a *Auth) OrgParamRequired(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	if org.Name != ginctx.Param(a.PathParamOrgKey()) {
+		logger.Error("org %s is not the user org", ginctx.Param(a.PathParamOrgKey()))
+		ginctx.AbortWithStatus(http.StatusForbidden)
+		return
+	}
 }
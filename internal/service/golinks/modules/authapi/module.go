@@ -1,13 +1,16 @@
 package authapi
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/haostudio/golinks/internal/api/middlewares"
 	"github.com/haostudio/golinks/internal/auth"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
 )
 
 // New returns auth api handles.
@@ -32,71 +35,38 @@ func (a *Auth) PathParamOrgKey() string {
 	return "org"
 }
 
-// SetOrg sets org.
-func (a *Auth) SetOrg(ctx *gin.Context) {
-	logger := middlewares.GetLogger(ctx)
-	key := ctx.Param(a.PathParamOrgKey())
+// SetOrg creates org with the request user as its admin.
+func (a *Auth) SetOrg(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	key := ginctx.Param(a.PathParamOrgKey())
 	if len(key) == 0 {
 		logger.Error("Auth: SetOrg: Empty key")
-		ctx.String(http.StatusBadRequest, "empty key")
-		return
-	}
-
-	// check if the org exists
-	_, err := a.manager.GetOrg(ctx, key)
-	if !errors.Is(err, auth.ErrNotFound) {
-		logger.Error("org already exists, err")
-		ctx.Status(http.StatusBadRequest)
+		ginctx.String(http.StatusBadRequest, "empty key")
 		return
 	}
-
-	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-	err = ctx.BindJSON(&req)
+	user, err := ctx.GetUser(ginctx)
 	if err != nil {
-		logger.Error("failed to bind json, err: %v", err)
-		ctx.String(http.StatusBadRequest, "parameters error")
+		logger.Error("failed to get user, err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
 		return
 	}
 	org := auth.Organization{
 		Name:       key,
-		AdminEmail: req.Email,
+		AdminEmail: user.Email,
 	}
-	err = a.manager.SetOrg(ctx.Request.Context(), org)
-	if err != nil {
-		logger.Error("failed to set org, err: %v", err)
-		ctx.Status(http.StatusInternalServerError)
+	err = a.manager.RegisterOrg(ginctx.Request.Context(), org)
+	if errors.Is(err, auth.ErrOrgExists) ||
+		errors.Is(err, auth.ErrBadParams) {
+		logger.Error("failed to register org, err: %v", err)
+		ginctx.Status(http.StatusBadRequest)
 		return
 	}
-	user, err := auth.NewUser(req.Email, req.Password, key)
 	if err != nil {
-		logger.Error("failed to set user pwd, err: %v", err)
-		err = a.manager.DeleteOrg(ctx.Request.Context(), key)
-		if err != nil {
-			logger.Error("failed to del org, err: %v", err)
-		}
-		ctx.Status(http.StatusInternalServerError)
-		return
-	}
-
-	err = a.manager.SetUser(ctx.Request.Context(), *user)
-	if err != nil {
-		logger.Error("failed to set user, err: %v", err)
-		err = a.manager.DeleteOrg(ctx.Request.Context(), key)
-		if err != nil {
-			logger.Error("failed to del org, err: %v", err)
-		}
-		ctx.Status(http.StatusInternalServerError)
+		logger.Error("failed to register org, err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
 		return
 	}
-
-	res := make(map[string]string)
-	res["Organization"] = org.Name
-	res["AdminEmail"] = org.AdminEmail
-
-	ctx.JSON(http.StatusOK, org)
+	ginctx.JSON(http.StatusOK, org)
 }
 
 // GetOrg returns org.
@@ -156,7 +126,7 @@ func (a *Auth) GetUser(ctx *gin.Context) {
 	}
 	res := make(map[string]string)
 	res["Email"] = user.Email
-	res["Organization"] = user.Organization
+	res["Organization"] = user.DefaultOrg()
 
 	ctx.JSON(http.StatusOK, res)
 }
@@ -199,23 +169,89 @@ func (a *Auth) SetOrgUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := auth.NewUser(req.Email, req.Password, key)
-	if err != nil {
-		logger.Error("failed to init user, err: %v", err)
-		ctx.Status(http.StatusInternalServerError)
+	// the password only applies to a new user. An existing user is only
+	// added to the org.
+	err = a.registerUser(ctx.Request.Context(), req.Email, req.Password, key)
+	if errors.Is(err, auth.ErrUserExists) {
+		err = a.manager.AddUserOrg(ctx.Request.Context(), req.Email, key)
+	}
+	if errors.Is(err, auth.ErrBadParams) || errors.Is(err, auth.ErrNotFound) {
+		logger.Error("failed to set user org, err: %v", err)
+		ctx.String(http.StatusBadRequest, "parameters error")
 		return
 	}
-
-	err = a.manager.SetUser(ctx.Request.Context(), *user)
 	if err != nil {
-		logger.Error("failed to set user, err: %v", err)
+		logger.Error("failed to set user org, err: %v", err)
 		ctx.Status(http.StatusInternalServerError)
 		return
 	}
 
 	res := make(map[string]string)
-	res["organization"] = user.Organization
-	res["email"] = user.Email
+	res["organization"] = key
+	res["email"] = req.Email
 
 	ctx.JSON(http.StatusOK, res)
 }
+
+// registerUser creates the user in org, or returns auth.ErrUserExists if the
+// user exists.
+func (a *Auth) registerUser(reqCtx context.Context, email, password,
+	org string) error {
+	exists, err := a.manager.IsUserExists(reqCtx, email)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return auth.ErrUserExists
+	}
+	if len(email) == 0 || len(password) == 0 {
+		return fmt.Errorf("empty email or password. %w", auth.ErrBadParams)
+	}
+	user, err := auth.NewUser(email, password, org)
+	if err != nil {
+		return err
+	}
+	return a.manager.RegisterUser(reqCtx, *user)
+}
+
+// SetOrgUserRole sets the role of an org user.
+func (a *Auth) SetOrgUserRole(ctx *gin.Context) {
+	logger := middlewares.GetLogger(ctx)
+	org := ctx.Param(a.PathParamOrgKey())
+	email := ctx.Param(a.PathParamUserKey())
+	var req struct {
+		Role string `json:"role"`
+	}
+	err := ctx.BindJSON(&req)
+	if err != nil {
+		logger.Error("failed to bind json, err: %v", err)
+		ctx.String(http.StatusBadRequest, "parameters error")
+		return
+	}
+	role, err := auth.ParseRole(req.Role)
+	if err != nil {
+		logger.Error("failed to parse role, err: %v", err)
+		ctx.String(http.StatusBadRequest, "invalid role")
+		return
+	}
+	err = a.manager.SetUserRole(ctx.Request.Context(), org, email, role)
+	if errors.Is(err, auth.ErrNotFound) {
+		logger.Error("failed to set user role, err: %v", err)
+		ctx.String(http.StatusNotFound, "user not found")
+		return
+	}
+	if errors.Is(err, auth.ErrBadParams) {
+		logger.Error("failed to set user role, err: %v", err)
+		ctx.String(http.StatusBadRequest, "invalid user")
+		return
+	}
+	if err != nil {
+		logger.Error("failed to set user role, err: %v", err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	ctx.JSON(http.StatusOK, map[string]string{
+		"email": email,
+		"role":  string(role),
+	})
+}
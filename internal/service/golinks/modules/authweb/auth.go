@@ -4,10 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/haostudio/golinks/internal/auth"
+	"github.com/haostudio/golinks/internal/auth/oidc"
 	"github.com/haostudio/golinks/internal/service/golinks/ctx"
 	"github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
 )
@@ -23,19 +25,24 @@ const (
 	formInputEmail    = "email"
 	formInputName     = "name"
 	formInputPassword = "password"
+	formInputRole     = "role"
 )
 
 // Web defines the web handler module.
 type Web struct {
 	webbase.Base
-	manager *auth.Manager
+	manager    *auth.Manager
+	pathPrefix string
+	sso        *oidc.Client
 }
 
 // New returns a new web handler module.
 func New(conf Config) *Web {
 	return &Web{
-		Base:    webbase.NewBase(conf.Traced),
-		manager: conf.Manager,
+		Base:       webbase.NewBase(conf.Traced),
+		manager:    conf.Manager,
+		pathPrefix: conf.PathPrefix,
+		sso:        conf.SSO,
 	}
 }
 
@@ -48,7 +55,7 @@ func (w *Web) Login() gin.HandlerFunc {
 			if callback == "" {
 				callback = "/"
 			}
-			return LoginData{
+			data := LoginData{
 				Data:                  webbase.NewData("Golinks - Login", ginctx),
 				FormInputEmail:        formInputEmail,
 				FormInputPassword:     formInputPassword,
@@ -57,7 +64,11 @@ func (w *Web) Login() gin.HandlerFunc {
 				FormLoginBtnAction:    formBtnActionLogin,
 				FormRegisterBtnAction: formBtnActionRegister,
 				Callback:              callback,
-			}, nil
+			}
+			if w.sso != nil {
+				data.SSOPath = w.ssoPath(callback)
+			}
+			return data, nil
 		},
 	)
 }
@@ -151,10 +162,7 @@ func (w *Web) SetOrgUser() gin.HandlerFunc {
 					Log:        fmt.Sprintf("failed to get user, err: %v", err),
 				}
 			}
-			admin := false
-			if org.AdminEmail == user.Email {
-				admin = true
-			}
+			admin := org.Role(user.Email) == auth.RoleAdmin
 
 			users, err := w.manager.GetOrgUsers(ginctx, org.Name)
 			if err != nil {
@@ -166,7 +174,7 @@ func (w *Web) SetOrgUser() gin.HandlerFunc {
 
 			userSlice := make([]User, len(users))
 			for i, d := range users {
-				userSlice[i] = User{Email: d}
+				userSlice[i] = User{Email: d, Role: org.Role(d)}
 			}
 
 			return PageData{
@@ -175,7 +183,9 @@ func (w *Web) SetOrgUser() gin.HandlerFunc {
 				Users:          userSlice,
 				Admin:          admin,
 				FormInputEmail: formInputEmail,
+				FormInputRole:  formInputRole,
 				FormBtnAction:  formBtnActionSave,
+				Roles:          auth.Roles,
 			}, nil
 		},
 	)
@@ -192,9 +202,9 @@ func (w *Web) HandleSetOrgUserForm(ginctx *gin.Context) {
 		})
 		return
 	}
-	err = w.manager.SetUserOrg(ginctx, email, org.Name)
+	err = w.manager.AddUserOrg(ginctx, email, org.Name)
 	if err == nil {
-		ginctx.Redirect(http.StatusMovedPermanently, "/org/manage")
+		ginctx.Redirect(http.StatusMovedPermanently, w.managePath())
 		return
 	}
 	if errors.Is(err, auth.ErrNotFound) {
@@ -210,6 +220,74 @@ func (w *Web) HandleSetOrgUserForm(ginctx *gin.Context) {
 	})
 }
 
+// HandleSetOrgUserRoleForm handle request to set the role of an org user.
+func (w *Web) HandleSetOrgUserRoleForm(ginctx *gin.Context) {
+	email := ginctx.PostForm(formInputEmail)
+	role, err := auth.ParseRole(ginctx.PostForm(formInputRole))
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusBadRequest,
+			Messages:   []string{"Invalid role"},
+			Log:        fmt.Sprintf("failed to parse role; err: %v", err),
+		})
+		return
+	}
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("failed to get org, err: %v", err),
+		})
+		return
+	}
+	err = w.manager.SetUserRole(
+		ginctx.Request.Context(), org.Name, email, role)
+	if err == nil {
+		ginctx.Redirect(http.StatusMovedPermanently, w.managePath())
+		return
+	}
+	if errors.Is(err, auth.ErrNotFound) || errors.Is(err, auth.ErrBadParams) {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusBadRequest,
+			Messages:   []string{"Role of the user can not be changed"},
+			Log:        fmt.Sprintf("failed to set user role; err: %v", err),
+		})
+		return
+	}
+	w.ServeErr(ginctx, &webbase.Error{
+		StatusCode: http.StatusInternalServerError,
+		Log:        fmt.Sprintf("failed to set user role; err: %v", err),
+	})
+}
+
+// HandleSwitchOrgForm handles request to switch the active org of the user.
+func (w *Web) HandleSwitchOrgForm(ginctx *gin.Context) {
+	name := ginctx.PostForm(formInputName)
+	user, err := ctx.GetUser(ginctx)
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("failed to get user, err: %v", err),
+		})
+		return
+	}
+	if !user.IsMember(name) {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusForbidden,
+			Messages:   []string{"Not a member of the org"},
+			Log:        fmt.Sprintf("%s is not in org %s", user.Email, name),
+		})
+		return
+	}
+	ctx.SetActiveOrg(ginctx, name)
+	ginctx.Redirect(http.StatusMovedPermanently, "/links")
+}
+
+// managePath returns the path of the org management page.
+func (w *Web) managePath() string {
+	return fmt.Sprintf("/%s/org/manage", strings.Trim(w.pathPrefix, "/"))
+}
+
 // OrgRegister sets org.
 func (w *Web) OrgRegister() gin.HandlerFunc {
 	return w.Handler(
@@ -242,6 +320,7 @@ func (w *Web) HandleOrgRegisterForm(ginctx *gin.Context) {
 	}
 	err = w.manager.RegisterOrg(ginctx.Request.Context(), org)
 	if err == nil {
+		ctx.SetActiveOrg(ginctx, org.Name)
 		ginctx.Redirect(http.StatusMovedPermanently, "/")
 		return
 	}
@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/haostudio/golinks/internal/auth"
+	"github.com/haostudio/golinks/internal/auth/oidc"
 	"github.com/haostudio/golinks/internal/service/golinks/ctx"
 	"github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
 )
@@ -50,6 +51,8 @@ type Config struct {
 	Traced     bool
 	Manager    *auth.Manager
 	PathPrefix string
+	// SSO enables the single sign-on alongside the password login.
+	SSO *oidc.Client // optional
 }
 
 //Register register auth web in router
@@ -73,6 +76,10 @@ func Register(router gin.IRouter, conf Config) {
 		web.Login()(ginctx)
 	})
 	router.POST("login", web.HandleLoginForm)
+	if conf.SSO != nil {
+		router.GET("sso", web.HandleSSO)
+		router.GET("sso/callback", web.HandleSSOCallback)
+	}
 	router.GET("logout", func(ginctx *gin.Context) {
 		token, err := ctx.GetToken(ginctx)
 		if err != nil {
@@ -92,35 +99,30 @@ func Register(router gin.IRouter, conf Config) {
 		}
 		// remove token
 		ctx.DeleteToken(ginctx)
+		ctx.DeleteActiveOrg(ginctx)
 		ginctx.Redirect(http.StatusMovedPermanently, "/")
 	})
 
 	{
 		orgRouter := router.Group("org")
 		orgRouter.Use(AuthRequired(conf.PathPrefix))
-		orgRouter.GET("register", func(ginctx *gin.Context) {
-			_, err := ctx.GetOrg(ginctx)
-			if err == nil {
-				// already in an org
-				ginctx.Redirect(
-					http.StatusMovedPermanently,
-					fmt.Sprintf("/%s/org/manage", conf.PathPrefix),
-				)
-				return
-			}
-			if !errors.Is(err, ctx.ErrNotFound) {
-				web.ServeErr(ginctx, &webbase.Error{
-					StatusCode: http.StatusInternalServerError,
-					Log:        fmt.Sprintf("failed to get org. %v", err),
-				})
-				return
-			}
-			web.OrgRegister()(ginctx)
-		})
+		orgRouter.GET("register", web.OrgRegister())
 		orgRouter.POST("register", web.HandleOrgRegisterForm)
+		orgRouter.POST("switch", web.HandleSwitchOrgForm)
 
 		orgRouter.Use(OrgRequired(conf.PathPrefix))
+		admin := web.RoleRequired(auth.RoleAdmin)
 		orgRouter.GET("manage", web.SetOrgUser())
-		orgRouter.POST("manage", web.HandleSetOrgUserForm)
+		orgRouter.POST("manage", admin, web.HandleSetOrgUserForm)
+		orgRouter.POST("manage/role", admin, web.HandleSetOrgUserRoleForm)
+	}
+
+	{
+		tokensRouter := router.Group("tokens")
+		tokensRouter.Use(
+			AuthRequired(conf.PathPrefix), OrgRequired(conf.PathPrefix))
+		tokensRouter.GET("", web.APITokens())
+		tokensRouter.POST("", web.HandleCreateAPITokenForm())
+		tokensRouter.POST("revoke", web.HandleRevokeAPITokenForm)
 	}
 }
@@ -1,10 +1,16 @@
 package authweb
 
-import "github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
+import (
+	"time"
+
+	"github.com/haostudio/golinks/internal/auth"
+	"github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
+)
 
 // User defines a user data for template
 type User struct {
 	Email string
+	Role  auth.Role
 }
 
 // PageData defines the data for links.html template.
@@ -14,8 +20,10 @@ type PageData struct {
 	FormInputEmail string
 	FormBtnAction  string
 	FormInputName  string
+	FormInputRole  string
 
 	Users []User
+	Roles []auth.Role
 	Admin bool
 }
 
@@ -31,4 +39,34 @@ type LoginData struct {
 	FormRegisterBtnAction string
 
 	Callback string
+	// SSOPath is the path starting the single sign-on, or empty if it's
+	// disabled.
+	SSOPath string
+}
+
+// APIToken defines a personal API token data for template.
+type APIToken struct {
+	ID         string
+	Name       string
+	Org        string
+	Scopes     []auth.Scope
+	CreatedAt  time.Time
+	ExpiredAt  time.Time
+	LastUsedAt time.Time
+	Expired    bool
+}
+
+// TokensPageData defines the data for tokens.html template.
+type TokensPageData struct {
+	webbase.Data
+
+	FormInputName      string
+	FormInputScope     string
+	FormInputExpiresAt string
+	FormInputTokenID   string
+
+	Scopes []auth.Scope
+	Tokens []APIToken
+	// NewToken is the token string just created, which is only shown once.
+	NewToken string
 }
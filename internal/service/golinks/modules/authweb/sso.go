@@ -0,0 +1,149 @@
+package authweb
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/haostudio/golinks/internal/auth/oidc"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
+	"github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
+)
+
+// ssoStateMaxAge is the max age in seconds of the single sign-on state, which
+// is the time a user has to log in at the provider.
+const ssoStateMaxAge = 600
+
+const (
+	ssoParamCode  = "code"
+	ssoParamError = "error"
+	ssoParamState = "state"
+	ssoParamNonce = "nonce"
+)
+
+// HandleSSO starts the single sign-on by redirecting to the provider. The
+// state, nonce and callback are kept in a cookie until the provider redirects
+// back.
+func (w *Web) HandleSSO(ginctx *gin.Context) {
+	var nonce, redirect string
+	state, err := randomString()
+	if err == nil {
+		nonce, err = randomString()
+	}
+	if err == nil {
+		redirect, err = w.sso.AuthCodeURL(ginctx.Request.Context(), state, nonce)
+	}
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Messages:   []string{"Single sign-on unavailable"},
+			Log:        fmt.Sprintf("failed to start single sign-on. %v", err),
+		})
+		return
+	}
+	cookie := url.Values{
+		ssoParamState:     {state},
+		ssoParamNonce:     {nonce},
+		formInputCallback: {ginctx.Query(formInputCallback)},
+	}
+	ctx.SetSSOState(ginctx, cookie.Encode(), ssoStateMaxAge)
+	ginctx.Redirect(http.StatusFound, redirect)
+}
+
+// HandleSSOCallback verifies the authorization of the provider, logs in the
+// user and redirects to the callback of the login.
+func (w *Web) HandleSSOCallback(ginctx *gin.Context) {
+	cookie, err := ctx.GetSSOState(ginctx)
+	ctx.DeleteSSOState(ginctx)
+	var saved url.Values
+	if err == nil {
+		saved, err = url.ParseQuery(cookie)
+	}
+	state := ginctx.Query(ssoParamState)
+	if err != nil || len(state) == 0 || saved.Get(ssoParamState) != state {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusBadRequest,
+			Messages:   []string{"Invalid single sign-on state"},
+			Log:        fmt.Sprintf("invalid single sign-on state. %v", err),
+		})
+		return
+	}
+	if e := ginctx.Query(ssoParamError); len(e) != 0 {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusUnauthorized,
+			Messages:   []string{"Single sign-on failed"},
+			Log:        fmt.Sprintf("single sign-on denied. %s", e),
+		})
+		return
+	}
+
+	identity, err := w.sso.Exchange(ginctx.Request.Context(),
+		ginctx.Query(ssoParamCode), saved.Get(ssoParamNonce))
+	if errors.Is(err, oidc.ErrDomainNotAllowed) {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusForbidden,
+			Messages:   []string{"Email domain not allowed"},
+			Log:        fmt.Sprintf("single sign-on rejected. %v", err),
+		})
+		return
+	}
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusUnauthorized,
+			Messages:   []string{"Single sign-on failed"},
+			Log:        fmt.Sprintf("single sign-on failed. %v", err),
+		})
+		return
+	}
+
+	token, err := w.manager.LoginSSO(
+		ginctx.Request.Context(), identity.Email, identity.Org)
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("login failed. %v", err),
+		})
+		return
+	}
+	// authorized
+	ctx.SetToken(
+		ginctx, token.JWT, int(w.manager.TokenExpieration.Seconds()))
+	if len(identity.Org) != 0 {
+		ctx.SetActiveOrg(ginctx, identity.Org)
+	}
+	ginctx.Redirect(http.StatusFound,
+		localCallback(saved.Get(formInputCallback)))
+}
+
+// ssoPath returns the path starting the single sign-on with callback.
+func (w *Web) ssoPath(callback string) string {
+	return fmt.Sprintf("/%s/sso?%s", strings.Trim(w.pathPrefix, "/"),
+		url.Values{formInputCallback: {callback}}.Encode())
+}
+
+// localCallback returns callback if it's a path of this site, or "/"
+// otherwise, so that the login can't redirect to another site.
+func localCallback(callback string) string {
+	if !strings.HasPrefix(callback, "/") ||
+		strings.HasPrefix(callback, "//") ||
+		strings.HasPrefix(callback, "/\\") {
+		return "/"
+	}
+	return callback
+}
+
+// randomString returns a random URL-safe string.
+func randomString() (string, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,196 @@
+package authweb
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/haostudio/golinks/internal/auth"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
+	"github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
+)
+
+const (
+	formInputTokenID   = "id"
+	formInputScope     = "scope"
+	formInputExpiresAt = "expires_at"
+
+	// formExpiresAtLayout is the value format of the date input.
+	formExpiresAtLayout = "2006-01-02"
+)
+
+// APITokens returns the page listing and creating the personal API tokens of
+// the request user.
+func (w *Web) APITokens() gin.HandlerFunc {
+	return w.Handler(
+		"tokens.html.tmpl",
+		func(ginctx *gin.Context) (interface{}, *webbase.Error) {
+			return w.apiTokensData(ginctx)
+		},
+	)
+}
+
+// HandleCreateAPITokenForm handles request to create a personal API token
+// acting in the request org, and shows the token once.
+func (w *Web) HandleCreateAPITokenForm() gin.HandlerFunc {
+	return w.Handler(
+		"tokens.html.tmpl",
+		func(ginctx *gin.Context) (interface{}, *webbase.Error) {
+			var scopes []auth.Scope
+			for _, s := range ginctx.PostFormArray(formInputScope) {
+				scope, err := auth.ParseScope(s)
+				if err != nil {
+					return nil, &webbase.Error{
+						StatusCode: http.StatusBadRequest,
+						Messages:   []string{"Invalid scope"},
+						Log: fmt.Sprintf(
+							"failed to parse scope; err: %v", err),
+					}
+				}
+				scopes = append(scopes, scope)
+			}
+			name := strings.TrimSpace(ginctx.PostForm(formInputName))
+			if len(name) == 0 || len(scopes) == 0 {
+				return nil, &webbase.Error{
+					StatusCode: http.StatusBadRequest,
+					Messages:   []string{"Name and scopes are required"},
+					Log:        "empty token name or scopes",
+				}
+			}
+			var expiredAt time.Time
+			expiresAt := ginctx.PostForm(formInputExpiresAt)
+			if len(expiresAt) != 0 {
+				var err error
+				expiredAt, err = time.ParseInLocation(
+					formExpiresAtLayout, expiresAt, time.Local)
+				if err != nil || !expiredAt.After(time.Now()) {
+					return nil, &webbase.Error{
+						StatusCode: http.StatusBadRequest,
+						Messages:   []string{"Invalid expiry date"},
+						Log: fmt.Sprintf(
+							"invalid expiry date %s; err: %v", expiresAt, err),
+					}
+				}
+			}
+
+			user, err := ctx.GetUser(ginctx)
+			if err != nil {
+				return nil, &webbase.Error{
+					StatusCode: http.StatusInternalServerError,
+					Log:        fmt.Sprintf("failed to get user, err: %v", err),
+				}
+			}
+			org, err := ctx.GetOrg(ginctx)
+			if err != nil {
+				return nil, &webbase.Error{
+					StatusCode: http.StatusInternalServerError,
+					Log:        fmt.Sprintf("failed to get org, err: %v", err),
+				}
+			}
+			_, tokenStr, err := w.manager.CreateAPIToken(
+				ginctx.Request.Context(), user.Email, org.Name, name, scopes,
+				expiredAt)
+			if err != nil {
+				return nil, &webbase.Error{
+					StatusCode: http.StatusInternalServerError,
+					Log: fmt.Sprintf(
+						"failed to create api token, err: %v", err),
+				}
+			}
+
+			data, e := w.apiTokensData(ginctx)
+			if e != nil {
+				return nil, e
+			}
+			data.NewToken = tokenStr
+			return data, nil
+		},
+	)
+}
+
+// HandleRevokeAPITokenForm handles request to revoke a personal API token of
+// the request user.
+func (w *Web) HandleRevokeAPITokenForm(ginctx *gin.Context) {
+	user, err := ctx.GetUser(ginctx)
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("failed to get user, err: %v", err),
+		})
+		return
+	}
+	id := ginctx.PostForm(formInputTokenID)
+	err = w.manager.RevokeAPIToken(ginctx.Request.Context(), user.Email, id)
+	if errors.Is(err, auth.ErrNotFound) || errors.Is(err, auth.ErrBadParams) {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusNotFound,
+			Messages:   []string{"Token not found"},
+			Log:        fmt.Sprintf("failed to revoke api token; err: %v", err),
+		})
+		return
+	}
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("failed to revoke api token; err: %v", err),
+		})
+		return
+	}
+	ginctx.Redirect(http.StatusMovedPermanently, w.tokensPath())
+}
+
+// apiTokensData returns the tokens page data of the request user.
+func (w *Web) apiTokensData(ginctx *gin.Context) (
+	*TokensPageData, *webbase.Error) {
+	user, err := ctx.GetUser(ginctx)
+	if err != nil {
+		return nil, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("failed to get user, err: %v", err),
+		}
+	}
+	tokens, err := w.manager.GetAPITokens(ginctx.Request.Context(), user.Email)
+	if err != nil {
+		return nil, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("failed to get api tokens, err: %v", err),
+		}
+	}
+	// the latest first
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+	})
+	now := time.Now()
+	data := &TokensPageData{
+		Data:               webbase.NewData("Golinks - API Tokens", ginctx),
+		FormInputName:      formInputName,
+		FormInputScope:     formInputScope,
+		FormInputExpiresAt: formInputExpiresAt,
+		FormInputTokenID:   formInputTokenID,
+		Scopes:             auth.Scopes,
+		Tokens:             make([]APIToken, len(tokens)),
+	}
+	for i, t := range tokens {
+		data.Tokens[i] = APIToken{
+			ID:         t.ID,
+			Name:       t.Name,
+			Org:        t.Org,
+			Scopes:     t.Scopes,
+			CreatedAt:  t.CreatedAt,
+			ExpiredAt:  t.ExpiredAt,
+			LastUsedAt: t.LastUsedAt,
+			Expired:    t.IsExpired(now),
+		}
+	}
+	return data, nil
+}
+
+// tokensPath returns the path of the API tokens page.
+func (w *Web) tokensPath() string {
+	return fmt.Sprintf("/%s/tokens", strings.Trim(w.pathPrefix, "/"))
+}
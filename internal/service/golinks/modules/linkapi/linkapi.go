@@ -5,20 +5,80 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/haostudio/golinks/internal/analytics"
+	"github.com/haostudio/golinks/internal/auth"
 	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/history"
+	"github.com/haostudio/golinks/internal/link/share"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
 )
 
+// Config defines the link api config.
+type Config struct {
+	Store     link.Store
+	Analytics analytics.Store // optional
+	History   history.Store   // optional
+	Shares    share.Store     // optional
+	// Manager validates the orgs the links are shared with. nil if auth is
+	// disabled, where the request org is the only org.
+	Manager *auth.Manager
+}
+
 // Register register api in router.
-func Register(router gin.IRouter, lnStore link.Store) {
-	module := New(lnStore)
+func Register(router gin.IRouter, conf Config) {
+	module := New(conf)
+	editor := ctx.RoleSimple403(auth.RoleEditor)
 	router.GET("", module.GetLinks)
-	// Admin functions
+	router.GET("export", module.ExportLinks)
+	router.GET(fmt.Sprintf(":%s", module.PathParamLinkKey()), module.GetLink)
+	if conf.Analytics != nil {
+		router.GET(
+			fmt.Sprintf(":%s/stats", module.PathParamLinkKey()),
+			module.GetLinkStats,
+		)
+	}
+	if conf.History != nil {
+		router.GET(
+			fmt.Sprintf(":%s/revisions", module.PathParamLinkKey()),
+			module.GetLinkRevisions,
+		)
+	}
+	// Editor functions
+	router.POST("import", editor, module.ImportLinks)
 	router.PUT(
 		fmt.Sprintf(":%s", module.PathParamLinkKey()),
+		editor,
 		module.UpdateLink,
 	)
 	router.DELETE(
 		fmt.Sprintf(":%s", module.PathParamLinkKey()),
+		editor,
 		module.DeleteLink,
 	)
+	if conf.Shares != nil {
+		router.GET(
+			fmt.Sprintf(":%s/shares", module.PathParamLinkKey()),
+			module.GetLinkShares,
+		)
+		router.PUT(
+			fmt.Sprintf(":%s/shares/:%s",
+				module.PathParamLinkKey(), module.PathParamOrgName()),
+			editor,
+			module.ShareLink,
+		)
+		router.DELETE(
+			fmt.Sprintf(":%s/shares/:%s",
+				module.PathParamLinkKey(), module.PathParamOrgName()),
+			editor,
+			module.UnshareLink,
+		)
+	}
+	if conf.History != nil {
+		router.POST(
+			fmt.Sprintf(":%s/revisions/:%s/restore",
+				module.PathParamLinkKey(), module.PathParamRevision()),
+			editor,
+			module.RestoreLinkRevision,
+		)
+	}
 }
@@ -1,26 +1,110 @@
 package linkapi
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/haostudio/golinks/internal/analytics"
 	"github.com/haostudio/golinks/internal/api/middlewares"
+	"github.com/haostudio/golinks/internal/auth"
 	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/history"
+	"github.com/haostudio/golinks/internal/link/share"
 	"github.com/haostudio/golinks/internal/service/golinks/ctx"
 )
 
 // New returns a new link api module.
-func New(store link.Store) *Links {
+func New(conf Config) *Links {
 	return &Links{
-		store: store,
+		store:     conf.Store,
+		analytics: conf.Analytics,
+		history:   conf.History,
+		shares:    conf.Shares,
+		manager:   conf.Manager,
 	}
 }
 
 // Links defines the link module struct.
 type Links struct {
-	store link.Store
+	store     link.Store
+	analytics analytics.Store
+	history   history.Store
+	shares    share.Store
+	manager   *auth.Manager
+}
+
+// linkResponse defines a link in the api response.
+type linkResponse struct {
+	Link        string     `json:"link"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedBy   string     `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	Revision    int64      `json:"revision"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Owners      []string   `json:"owners,omitempty"`
+	Protected   bool       `json:"protected,omitempty"`
+	Requests    []string   `json:"ownership_requests,omitempty"`
+}
+
+func newLinkResponse(ln link.Link) (res linkResponse, err error) {
+	res.Link, err = ln.Description()
+	if err != nil {
+		return
+	}
+	res.Description = ln.Meta.Description
+	res.Tags = ln.Meta.Tags
+	res.CreatedBy = ln.Meta.CreatedBy
+	res.CreatedAt = timePtr(ln.Meta.CreatedAt)
+	res.UpdatedBy = ln.Meta.UpdatedBy
+	res.UpdatedAt = timePtr(ln.Meta.UpdatedAt)
+	res.Revision = ln.Meta.Revision
+	res.ExpiresAt = timePtr(ln.Meta.ExpiresAt)
+	res.Owners = ln.Meta.Owners
+	res.Protected = ln.Meta.Protected
+	res.Requests = ln.Meta.OwnershipRequests
+	return
+}
+
+// etag returns the entity tag of the link revision.
+func etag(rev int64) string {
+	return fmt.Sprintf("\"%d\"", rev)
+}
+
+// parseETag parses the revision from the entity tag.
+func parseETag(tag string) (int64, error) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	return strconv.ParseInt(strings.Trim(tag, "\""), 10, 64)
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// revisionResponse defines a link revision in the api response.
+type revisionResponse struct {
+	ID      int          `json:"id"`
+	Link    linkResponse `json:"link"`
+	Deleted bool         `json:"deleted"`
+	Editor  string       `json:"editor,omitempty"`
+	Time    time.Time    `json:"time"`
+}
+
+// PathParamRevision returns the revision path parameter.
+func (l *Links) PathParamRevision() string {
+	return "revision"
 }
 
 // PathParamLinkKey returns the link_key path parameter.
@@ -28,7 +112,29 @@ func (l *Links) PathParamLinkKey() string {
 	return "link_key"
 }
 
-// GetLinks returns all links.
+// QueryPrefix returns the key prefix query parameter of GetLinks.
+func (l *Links) QueryPrefix() string {
+	return "prefix"
+}
+
+// QueryCursor returns the page cursor query parameter of GetLinks.
+func (l *Links) QueryCursor() string {
+	return "cursor"
+}
+
+// QueryLimit returns the page size query parameter of GetLinks.
+func (l *Links) QueryLimit() string {
+	return "limit"
+}
+
+// HeaderNextCursor returns the response header of GetLinks holding the cursor
+// of the next page.
+func (l *Links) HeaderNextCursor() string {
+	return "X-Next-Cursor"
+}
+
+// GetLinks returns all links, or a page of the links if any of the prefix,
+// cursor and limit query parameters is set.
 func (l *Links) GetLinks(ginctx *gin.Context) {
 	logger := middlewares.GetLogger(ginctx)
 
@@ -39,39 +145,154 @@ func (l *Links) GetLinks(ginctx *gin.Context) {
 		ginctx.Status(http.StatusInternalServerError)
 		return
 	}
-	links, err := l.store.GetLinks(ginctx.Request.Context(), org.Name)
-	if err != nil {
-		logger.Error("failed to get links from store. err: %v", err)
-		ginctx.Status(http.StatusInternalServerError)
-		return
-	}
-
-	// sorted keys
-	keys := make([]string, len(links))
-	var i int
-	for k := range links {
-		keys[i] = k
-		i++
+	var (
+		keys  []string
+		links map[string]link.Link
+	)
+	prefix, hasPrefix := ginctx.GetQuery(l.QueryPrefix())
+	cursor, hasCursor := ginctx.GetQuery(l.QueryCursor())
+	limitStr, hasLimit := ginctx.GetQuery(l.QueryLimit())
+	if hasPrefix || hasCursor || hasLimit {
+		var limit int
+		if hasLimit {
+			limit, err = strconv.Atoi(limitStr)
+		}
+		if err != nil || limit < 0 {
+			logger.Error("invalid limit %s. err: %v", limitStr, err)
+			ginctx.String(http.StatusBadRequest, "invalid limit")
+			return
+		}
+		var page link.Page
+		page, err = l.store.ScanLinks(
+			ginctx.Request.Context(), org.Name, prefix, cursor, limit)
+		if err != nil {
+			logger.Error("failed to scan links from store. err: %v", err)
+			ginctx.Status(http.StatusInternalServerError)
+			return
+		}
+		if page.Next != "" {
+			ginctx.Header(l.HeaderNextCursor(), page.Next)
+		}
+		keys, links = page.Keys, page.Links
+	} else {
+		links, err = l.store.GetLinks(ginctx.Request.Context(), org.Name)
+		if err != nil {
+			logger.Error("failed to get links from store. err: %v", err)
+			ginctx.Status(http.StatusInternalServerError)
+			return
+		}
+		// sorted keys
+		keys = make([]string, 0, len(links))
+		for k := range links {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
 	}
-	sort.Strings(keys)
 
 	// construct response
-	res := make(map[string]string)
+	res := make(map[string]linkResponse)
 	for _, key := range keys {
-		ln := links[key]
-		desc, err := ln.Description()
+		lnRes, err := newLinkResponse(links[key])
 		if err != nil {
 			logger.Debug(
 				"failed to get description of link with key \"%s\". err: %v", key, err)
 			continue
 		}
-		res[key] = desc
+		res[key] = lnRes
+	}
+
+	ginctx.JSON(http.StatusOK, res)
+}
+
+// GetLink returns the link with its revision as the ETag.
+func (l *Links) GetLink(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	key := ginctx.Param(l.PathParamLinkKey())
+	if len(key) == 0 {
+		logger.Error("empty key")
+		ginctx.String(http.StatusBadRequest, "empty key")
+		return
+	}
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	ln, err := l.store.GetLink(ginctx.Request.Context(), org.Name, key)
+	if errors.Is(err, link.ErrNotFound) {
+		ginctx.Status(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed to get \"%s\" from store. err: %v", key, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	res, err := newLinkResponse(ln)
+	if err != nil {
+		logger.Error(
+			"failed to get description of link with key \"%s\". err: %v", key, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	ginctx.Header("ETag", etag(ln.Meta.Revision))
+	ginctx.JSON(http.StatusOK, res)
+}
+
+// GetLinkStats returns the click stats of the link.
+func (l *Links) GetLinkStats(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	key := ginctx.Param(l.PathParamLinkKey())
+	if len(key) == 0 {
+		logger.Error("empty key")
+		ginctx.String(http.StatusBadRequest, "empty key")
+		return
+	}
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	_, err = l.store.GetLink(ginctx.Request.Context(), org.Name, key)
+	if errors.Is(err, link.ErrNotFound) {
+		ginctx.Status(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed to get \"%s\" from store. err: %v", key, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	stats, err := l.analytics.GetStats(ginctx.Request.Context(), org.Name, key)
+	if err != nil {
+		logger.Error("failed to get stats of \"%s\". err: %v", key, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
 	}
 
+	var res struct {
+		Count        int64            `json:"count"`
+		LastAccessed *time.Time       `json:"last_accessed,omitempty"`
+		Daily        map[string]int64 `json:"daily"`
+	}
+	res.Count = stats.Count
+	res.LastAccessed = timePtr(stats.LastAccessed)
+	res.Daily = stats.Daily
+	if res.Daily == nil {
+		res.Daily = make(map[string]int64)
+	}
 	ginctx.JSON(http.StatusOK, res)
 }
 
-// UpdateLink updates the link.
+// UpdateLink updates the link. The link never expires unless expires_at is
+// set. The update is rejected with 412 if the If-Match header, or with 409 if
+// the revision in the body, doesn't match the revision of the stored link. A
+// protected link, or the protection of a link, can only be changed by the
+// link owners and the org admins.
 func (l *Links) UpdateLink(ginctx *gin.Context) {
 	logger := middlewares.GetLogger(ginctx)
 	key := ginctx.Param(l.PathParamLinkKey())
@@ -80,23 +301,38 @@ func (l *Links) UpdateLink(ginctx *gin.Context) {
 		ginctx.String(http.StatusBadRequest, "empty key")
 		return
 	}
+	if !link.ValidKey(key) {
+		logger.Error("invalid key %s", key)
+		ginctx.String(http.StatusBadRequest, "invalid key")
+		return
+	}
 
 	// read link from request
 	var req struct {
-		Version int    `json:"version"`
-		Payload string `json:"payload"`
+		Version     int        `json:"version"`
+		Payload     string     `json:"payload"`
+		Description string     `json:"description"`
+		Tags        []string   `json:"tags"`
+		Revision    *int64     `json:"revision"`
+		ExpiresAt   *time.Time `json:"expires_at"`
+		Protected   *bool      `json:"protected"`
 	}
 	err := ginctx.BindJSON(&req)
 	if err != nil {
 		logger.Error("failed to bind json. err: %v", err)
 		return
 	}
-	link, err := link.New(req.Version, req.Payload)
+	ln, err := link.New(req.Version, req.Payload)
 	if err != nil {
 		logger.Error("failed to bind json. err: %v", err)
 		ginctx.Status(http.StatusBadRequest)
 		return
 	}
+	ln.Meta.Description = req.Description
+	ln.Meta.Tags = link.NormalizeTags(req.Tags)
+	if req.ExpiresAt != nil {
+		ln.Meta.ExpiresAt = *req.ExpiresAt
+	}
 
 	// update to store
 	org, err := ctx.GetOrg(ginctx)
@@ -105,17 +341,94 @@ func (l *Links) UpdateLink(ginctx *gin.Context) {
 		ginctx.Status(http.StatusInternalServerError)
 		return
 	}
-	err = l.store.UpdateLink(ginctx.Request.Context(), org.Name, key, *link)
+	_, _, err = link.Canonical(
+		ginctx.Request.Context(), l.store, org.Name, key, *ln)
+	if errors.Is(err, link.ErrNotFound) || errors.Is(err, link.ErrAliasLoop) {
+		logger.Error("invalid alias \"%s\". err: %v", key, err)
+		ginctx.String(http.StatusBadRequest, "invalid alias")
+		return
+	}
+	if err != nil {
+		logger.Error("failed to get canonical link of \"%s\". err: %v", key, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	var prev *link.Link
+	existing, err := l.store.GetLink(ginctx.Request.Context(), org.Name, key)
+	if err == nil {
+		prev = &existing
+	} else if !errors.Is(err, link.ErrNotFound) {
+		logger.Error("failed to get \"%s\" from store. err: %v", key, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	email := ctx.GetUserEmail(ginctx)
+	admin := ctx.IsAdmin(ginctx)
+	if prev != nil && !prev.Editable(email, admin) {
+		logger.Warn("\"%s\" is protected from %s", key, email)
+		ginctx.String(http.StatusForbidden, "protected link")
+		return
+	}
+
+	// optimistic concurrency control. The link is only written at the
+	// revision checked above, and the requested revision must match it.
+	var rev int64
+	if prev != nil {
+		rev = prev.Meta.Revision
+	}
+	stale := req.Revision != nil && *req.Revision != rev
+	conflict := http.StatusConflict
+	if ifMatch := ginctx.GetHeader("If-Match"); ifMatch == "*" {
+		if prev == nil {
+			ginctx.Status(http.StatusPreconditionFailed)
+			return
+		}
+	} else if ifMatch != "" {
+		var matched int64
+		matched, err = parseETag(ifMatch)
+		if err != nil {
+			logger.Error("invalid If-Match %s. err: %v", ifMatch, err)
+			ginctx.String(http.StatusBadRequest, "invalid If-Match")
+			return
+		}
+		stale = stale || matched != rev
+		conflict = http.StatusPreconditionFailed
+	}
+	if stale {
+		logger.Warn("conflicted update of \"%s\" at revision %d", key, rev)
+		ginctx.Status(conflict)
+		return
+	}
+
+	ln.Touch(email, time.Now(), prev)
+	if req.Protected != nil && *req.Protected != ln.Meta.Protected {
+		if !admin && !ln.IsOwner(email) {
+			logger.Warn("%s is not the owner of \"%s\"", email, key)
+			ginctx.String(http.StatusForbidden, "not link owner")
+			return
+		}
+		ln.Meta.Protected = *req.Protected
+	}
+	err = l.store.UpdateLinkIf(
+		ginctx.Request.Context(), org.Name, key, rev, *ln)
+	ln.Meta.Revision = rev + 1
+	if errors.Is(err, link.ErrConflict) {
+		logger.Warn("conflicted update of \"%s\". err: %v", key, err)
+		ginctx.Status(conflict)
+		return
+	}
 	if err != nil {
 		logger.Error(
-			"failed to update \"%s\" to %s in store. err: %v", key, *link, err)
+			"failed to update \"%s\" to %v in store. err: %v", key, *ln, err)
 		ginctx.Status(http.StatusInternalServerError)
 		return
 	}
+	ginctx.Header("ETag", etag(ln.Meta.Revision))
 	ginctx.Status(http.StatusOK)
 }
 
-// DeleteLink deletes the link.
+// DeleteLink deletes the link. A protected link can only be deleted by the
+// link owners and the org admins.
 func (l *Links) DeleteLink(ginctx *gin.Context) {
 	logger := middlewares.GetLogger(ginctx)
 	key := ginctx.Param(l.PathParamLinkKey())
@@ -131,7 +444,22 @@ func (l *Links) DeleteLink(ginctx *gin.Context) {
 		ginctx.Status(http.StatusInternalServerError)
 		return
 	}
-	err = l.store.DeleteLink(ginctx.Request.Context(), org.Name, key)
+	rev, ok := l.editable(ginctx, org.Name, key)
+	if !ok {
+		return
+	}
+	reqCtx := history.WithEditor(
+		ginctx.Request.Context(), ctx.GetUserEmail(ginctx))
+	// the link is only deleted at the revision checked
+	err = l.store.DeleteLinkIf(reqCtx, org.Name, key, rev)
+	if errors.Is(err, link.ErrNotFound) {
+		err = nil
+	}
+	if errors.Is(err, link.ErrConflict) {
+		logger.Warn("conflicted delete of \"%s\". err: %v", key, err)
+		ginctx.Status(http.StatusConflict)
+		return
+	}
 	if err != nil {
 		logger.Error("failed to delete \"%s\" from store. err: %v", key, err)
 		ginctx.Status(http.StatusInternalServerError)
@@ -139,3 +467,110 @@ func (l *Links) DeleteLink(ginctx *gin.Context) {
 	}
 	ginctx.Status(http.StatusOK)
 }
+
+// GetLinkRevisions returns the revisions of the link.
+func (l *Links) GetLinkRevisions(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	key := ginctx.Param(l.PathParamLinkKey())
+	if len(key) == 0 {
+		logger.Error("empty key")
+		ginctx.String(http.StatusBadRequest, "empty key")
+		return
+	}
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	revs, err := l.history.GetRevisions(
+		ginctx.Request.Context(), org.Name, key)
+	if err != nil {
+		logger.Error("failed to get revisions of \"%s\". err: %v", key, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	res := make([]revisionResponse, 0, len(revs))
+	for _, rev := range revs {
+		lnRes, err := newLinkResponse(rev.Link)
+		if err != nil {
+			logger.Error("failed to get description of revision %d. err: %v",
+				rev.ID, err)
+			continue
+		}
+		res = append(res, revisionResponse{
+			ID:      rev.ID,
+			Link:    lnRes,
+			Deleted: rev.Deleted,
+			Editor:  rev.Editor,
+			Time:    rev.Time,
+		})
+	}
+	ginctx.JSON(http.StatusOK, res)
+}
+
+// RestoreLinkRevision restores the link to the revision.
+func (l *Links) RestoreLinkRevision(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	key := ginctx.Param(l.PathParamLinkKey())
+	if len(key) == 0 {
+		logger.Error("empty key")
+		ginctx.String(http.StatusBadRequest, "empty key")
+		return
+	}
+	id, err := strconv.Atoi(ginctx.Param(l.PathParamRevision()))
+	if err != nil {
+		logger.Error("invalid revision. err: %v", err)
+		ginctx.String(http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	if _, ok := l.editable(ginctx, org.Name, key); !ok {
+		return
+	}
+	reqCtx := history.WithEditor(
+		ginctx.Request.Context(), ctx.GetUserEmail(ginctx))
+	err = l.history.Restore(reqCtx, org.Name, key, id)
+	if errors.Is(err, history.ErrRevisionNotFound) {
+		ginctx.Status(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed to restore \"%s\" to revision %d. err: %v",
+			key, id, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	ginctx.Status(http.StatusOK)
+}
+
+// editable returns if the request user can change the link with key in org,
+// and the revision of the link checked, which is 0 if the link doesn't exist.
+// Otherwise, the response is written and false is returned.
+func (l *Links) editable(ginctx *gin.Context, org, key string) (
+	rev int64, ok bool) {
+	logger := middlewares.GetLogger(ginctx)
+	ln, err := l.store.GetLink(ginctx.Request.Context(), org, key)
+	if errors.Is(err, link.ErrNotFound) {
+		return 0, true
+	}
+	if err != nil {
+		logger.Error("failed to get \"%s\" from store. err: %v", key, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return 0, false
+	}
+	email := ctx.GetUserEmail(ginctx)
+	if !ln.Editable(email, ctx.IsAdmin(ginctx)) {
+		logger.Warn("\"%s\" is protected from %s", key, email)
+		ginctx.String(http.StatusForbidden, "protected link")
+		return 0, false
+	}
+	return ln.Meta.Revision, true
+}
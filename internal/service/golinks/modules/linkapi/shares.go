@@ -0,0 +1,157 @@
+package linkapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/haostudio/golinks/internal/api/middlewares"
+	"github.com/haostudio/golinks/internal/auth"
+	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/share"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
+)
+
+// PathParamOrgName returns the org_name path parameter of the shares.
+func (l *Links) PathParamOrgName() string {
+	return "org_name"
+}
+
+// GetLinkShares returns the orgs the link is shared with.
+func (l *Links) GetLinkShares(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	key := ginctx.Param(l.PathParamLinkKey())
+	if len(key) == 0 {
+		logger.Error("empty key")
+		ginctx.String(http.StatusBadRequest, "empty key")
+		return
+	}
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	orgs, err := l.shares.GetSharedOrgs(ginctx.Request.Context(), org.Name, key)
+	if err != nil {
+		logger.Error("failed to get shares of \"%s\". err: %v", key, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	if orgs == nil {
+		orgs = []string{}
+	}
+	ginctx.JSON(http.StatusOK, gin.H{"orgs": orgs})
+}
+
+// ShareLink shares the link with the org.
+func (l *Links) ShareLink(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	key := ginctx.Param(l.PathParamLinkKey())
+	target := ginctx.Param(l.PathParamOrgName())
+	if len(key) == 0 || len(target) == 0 {
+		logger.Error("empty key or org")
+		ginctx.String(http.StatusBadRequest, "empty key or org")
+		return
+	}
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	if target == org.Name {
+		ginctx.String(http.StatusBadRequest, "cannot share with own org")
+		return
+	}
+	if l.manager == nil {
+		ginctx.String(http.StatusNotFound, "org not found")
+		return
+	}
+	targetOrg, err := l.manager.GetOrg(ginctx.Request.Context(), target)
+	if errors.Is(err, auth.ErrNotFound) {
+		ginctx.String(http.StatusNotFound, "org not found")
+		return
+	}
+	if err != nil {
+		logger.Error("failed to get org %s. err: %v", target, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	// the links shared with an org redirect its missing keys, so only its
+	// editors may share with it.
+	user, err := ctx.GetUser(ginctx)
+	if err != nil {
+		logger.Error("failed to get user. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	if !user.IsMember(target) ||
+		!targetOrg.Role(user.Email).Allows(auth.RoleEditor) {
+		logger.Warn("%s is not an editor of %s", user.Email, target)
+		ginctx.String(http.StatusForbidden, "not an editor of the org")
+		return
+	}
+	_, err = l.store.GetLink(ginctx.Request.Context(), org.Name, key)
+	if errors.Is(err, link.ErrNotFound) {
+		ginctx.Status(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed to get \"%s\" from store. err: %v", key, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	err = l.shares.UpdateShare(ginctx.Request.Context(), share.Share{
+		Org:  target,
+		Key:  key,
+		From: org.Name,
+	})
+	if errors.Is(err, share.ErrConflict) {
+		logger.Error("failed to share \"%s\" with %s. err: %v", key, target, err)
+		ginctx.String(http.StatusConflict, "shared from another org")
+		return
+	}
+	if err != nil {
+		logger.Error("failed to share \"%s\" with %s. err: %v", key, target, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	ginctx.Status(http.StatusOK)
+}
+
+// UnshareLink stops sharing the link with the org.
+func (l *Links) UnshareLink(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	key := ginctx.Param(l.PathParamLinkKey())
+	target := ginctx.Param(l.PathParamOrgName())
+	if len(key) == 0 || len(target) == 0 {
+		logger.Error("empty key or org")
+		ginctx.String(http.StatusBadRequest, "empty key or org")
+		return
+	}
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	s, err := l.shares.GetShare(ginctx.Request.Context(), target, key)
+	if errors.Is(err, share.ErrNotFound) || (err == nil && s.From != org.Name) {
+		ginctx.Status(http.StatusNotFound)
+		return
+	}
+	if err == nil {
+		err = l.shares.DeleteShare(ginctx.Request.Context(), target, key)
+	}
+	if err != nil {
+		logger.Error("failed to unshare \"%s\" with %s. err: %v", key, target, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	ginctx.Status(http.StatusOK)
+}
@@ -0,0 +1,269 @@
+package linkapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v2"
+
+	"github.com/haostudio/golinks/internal/api/middlewares"
+	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/history"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
+)
+
+// Transfer formats.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Import modes.
+const (
+	ImportDryRun       = "dry-run"
+	ImportSkipExisting = "skip-existing"
+	ImportOverwrite    = "overwrite"
+)
+
+// Import results.
+const (
+	ImportResultNew       = "new"
+	ImportResultConflict  = "conflict"
+	ImportResultCreated   = "created"
+	ImportResultUpdated   = "updated"
+	ImportResultSkipped   = "skipped"
+	ImportResultProtected = "protected"
+	ImportResultInvalid   = "invalid"
+	ImportResultFailed    = "failed"
+)
+
+// transferDoc defines the document of exported links.
+type transferDoc struct {
+	Org   string         `json:"org" yaml:"org"`
+	Links []transferLink `json:"links" yaml:"links"`
+}
+
+// transferLink defines an exported link.
+type transferLink struct {
+	Key         string   `json:"key" yaml:"key"`
+	Version     int      `json:"version" yaml:"version"`
+	Payload     string   `json:"payload" yaml:"payload"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"` // nolint: lll
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// importResult defines the import result of a link.
+type importResult struct {
+	Key    string `json:"key"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// QueryFormat returns the format query key.
+func (l *Links) QueryFormat() string {
+	return "format"
+}
+
+// QueryMode returns the import mode query key.
+func (l *Links) QueryMode() string {
+	return "mode"
+}
+
+// ExportLinks writes all links of the org in json or yaml.
+func (l *Links) ExportLinks(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	format := strings.ToLower(ginctx.DefaultQuery(l.QueryFormat(), FormatJSON))
+	if format != FormatJSON && format != FormatYAML {
+		ginctx.String(http.StatusBadRequest, "invalid format %s", format)
+		return
+	}
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	links, err := l.store.GetLinks(ginctx.Request.Context(), org.Name)
+	if errors.Is(err, link.ErrNotFound) {
+		links = make(map[string]link.Link)
+	} else if err != nil {
+		logger.Error("failed to get links. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	doc := transferDoc{
+		Org:   org.Name,
+		Links: make([]transferLink, 0, len(links)),
+	}
+	for key, ln := range links {
+		payload, err := ln.Payload()
+		if err != nil {
+			logger.Error("failed to get payload of \"%s\". err: %v", key, err)
+			continue
+		}
+		doc.Links = append(doc.Links, transferLink{
+			Key:         key,
+			Version:     ln.Version,
+			Payload:     payload,
+			Description: ln.Meta.Description,
+			Tags:        ln.Meta.Tags,
+		})
+	}
+	sort.Slice(doc.Links, func(i, j int) bool {
+		return doc.Links[i].Key < doc.Links[j].Key
+	})
+
+	ginctx.Header("Content-Disposition", fmt.Sprintf(
+		"attachment; filename=\"%s-links.%s\"", org.Name, format))
+	switch format {
+	case FormatYAML:
+		ginctx.Header("Content-Type", "application/x-yaml; charset=utf-8")
+		ginctx.Status(http.StatusOK)
+		err = yaml.NewEncoder(ginctx.Writer).Encode(doc)
+	default:
+		ginctx.Header("Content-Type", "application/json; charset=utf-8")
+		ginctx.Status(http.StatusOK)
+		enc := json.NewEncoder(ginctx.Writer)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(doc)
+	}
+	if err != nil {
+		logger.Error("failed to write links. err: %v", err)
+	}
+}
+
+// ImportLinks imports the links in the json or yaml request body to the org.
+func (l *Links) ImportLinks(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	mode := strings.ToLower(
+		ginctx.DefaultQuery(l.QueryMode(), ImportSkipExisting))
+	switch mode {
+	case ImportDryRun, ImportSkipExisting, ImportOverwrite:
+	default:
+		ginctx.String(http.StatusBadRequest, "invalid mode %s", mode)
+		return
+	}
+	format := strings.ToLower(ginctx.Query(l.QueryFormat()))
+	if format == "" {
+		format = FormatJSON
+		if strings.Contains(ginctx.ContentType(), FormatYAML) {
+			format = FormatYAML
+		}
+	}
+
+	// read links from request
+	body, err := ioutil.ReadAll(ginctx.Request.Body)
+	if err != nil {
+		logger.Error("failed to read body. err: %v", err)
+		ginctx.Status(http.StatusBadRequest)
+		return
+	}
+	var doc transferDoc
+	switch format {
+	case FormatJSON:
+		err = json.Unmarshal(body, &doc)
+	case FormatYAML:
+		err = yaml.Unmarshal(body, &doc)
+	default:
+		ginctx.String(http.StatusBadRequest, "invalid format %s", format)
+		return
+	}
+	if err != nil {
+		logger.Error("failed to decode %s. err: %v", format, err)
+		ginctx.String(http.StatusBadRequest, "invalid %s document", format)
+		return
+	}
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	editor := ctx.GetUserEmail(ginctx)
+	admin := ctx.IsAdmin(ginctx)
+	reqCtx := history.WithEditor(ginctx.Request.Context(), editor)
+	now := time.Now()
+
+	results := make([]importResult, 0, len(doc.Links))
+	for _, in := range doc.Links {
+		res := importResult{Key: in.Key}
+		res.Result, err = l.importLink(
+			reqCtx, org.Name, mode, editor, admin, now, in)
+		if err != nil {
+			res.Error = err.Error()
+		}
+		results = append(results, res)
+	}
+	ginctx.JSON(http.StatusOK, gin.H{
+		"mode":    mode,
+		"results": results,
+	})
+}
+
+func (l *Links) importLink(reqCtx context.Context, org string, mode string,
+	editor string, admin bool, now time.Time, in transferLink) (string, error) {
+	if !link.ValidKey(in.Key) {
+		return ImportResultInvalid, fmt.Errorf("invalid key")
+	}
+	ln, err := link.New(in.Version, in.Payload)
+	if err != nil {
+		return ImportResultInvalid, err
+	}
+	ln.Meta.Description = in.Description
+	ln.Meta.Tags = link.NormalizeTags(in.Tags)
+	// the canonical links may be imported after their aliases.
+	_, _, err = link.Canonical(reqCtx, l.store, org, in.Key, *ln)
+	if errors.Is(err, link.ErrAliasLoop) {
+		return ImportResultInvalid, err
+	}
+	if err != nil && !errors.Is(err, link.ErrNotFound) {
+		return ImportResultFailed, err
+	}
+
+	var prev *link.Link
+	existing, err := l.store.GetLink(reqCtx, org, in.Key)
+	if err == nil {
+		prev = &existing
+	} else if !errors.Is(err, link.ErrNotFound) {
+		return ImportResultFailed, err
+	}
+
+	switch {
+	case mode == ImportDryRun && prev == nil:
+		return ImportResultNew, nil
+	case mode == ImportSkipExisting && prev != nil:
+		return ImportResultSkipped, nil
+	case prev != nil && !prev.Editable(editor, admin):
+		return ImportResultProtected, link.ErrNotOwner
+	case mode == ImportDryRun:
+		return ImportResultConflict, nil
+	}
+	ln.Touch(editor, now, prev)
+	// the link checked above may be changed since
+	var rev int64
+	if prev != nil {
+		rev = prev.Meta.Revision
+	}
+	err = l.store.UpdateLinkIf(reqCtx, org, in.Key, rev, *ln)
+	if errors.Is(err, link.ErrConflict) {
+		return ImportResultConflict, err
+	}
+	if err != nil {
+		return ImportResultFailed, err
+	}
+	if prev != nil {
+		return ImportResultUpdated, nil
+	}
+	return ImportResultCreated, nil
+}
@@ -2,10 +2,14 @@ package linkweb
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/history"
+	"github.com/haostudio/golinks/internal/link/pattern"
 	"github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
 )
 
@@ -15,6 +19,68 @@ type Link struct {
 	Key     string
 	Version int
 	Format  string
+
+	Description string
+	Tags        []string
+	CreatedBy   string
+	CreatedAt   time.Time
+	UpdatedBy   string
+	UpdatedAt   time.Time
+	Revision    int64
+	ExpiresAt   time.Time
+	Expired     bool
+	Schedule    []ScheduleEntry
+	// AliasOf is the canonical key of an alias link.
+	AliasOf string
+	// Aliases are the aliases of a canonical link.
+	Aliases []Link
+	// Org is the org of a link shared from another org.
+	Org string
+	// Owners are the emails of the link owners.
+	Owners    []string
+	Protected bool
+	// OwnershipRequests are the emails of the users requesting the ownership.
+	OwnershipRequests []string
+
+	Clicks       int64
+	LastAccessed time.Time
+}
+
+// ScheduleEntry defines a schedule entry data of a v3 link for template.
+type ScheduleEntry struct {
+	ActiveFrom time.Time
+	Target     string
+	Active     bool
+}
+
+// TagsString returns the comma separated tags.
+func (l Link) TagsString() string {
+	return strings.Join(l.Tags, ", ")
+}
+
+// PathKey returns the key escaped as a url path segment.
+func (l Link) PathKey() string {
+	return link.EscapeKey(l.Key)
+}
+
+// AliasPathKey returns the canonical key of an alias escaped as a url path
+// segment.
+func (l Link) AliasPathKey() string {
+	return link.EscapeKey(l.AliasOf)
+}
+
+// FormatRows returns the number of lines of the format.
+func (l Link) FormatRows() int {
+	return strings.Count(l.Format, "\n") + 1
+}
+
+// ExpiresAtInput returns the expiry time in the format of the datetime-local
+// input.
+func (l Link) ExpiresAtInput() string {
+	if l.ExpiresAt.IsZero() {
+		return ""
+	}
+	return l.ExpiresAt.Local().Format(formExpiresAtLayout)
 }
 
 // NewLink returns a new link data.
@@ -22,19 +88,68 @@ func NewLink(key string, ln link.Link) (data Link, err error) {
 	data.Exists = true
 	data.Key = key
 	data.Version = ln.Version
-	desc, err := ln.Description()
+	data.Format, err = ln.Payload()
+	if err != nil {
+		err = fmt.Errorf(
+			"failed to get payload of link with key \"%s\". err: %w",
+			key, err,
+		)
+		return
+	}
+	data.Description = ln.Meta.Description
+	data.Tags = ln.Meta.Tags
+	data.CreatedBy = ln.Meta.CreatedBy
+	data.CreatedAt = ln.Meta.CreatedAt
+	data.UpdatedBy = ln.Meta.UpdatedBy
+	data.UpdatedAt = ln.Meta.UpdatedAt
+	data.Revision = ln.Meta.Revision
+	data.ExpiresAt = ln.Meta.ExpiresAt
+	data.Expired = ln.Expired(time.Now())
+	data.AliasOf, _ = ln.AliasOf()
+	data.Owners = ln.Meta.Owners
+	data.Protected = ln.Meta.Protected
+	data.OwnershipRequests = ln.Meta.OwnershipRequests
+	if ln.Version != 3 {
+		return
+	}
+	entries, err := ln.Schedule()
 	if err != nil {
 		err = fmt.Errorf(
-			"failed to get description of link with key \"%s\". err: %w",
+			"failed to get schedule of link with key \"%s\". err: %w",
 			key, err,
 		)
 		return
 	}
-	// XXX: Since link description is now "vx|...|http(s)://xxx", we simply trim
-	// the leading "vx|...|" and return the right.
-	_, desc = link.Pop(desc, "http")
-	desc = "http" + desc
-	data.Format = desc
+	active := link.ActiveEntry(entries, time.Now())
+	for i, entry := range entries {
+		data.Schedule = append(data.Schedule, ScheduleEntry{
+			ActiveFrom: entry.ActiveFrom,
+			Target:     entry.Target,
+			Active:     i == active,
+		})
+	}
+	return
+}
+
+// Revision defines a link revision data for template.
+type Revision struct {
+	ID      int
+	Link    Link
+	Deleted bool
+	Editor  string
+	Time    time.Time
+}
+
+// NewRevision returns a new revision data.
+func NewRevision(key string, rev history.Revision) (data Revision, err error) {
+	data.Link, err = NewLink(key, rev.Link)
+	if err != nil {
+		return
+	}
+	data.ID = rev.ID
+	data.Deleted = rev.Deleted
+	data.Editor = rev.Editor
+	data.Time = rev.Time
 	return
 }
 
@@ -42,6 +157,10 @@ func NewLink(key string, ln link.Link) (data Link, err error) {
 type AllPageData struct {
 	webbase.Data
 	Links []Link
+	// Shared are the links shared from the other orgs.
+	Shared    []Link
+	Analytics bool
+	Patterns  bool
 }
 
 // NewAllPageData returns links page data.
@@ -55,13 +174,35 @@ func NewAllPageData(ctx *gin.Context) AllPageData {
 type EditPageData struct {
 	webbase.Data
 
-	FormInputVersion string
-	FormInputPayload string
-	FormInputAction  string
-	FormSaveValue    string
-	FormDeleteValue  string
+	FormInputVersion      string
+	FormInputPayload      string
+	FormInputDescription  string
+	FormInputTags         string
+	FormInputExpiresAt    string
+	FormInputAction       string
+	FormSaveValue         string
+	FormDeleteValue       string
+	FormInputRevision     string
+	FormInputLinkRevision string
+	FormInputProtected    string
+	FormInputOwner        string
+	FormRequestValue      string
+	FormGrantValue        string
+	FormTransferValue     string
+	FormRemoveValue       string
 
-	Link Link
+	Link      Link
+	History   bool
+	Revisions []Revision
+	// SharedWith are the orgs the link is shared with.
+	SharedWith []string
+	// Editable is true if the user can change the link.
+	Editable bool
+	// Owner is true if the user owns the link.
+	Owner bool
+	// Manager is true if the user can change the link protection and owners,
+	// as a link owner or an org admin.
+	Manager bool
 }
 
 // NewEditPageData returns edit page data.
@@ -70,3 +211,26 @@ func NewEditPageData(ctx *gin.Context) EditPageData {
 		Data: webbase.NewData("Golinks - Edit links", ctx),
 	}
 }
+
+// PatternsPageData defines the data for patterns.html template.
+type PatternsPageData struct {
+	webbase.Data
+
+	FormInputName        string
+	FormInputExpr        string
+	FormInputTarget      string
+	FormInputPriority    string
+	FormInputDescription string
+	FormInputAction      string
+	FormSaveValue        string
+	FormDeleteValue      string
+
+	Patterns []pattern.Pattern
+}
+
+// NewPatternsPageData returns patterns page data.
+func NewPatternsPageData(ctx *gin.Context) PatternsPageData {
+	return PatternsPageData{
+		Data: webbase.NewData("Golinks - Pattern routes", ctx),
+	}
+}
@@ -6,40 +6,75 @@ import (
 	"net/http"
 	"sort"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/haostudio/golinks/internal/analytics"
 	"github.com/haostudio/golinks/internal/api/middlewares"
 	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/history"
+	"github.com/haostudio/golinks/internal/link/pattern"
+	"github.com/haostudio/golinks/internal/link/share"
 	"github.com/haostudio/golinks/internal/service/golinks/ctx"
 	"github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
 )
 
 const (
-	formInputVersion = "version"
-	formInputPayload = "payload"
-	formInputAction  = "action"
-	formSaveValue    = "Save"
-	formDeleteValue  = "Delete"
+	formInputVersion      = "version"
+	formInputPayload      = "payload"
+	formInputDescription  = "description"
+	formInputTags         = "tags"
+	formInputExpiresAt    = "expires_at"
+	formInputAction       = "action"
+	formInputRevision     = "revision"
+	formInputLinkRevision = "link_revision"
+	formInputProtected    = "protected"
+	formInputOwner        = "owner"
+	formSaveValue         = "Save"
+	formDeleteValue       = "Delete"
+	formRequestValue      = "Request ownership"
+	formGrantValue        = "Grant"
+	formTransferValue     = "Transfer"
+	formRemoveValue       = "Remove"
+	// formExpiresAtLayout is the value format of the datetime-local input.
+	formExpiresAtLayout = "2006-01-02T15:04"
 )
 
 // Config defines the web config.
 type Config struct {
-	Store  link.Store
-	Traced bool
+	Store     link.Store
+	Analytics analytics.Store // optional
+	History   history.Store   // optional
+	Patterns  *pattern.Router // optional
+	Shares    share.Store     // optional
+	// FallbackOrgs are the orgs whose links are shared with every org.
+	FallbackOrgs []string // optional
+	Traced       bool
 }
 
 // Web defines the web handler module.
 type Web struct {
 	webbase.Base
-	store link.Store
+	store     link.Store
+	analytics analytics.Store
+	history   history.Store
+	patterns  *pattern.Router
+	shares    share.Store
+	resolver  *share.Resolver
 }
 
 // New returns a new web handler module.
 func New(conf Config) *Web {
 	return &Web{
-		Base:  webbase.NewBase(conf.Traced),
-		store: conf.Store,
+		Base:      webbase.NewBase(conf.Traced),
+		store:     conf.Store,
+		analytics: conf.Analytics,
+		history:   conf.History,
+		patterns:  conf.Patterns,
+		shares:    conf.Shares,
+		resolver: share.NewResolver(
+			conf.Store, conf.Shares, conf.FallbackOrgs...),
 	}
 }
 
@@ -82,9 +117,23 @@ func (w *Web) Links() gin.HandlerFunc {
 			}
 			sort.Strings(keys)
 
-			// construct data
+			// get stats
 			logger := middlewares.GetLogger(ginctx)
+			var stats map[string]analytics.Stats
+			if w.analytics != nil {
+				stats, err = w.analytics.GetOrgStats(
+					ginctx.Request.Context(), org.Name)
+				if err != nil {
+					// stats are not critical for the page.
+					logger.Error("failed to get org stats. err: %v", err)
+				}
+			}
+
+			// construct data
 			pageData := NewAllPageData(ginctx)
+			pageData.Analytics = w.analytics != nil
+			pageData.Patterns = w.patterns != nil
+			aliases := make(map[string][]Link)
 			for _, key := range keys {
 				ln := links[key]
 				lnData, err := NewLink(key, ln)
@@ -92,13 +141,66 @@ func (w *Web) Links() gin.HandlerFunc {
 					logger.Error("failed to get links data of \"%s\". err: %v", key, err)
 					continue
 				}
+				if s, ok := stats[key]; ok {
+					lnData.Clicks = s.Count
+					lnData.LastAccessed = s.LastAccessed
+				}
+				// group the aliases under their canonical links
+				if canonical := canonicalKey(links, key); canonical != key {
+					aliases[canonical] = append(aliases[canonical], lnData)
+					continue
+				}
 				pageData.Links = append(pageData.Links, lnData)
 			}
+			for i := range pageData.Links {
+				pageData.Links[i].Aliases = aliases[pageData.Links[i].Key]
+			}
+
+			// links shared from the other orgs
+			shared, err := w.resolver.SharedLinks(
+				ginctx.Request.Context(), org.Name, links)
+			if err != nil {
+				// shared links are not critical for the page.
+				logger.Error("failed to get shared links. err: %v", err)
+			}
+			for _, resolved := range shared {
+				lnData, err := NewLink(resolved.Key, resolved.Link)
+				if err != nil {
+					logger.Error("failed to get links data of \"%s\" in %s. err: %v",
+						resolved.Key, resolved.Org, err)
+					continue
+				}
+				lnData.Org = resolved.Org
+				pageData.Shared = append(pageData.Shared, lnData)
+			}
 			return pageData, nil
 		},
 	)
 }
 
+// canonicalKey follows the aliases from key in links, and returns the key of
+// the canonical link, or key itself if the canonical link is not in links or
+// the aliases form a loop.
+func canonicalKey(links map[string]link.Link, key string) string {
+	visited := map[string]struct{}{key: {}}
+	current := key
+	for {
+		ln := links[current]
+		next, ok := ln.AliasOf()
+		if !ok {
+			return current
+		}
+		if _, ok := links[next]; !ok {
+			return key
+		}
+		if _, ok := visited[next]; ok {
+			return key
+		}
+		visited[next] = struct{}{}
+		current = next
+	}
+}
+
 // EditLink returns the edit page of a link (./web/edit.yaml)
 func (w *Web) EditLink() gin.HandlerFunc {
 	return w.Handler(
@@ -109,10 +211,25 @@ func (w *Web) EditLink() gin.HandlerFunc {
 			pageData := NewEditPageData(ginctx)
 			pageData.FormInputVersion = formInputVersion
 			pageData.FormInputPayload = formInputPayload
+			pageData.FormInputDescription = formInputDescription
+			pageData.FormInputTags = formInputTags
+			pageData.FormInputExpiresAt = formInputExpiresAt
 			pageData.FormInputAction = formInputAction
 			pageData.FormSaveValue = formSaveValue
 			pageData.FormDeleteValue = formDeleteValue
+			pageData.FormInputRevision = formInputRevision
+			pageData.FormInputLinkRevision = formInputLinkRevision
+			pageData.FormInputProtected = formInputProtected
+			pageData.FormInputOwner = formInputOwner
+			pageData.FormRequestValue = formRequestValue
+			pageData.FormGrantValue = formGrantValue
+			pageData.FormTransferValue = formTransferValue
+			pageData.FormRemoveValue = formRemoveValue
+			pageData.History = w.history != nil
 			pageData.Link.Key = key
+			// the editor owns the new link.
+			pageData.Editable = true
+			pageData.Manager = true
 
 			org, err := ctx.GetOrg(ginctx)
 			if err != nil {
@@ -144,8 +261,49 @@ func (w *Web) EditLink() gin.HandlerFunc {
 						),
 					}
 				}
+				email := ctx.GetUserEmail(ginctx)
+				admin := ctx.IsAdmin(ginctx)
+				pageData.Editable = ln.Editable(email, admin)
+				pageData.Owner = ln.IsOwner(email)
+				pageData.Manager = pageData.Owner || admin
 				break
 			}
+			if w.shares != nil && pageData.Link.Exists {
+				pageData.SharedWith, err = w.shares.GetSharedOrgs(
+					ginctx.Request.Context(), org.Name, key)
+				if err != nil {
+					return nil, &webbase.Error{
+						StatusCode: http.StatusInternalServerError,
+						Log: fmt.Sprintf(
+							"failed to get shared orgs from store. err: %v", err,
+						),
+					}
+				}
+			}
+			if w.history == nil {
+				return pageData, nil
+			}
+			revs, err := w.history.GetRevisions(
+				ginctx.Request.Context(), org.Name, pageData.Link.Key)
+			if err != nil {
+				return nil, &webbase.Error{
+					StatusCode: http.StatusInternalServerError,
+					Log: fmt.Sprintf(
+						"failed to get revisions from store. err: %v", err,
+					),
+				}
+			}
+			logger := middlewares.GetLogger(ginctx)
+			// latest first
+			for i := len(revs) - 1; i >= 0; i-- {
+				revData, err := NewRevision(pageData.Link.Key, revs[i])
+				if err != nil {
+					logger.Error("failed to get revision data %d of \"%s\". err: %v",
+						revs[i].ID, pageData.Link.Key, err)
+					continue
+				}
+				pageData.Revisions = append(pageData.Revisions, revData)
+			}
 			return pageData, nil
 		},
 	)
@@ -163,9 +321,22 @@ func (w *Web) HandleEditLinktForm(ginctx *gin.Context) {
 		return
 	}
 
-	action := ginctx.PostForm("action")
-	version := ginctx.PostForm("version")
-	payload := ginctx.PostForm("payload")
+	if !link.ValidKey(key) {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusBadRequest,
+			Messages:   []string{"Invalid key"},
+			Log:        fmt.Sprintf("invalid key %s", key),
+		})
+		return
+	}
+
+	action := ginctx.PostForm(formInputAction)
+	version := ginctx.PostForm(formInputVersion)
+	payload := ginctx.PostForm(formInputPayload)
+	description := ginctx.PostForm(formInputDescription)
+	tags := ginctx.PostForm(formInputTags)
+	expiresAt := ginctx.PostForm(formInputExpiresAt)
+	linkRevision := ginctx.PostForm(formInputLinkRevision)
 
 	org, err := ctx.GetOrg(ginctx)
 	if err != nil {
@@ -188,6 +359,16 @@ func (w *Web) HandleEditLinktForm(ginctx *gin.Context) {
 			return
 		}
 		ln, err := link.New(v, payload)
+		if errors.Is(err, link.ErrInvalidPayload) {
+			w.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusBadRequest,
+				Messages:   []string{"Invalid link", err.Error()},
+				Log: fmt.Sprintf(
+					"failed to create link from request form. err: %v", err,
+				),
+			})
+			return
+		}
 		if err != nil {
 			w.ServeErr(ginctx, &webbase.Error{
 				StatusCode: http.StatusInternalServerError,
@@ -197,8 +378,104 @@ func (w *Web) HandleEditLinktForm(ginctx *gin.Context) {
 			})
 			return
 		}
-		// update to store
-		err = w.store.UpdateLink(ginctx.Request.Context(), org.Name, key, *ln)
+		ln.Meta.Description = description
+		ln.Meta.Tags = link.ParseTags(tags)
+		if len(expiresAt) != 0 {
+			ln.Meta.ExpiresAt, err = time.ParseInLocation(
+				formExpiresAtLayout, expiresAt, time.Local)
+			if err != nil {
+				w.ServeErr(ginctx, &webbase.Error{
+					StatusCode: http.StatusBadRequest,
+					Messages:   []string{"Invalid expiry time"},
+					Log: fmt.Sprintf(
+						"failed to parse expiry time %s. err: %v", expiresAt, err,
+					),
+				})
+				return
+			}
+		}
+		_, _, err = link.Canonical(
+			ginctx.Request.Context(), w.store, org.Name, key, *ln)
+		if errors.Is(err, link.ErrNotFound) ||
+			errors.Is(err, link.ErrAliasLoop) {
+			w.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusBadRequest,
+				Messages:   []string{"Invalid alias", err.Error()},
+				Log:        fmt.Sprintf("invalid alias %s. err: %v", key, err),
+			})
+			return
+		}
+		if err != nil {
+			w.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusInternalServerError,
+				Log: fmt.Sprintf(
+					"failed to get canonical link from store. err: %v", err,
+				),
+			})
+			return
+		}
+		var prev *link.Link
+		existing, err := w.store.GetLink(ginctx.Request.Context(), org.Name, key)
+		if err == nil {
+			prev = &existing
+		} else if !errors.Is(err, link.ErrNotFound) {
+			w.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusInternalServerError,
+				Log: fmt.Sprintf(
+					"failed to get link from store. err: %v", err,
+				),
+			})
+			return
+		}
+		email := ctx.GetUserEmail(ginctx)
+		admin := ctx.IsAdmin(ginctx)
+		if prev != nil && !prev.Editable(email, admin) {
+			w.serveProtected(ginctx, key)
+			return
+		}
+		ln.Touch(email, time.Now(), prev)
+		if admin || ln.IsOwner(email) {
+			ln.Meta.Protected = len(ginctx.PostForm(formInputProtected)) != 0
+		}
+		// update to store at the revision checked above
+		var rev int64
+		if prev != nil {
+			rev = prev.Meta.Revision
+		}
+		stale := false
+		if len(linkRevision) != 0 {
+			// reject the form if the link was changed after it was rendered
+			var formRev int64
+			formRev, err = strconv.ParseInt(linkRevision, 10, 64)
+			if err != nil {
+				w.ServeErr(ginctx, &webbase.Error{
+					StatusCode: http.StatusBadRequest,
+					Messages:   []string{"Invalid link revision"},
+					Log: fmt.Sprintf(
+						"failed to parse link revision %s. err: %v", linkRevision, err,
+					),
+				})
+				return
+			}
+			stale = formRev != rev
+		}
+		if stale {
+			err = link.ErrConflict
+		} else {
+			err = w.store.UpdateLinkIf(
+				ginctx.Request.Context(), org.Name, key, rev, *ln)
+		}
+		if errors.Is(err, link.ErrConflict) {
+			w.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusConflict,
+				Messages: []string{
+					"Link was changed by someone else",
+					"Reload the page and try again",
+				},
+				Log: fmt.Sprintf("conflicted update of \"%s\". err: %v", key, err),
+			})
+			return
+		}
 		if err != nil {
 			w.ServeErr(ginctx, &webbase.Error{
 				StatusCode: http.StatusInternalServerError,
@@ -210,7 +487,28 @@ func (w *Web) HandleEditLinktForm(ginctx *gin.Context) {
 		ginctx.Redirect(http.StatusMovedPermanently, "/links")
 		return
 	case formDeleteValue:
-		err := w.store.DeleteLink(ginctx.Request.Context(), org.Name, key)
+		rev, ok := w.editable(ginctx, org.Name, key)
+		if !ok {
+			return
+		}
+		reqCtx := history.WithEditor(
+			ginctx.Request.Context(), ctx.GetUserEmail(ginctx))
+		// the link is only deleted at the revision checked
+		err := w.store.DeleteLinkIf(reqCtx, org.Name, key, rev)
+		if errors.Is(err, link.ErrNotFound) {
+			err = nil
+		}
+		if errors.Is(err, link.ErrConflict) {
+			w.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusConflict,
+				Messages: []string{
+					"Link was changed by someone else",
+					"Reload the page and try again",
+				},
+				Log: fmt.Sprintf("conflicted delete of \"%s\". err: %v", key, err),
+			})
+			return
+		}
 		if err != nil {
 			w.ServeErr(ginctx, &webbase.Error{
 				StatusCode: http.StatusInternalServerError,
@@ -230,3 +528,69 @@ func (w *Web) HandleEditLinktForm(ginctx *gin.Context) {
 		return
 	}
 }
+
+// HandleRestoreForm handles the revision restore form submission.
+func (w *Web) HandleRestoreForm(ginctx *gin.Context) {
+	key := ginctx.Param(w.PathParamLinkKey())
+	if len(key) == 0 {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Messages:   []string{"Empty key"},
+			Log:        "Empty key",
+		})
+		return
+	}
+	if w.history == nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusNotFound,
+			Messages:   []string{"Link history is disabled"},
+			Log:        "link history is disabled",
+		})
+		return
+	}
+	revision := ginctx.PostForm(formInputRevision)
+	id, err := strconv.Atoi(revision)
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusBadRequest,
+			Messages:   []string{"Invalid revision"},
+			Log: fmt.Sprintf(
+				"failed to parse revision %s. err: %v", revision, err,
+			),
+		})
+		return
+	}
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("failed to get org. err: %v", err),
+		})
+		return
+	}
+	if _, ok := w.editable(ginctx, org.Name, key); !ok {
+		return
+	}
+	reqCtx := history.WithEditor(
+		ginctx.Request.Context(), ctx.GetUserEmail(ginctx))
+	err = w.history.Restore(reqCtx, org.Name, key, id)
+	if errors.Is(err, history.ErrRevisionNotFound) {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusNotFound,
+			Messages:   []string{"Revision not found"},
+			Log:        fmt.Sprintf("revision %d of \"%s\" not found", id, key),
+		})
+		return
+	}
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log: fmt.Sprintf(
+				"failed to restore \"%s\" to revision %d. err: %v", key, id, err),
+		})
+		return
+	}
+	ginctx.Redirect(http.StatusMovedPermanently,
+		"/links/edit/"+link.EscapeKey(key))
+}
@@ -4,11 +4,14 @@ import (
 	"fmt"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/haostudio/golinks/internal/auth"
 )
 
 // Register register links web in router.
 func Register(router gin.IRouter, conf Config) {
 	module := New(conf)
+	editor := module.RoleRequired(auth.RoleEditor)
 	router.GET("", module.Links())
 	// Admin pages
 	router.GET(
@@ -17,6 +20,21 @@ func Register(router gin.IRouter, conf Config) {
 	)
 	router.POST(
 		fmt.Sprintf("edit/:%s", module.PathParamLinkKey()),
+		editor,
 		module.HandleEditLinktForm,
 	)
+	router.POST(
+		fmt.Sprintf("edit/:%s/restore", module.PathParamLinkKey()),
+		editor,
+		module.HandleRestoreForm,
+	)
+	router.POST(
+		fmt.Sprintf("edit/:%s/owners", module.PathParamLinkKey()),
+		editor,
+		module.HandleOwnersForm,
+	)
+	if conf.Patterns != nil {
+		router.GET("patterns", module.Patterns())
+		router.POST("patterns", editor, module.HandlePatternsForm)
+	}
 }
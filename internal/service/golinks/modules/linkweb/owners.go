@@ -0,0 +1,150 @@
+package linkweb
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/history"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
+	"github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
+)
+
+// HandleOwnersForm handles the ownership form submission of the edit page.
+// Any user can request the ownership of a link, the owners and the org admins
+// can grant or remove the ownership, and an owner can transfer the ownership.
+func (w *Web) HandleOwnersForm(ginctx *gin.Context) {
+	key := ginctx.Param(w.PathParamLinkKey())
+	action := ginctx.PostForm(formInputAction)
+	owner := strings.TrimSpace(ginctx.PostForm(formInputOwner))
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("failed to get org. err: %v", err),
+		})
+		return
+	}
+	prev, err := w.store.GetLink(ginctx.Request.Context(), org.Name, key)
+	if errors.Is(err, link.ErrNotFound) {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusNotFound,
+			Messages:   []string{"Link not found"},
+			Log:        fmt.Sprintf("link \"%s\" not found", key),
+		})
+		return
+	}
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("failed to get link from store. err: %v", err),
+		})
+		return
+	}
+
+	email := ctx.GetUserEmail(ginctx)
+	manager := prev.IsOwner(email) || ctx.IsAdmin(ginctx)
+	ln := prev
+	ln.Touch(email, time.Now(), &prev)
+	switch {
+	case action == formRequestValue:
+		ln.RequestOwnership(email)
+	case action == formTransferValue && len(owner) != 0:
+		err = ln.TransferOwnership(email, owner)
+	case action == formGrantValue && len(owner) != 0 && manager:
+		ln.AddOwner(owner)
+	case action == formRemoveValue && len(owner) != 0 && manager:
+		ln.RemoveOwner(owner)
+	case action == formGrantValue || action == formRemoveValue:
+		err = link.ErrNotOwner
+	default:
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusBadRequest,
+			Messages:   []string{"Invalid action"},
+			Log:        fmt.Sprintf("invalid action %s", action),
+		})
+		return
+	}
+	if errors.Is(err, link.ErrNotOwner) {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusForbidden,
+			Messages:   []string{"Not the link owner"},
+			Log:        fmt.Sprintf("%s is not the owner of \"%s\"", email, key),
+		})
+		return
+	}
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusBadRequest,
+			Messages:   []string{"Invalid owner"},
+			Log:        fmt.Sprintf("invalid owner %s. err: %v", owner, err),
+		})
+		return
+	}
+
+	reqCtx := history.WithEditor(ginctx.Request.Context(), email)
+	err = w.store.UpdateLinkIf(reqCtx, org.Name, key, prev.Meta.Revision, ln)
+	if errors.Is(err, link.ErrConflict) {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusConflict,
+			Messages: []string{
+				"Link was changed by someone else",
+				"Reload the page and try again",
+			},
+			Log: fmt.Sprintf("conflicted update of \"%s\". err: %v", key, err),
+		})
+		return
+	}
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log: fmt.Sprintf(
+				"failed to update owners of \"%s\" in store. err: %v", key, err),
+		})
+		return
+	}
+	ginctx.Redirect(http.StatusMovedPermanently,
+		"/links/edit/"+link.EscapeKey(key))
+}
+
+// editable returns if the request user can change the link with key in org,
+// and the revision of the link checked, which is 0 if the link doesn't exist.
+// Otherwise, the error page is served and false is returned.
+func (w *Web) editable(ginctx *gin.Context, org, key string) (
+	rev int64, ok bool) {
+	ln, err := w.store.GetLink(ginctx.Request.Context(), org, key)
+	if errors.Is(err, link.ErrNotFound) {
+		return 0, true
+	}
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("failed to get link from store. err: %v", err),
+		})
+		return 0, false
+	}
+	if !ln.Editable(ctx.GetUserEmail(ginctx), ctx.IsAdmin(ginctx)) {
+		w.serveProtected(ginctx, key)
+		return 0, false
+	}
+	return ln.Meta.Revision, true
+}
+
+// serveProtected serves the error page of changing the protected link.
+func (w *Web) serveProtected(ginctx *gin.Context, key string) {
+	w.ServeErr(ginctx, &webbase.Error{
+		StatusCode: http.StatusForbidden,
+		Messages: []string{
+			"Link is protected",
+			"Only the link owners and the org admins can change it",
+		},
+		Log: fmt.Sprintf(
+			"\"%s\" is protected from %s", key, ctx.GetUserEmail(ginctx)),
+	})
+}
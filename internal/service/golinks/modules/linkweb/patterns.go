@@ -0,0 +1,142 @@
+package linkweb
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/haostudio/golinks/internal/link/pattern"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
+	"github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
+)
+
+const (
+	formInputPatternName     = "name"
+	formInputPatternExpr     = "expr"
+	formInputPatternTarget   = "target"
+	formInputPatternPriority = "priority"
+)
+
+// Patterns returns the page for the list of pattern routes.
+// (./web/patterns.html)
+func (w *Web) Patterns() gin.HandlerFunc {
+	return w.Handler(
+		"patterns.html.tmpl",
+		func(ginctx *gin.Context) (interface{}, *webbase.Error) {
+			org, err := ctx.GetOrg(ginctx)
+			if err != nil {
+				return nil, &webbase.Error{
+					StatusCode: http.StatusInternalServerError,
+					Log:        fmt.Sprintf("failed to get org. err: %v", err),
+				}
+			}
+			pageData := NewPatternsPageData(ginctx)
+			pageData.FormInputName = formInputPatternName
+			pageData.FormInputExpr = formInputPatternExpr
+			pageData.FormInputTarget = formInputPatternTarget
+			pageData.FormInputPriority = formInputPatternPriority
+			pageData.FormInputDescription = formInputDescription
+			pageData.FormInputAction = formInputAction
+			pageData.FormSaveValue = formSaveValue
+			pageData.FormDeleteValue = formDeleteValue
+			pageData.Patterns, err = w.patterns.GetPatterns(
+				ginctx.Request.Context(), org.Name)
+			if err != nil {
+				return nil, &webbase.Error{
+					StatusCode: http.StatusInternalServerError,
+					Log: fmt.Sprintf(
+						"failed to get patterns from store. err: %v", err,
+					),
+				}
+			}
+			return pageData, nil
+		},
+	)
+}
+
+// HandlePatternsForm handles the patterns.html form submission.
+func (w *Web) HandlePatternsForm(ginctx *gin.Context) {
+	action := ginctx.PostForm(formInputAction)
+	name := ginctx.PostForm(formInputPatternName)
+	priority := ginctx.PostForm(formInputPatternPriority)
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusInternalServerError,
+			Log:        fmt.Sprintf("failed to get org. err: %v", err),
+		})
+		return
+	}
+	switch action {
+	case formSaveValue:
+		p := pattern.Pattern{
+			Name:        name,
+			Expr:        ginctx.PostForm(formInputPatternExpr),
+			Target:      strings.TrimSpace(ginctx.PostForm(formInputPatternTarget)),
+			Description: ginctx.PostForm(formInputDescription),
+		}
+		if len(priority) != 0 {
+			p.Priority, err = strconv.Atoi(priority)
+			if err != nil {
+				w.ServeErr(ginctx, &webbase.Error{
+					StatusCode: http.StatusBadRequest,
+					Messages:   []string{"Invalid priority"},
+					Log: fmt.Sprintf(
+						"failed to parse priority %s. err: %v", priority, err,
+					),
+				})
+				return
+			}
+		}
+		err = w.patterns.UpdatePattern(ginctx.Request.Context(), org.Name, p)
+		if errors.Is(err, pattern.ErrInvalid) {
+			w.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusBadRequest,
+				Messages:   []string{"Invalid pattern", err.Error()},
+				Log:        fmt.Sprintf("invalid pattern \"%s\". err: %v", name, err),
+			})
+			return
+		}
+		if err != nil {
+			w.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusInternalServerError,
+				Log: fmt.Sprintf(
+					"failed to update pattern \"%s\". err: %v", name, err,
+				),
+			})
+			return
+		}
+	case formDeleteValue:
+		err = w.patterns.DeletePattern(ginctx.Request.Context(), org.Name, name)
+		if errors.Is(err, pattern.ErrNotFound) {
+			w.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusNotFound,
+				Messages:   []string{"Pattern not found"},
+				Log:        fmt.Sprintf("pattern \"%s\" not found", name),
+			})
+			return
+		}
+		if err != nil {
+			w.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusInternalServerError,
+				Log: fmt.Sprintf(
+					"failed to delete pattern \"%s\". err: %v", name, err,
+				),
+			})
+			return
+		}
+	default:
+		w.ServeErr(ginctx, &webbase.Error{
+			StatusCode: http.StatusBadRequest,
+			Messages:   []string{"Invalid action"},
+			Log:        fmt.Sprintf("invalid action %s", action),
+		})
+		return
+	}
+	ginctx.Redirect(http.StatusMovedPermanently, "/links/patterns")
+}
@@ -0,0 +1,134 @@
+package patternapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/haostudio/golinks/internal/api/middlewares"
+	"github.com/haostudio/golinks/internal/link/pattern"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
+)
+
+// New returns a new pattern api module.
+func New(conf Config) *Patterns {
+	return &Patterns{
+		router: conf.Router,
+	}
+}
+
+// Patterns defines the pattern module struct.
+type Patterns struct {
+	router *pattern.Router
+}
+
+// patternResponse defines a pattern in the api response.
+type patternResponse struct {
+	Name        string `json:"name"`
+	Expr        string `json:"expr"`
+	Target      string `json:"target"`
+	Priority    int    `json:"priority"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathParamPatternName returns the pattern_name path parameter.
+func (p *Patterns) PathParamPatternName() string {
+	return "pattern_name"
+}
+
+// GetPatterns returns the patterns in the order of evaluation.
+func (p *Patterns) GetPatterns(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	patterns, err := p.router.GetPatterns(ginctx.Request.Context(), org.Name)
+	if err != nil {
+		logger.Error("failed to get patterns from store. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	res := make([]patternResponse, 0, len(patterns))
+	for _, pt := range patterns {
+		res = append(res, patternResponse{
+			Name:        pt.Name,
+			Expr:        pt.Expr,
+			Target:      pt.Target,
+			Priority:    pt.Priority,
+			Description: pt.Description,
+		})
+	}
+	ginctx.JSON(http.StatusOK, res)
+}
+
+// UpdatePattern creates or replaces the pattern.
+func (p *Patterns) UpdatePattern(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	name := ginctx.Param(p.PathParamPatternName())
+
+	var req struct {
+		Expr        string `json:"expr"`
+		Target      string `json:"target"`
+		Priority    int    `json:"priority"`
+		Description string `json:"description"`
+	}
+	err := ginctx.BindJSON(&req)
+	if err != nil {
+		logger.Error("failed to bind json. err: %v", err)
+		return
+	}
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	err = p.router.UpdatePattern(ginctx.Request.Context(), org.Name,
+		pattern.Pattern{
+			Name:        name,
+			Expr:        req.Expr,
+			Target:      req.Target,
+			Priority:    req.Priority,
+			Description: req.Description,
+		})
+	if errors.Is(err, pattern.ErrInvalid) {
+		logger.Error("invalid pattern \"%s\". err: %v", name, err)
+		ginctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		logger.Error("failed to update \"%s\" to store. err: %v", name, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	ginctx.Status(http.StatusOK)
+}
+
+// DeletePattern deletes the pattern.
+func (p *Patterns) DeletePattern(ginctx *gin.Context) {
+	logger := middlewares.GetLogger(ginctx)
+	name := ginctx.Param(p.PathParamPatternName())
+
+	org, err := ctx.GetOrg(ginctx)
+	if err != nil {
+		logger.Error("failed to get org. err: %v", err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	err = p.router.DeletePattern(ginctx.Request.Context(), org.Name, name)
+	if errors.Is(err, pattern.ErrNotFound) {
+		ginctx.Status(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("failed to delete \"%s\" from store. err: %v", name, err)
+		ginctx.Status(http.StatusInternalServerError)
+		return
+	}
+	ginctx.Status(http.StatusOK)
+}
@@ -0,0 +1,34 @@
+package patternapi
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/haostudio/golinks/internal/auth"
+	"github.com/haostudio/golinks/internal/link/pattern"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
+)
+
+// Config defines the pattern api config.
+type Config struct {
+	Router *pattern.Router
+}
+
+// Register register api in router.
+func Register(router gin.IRouter, conf Config) {
+	module := New(conf)
+	editor := ctx.RoleSimple403(auth.RoleEditor)
+	router.GET("", module.GetPatterns)
+	// Editor functions
+	router.PUT(
+		fmt.Sprintf(":%s", module.PathParamPatternName()),
+		editor,
+		module.UpdatePattern,
+	)
+	router.DELETE(
+		fmt.Sprintf(":%s", module.PathParamPatternName()),
+		editor,
+		module.DeletePattern,
+	)
+}
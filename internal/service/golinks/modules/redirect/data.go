@@ -0,0 +1,88 @@
+package redirect
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
+)
+
+// ExpiredPageData defines the data for expired.html template.
+type ExpiredPageData struct {
+	webbase.Data
+	Key         string
+	Description string
+	ExpiresAt   time.Time
+}
+
+// PathKey returns the key escaped as a url path segment.
+func (d ExpiredPageData) PathKey() string {
+	return link.EscapeKey(d.Key)
+}
+
+// NewExpiredPageData returns expired page data.
+func NewExpiredPageData(ctx *gin.Context) ExpiredPageData {
+	return ExpiredPageData{
+		Data: webbase.NewData("Golinks - Expired link", ctx),
+	}
+}
+
+// maxSuggestions is the maximum number of links suggested on a missing link.
+const maxSuggestions = 5
+
+// Suggestion defines a suggested link for notfound.html template.
+type Suggestion struct {
+	Key         string
+	Target      string
+	Description string
+}
+
+// PathKey returns the key escaped as a url path segment.
+func (s Suggestion) PathKey() string {
+	return link.EscapeKey(s.Key)
+}
+
+// NotFoundPageData defines the data for notfound.html template.
+type NotFoundPageData struct {
+	webbase.Data
+	Key         string
+	Suggestions []Suggestion
+}
+
+// PathKey returns the key escaped as a url path segment.
+func (d NotFoundPageData) PathKey() string {
+	return link.EscapeKey(d.Key)
+}
+
+func newNotFoundPageData(ginctx *gin.Context, conf Config, org string,
+	key string) (data NotFoundPageData, err error) {
+	data.Data = webbase.NewData("Golinks - Link not found", ginctx)
+	data.Key = key
+	keys, err := conf.Suggestions.Suggest(
+		ginctx.Request.Context(), org, key, maxSuggestions)
+	if err != nil {
+		return
+	}
+	for _, k := range keys {
+		ln, err := conf.Store.GetLink(ginctx.Request.Context(), org, k)
+		if errors.Is(err, link.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return data, err
+		}
+		target, err := ln.Payload()
+		if err != nil {
+			return data, err
+		}
+		data.Suggestions = append(data.Suggestions, Suggestion{
+			Key:         k,
+			Target:      target,
+			Description: ln.Meta.Description,
+		})
+	}
+	return
+}
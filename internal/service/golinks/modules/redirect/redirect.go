@@ -3,31 +3,52 @@ package redirect
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/haostudio/golinks/internal/analytics"
 	"github.com/haostudio/golinks/internal/api/middlewares"
 	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/pattern"
+	"github.com/haostudio/golinks/internal/link/share"
+	"github.com/haostudio/golinks/internal/link/suggest"
 	"github.com/haostudio/golinks/internal/service/golinks/ctx"
 	"github.com/haostudio/golinks/internal/service/golinks/modules/webbase"
 )
 
+// Response headers of the org and key of the resolved link.
+const (
+	HeaderLinkOrg = "X-Golinks-Org"
+	HeaderLinkKey = "X-Golinks-Key"
+)
+
 // Config defines the config struct.
 type Config struct {
-	Traced bool
-	Store  link.Store
+	Traced    bool
+	Store     link.Store
+	Analytics analytics.Recorder // optional
+	Patterns  *pattern.Router    // optional
+	Shares    share.Store        // optional
+	// FallbackOrgs are the orgs to resolve the links missing in the org of the
+	// request, in order.
+	FallbackOrgs []string // optional
+	// Suggestions suggests the similar links on a missing link instead of
+	// redirecting to its edit page.
+	Suggestions *suggest.Index // optional
 }
 
 // Handler redirects requests based on the link.Store.
 func Handler(conf Config) gin.HandlerFunc {
 	web := webbase.NewBase(conf.Traced)
+	resolver := share.NewResolver(conf.Store, conf.Shares, conf.FallbackOrgs...)
 	return func(ginctx *gin.Context) {
 		logger := middlewares.GetLogger(ginctx)
 
 		path := ginctx.Request.URL.Path
-		key, param := link.Parse(path)
-		logger.Debug("key=%s param=%s", key, param)
 
 		org, err := ctx.GetOrg(ginctx)
 		if err != nil {
@@ -37,10 +58,51 @@ func Handler(conf Config) gin.HandlerFunc {
 			})
 			return
 		}
-		ln, err := conf.Store.GetLink(ginctx, org.Name, key)
+		resolved, err := resolver.Lookup(ginctx, org.Name, path)
+		key, param, ln := resolved.Key, resolved.Param, resolved.Link
+		logger.Debug("org=%s key=%s param=%s", resolved.Org, key, param)
+		if errors.Is(err, link.ErrNotFound) && conf.Patterns != nil {
+			// fall back to the pattern routes
+			var (
+				target string
+				p      pattern.Pattern
+				ok     bool
+			)
+			target, p, ok, err = conf.Patterns.Match(
+				ginctx.Request.Context(), org.Name, strings.Trim(path, "/"))
+			if err != nil {
+				logger.Error("failed to match patterns. err: %v", err)
+				web.ServeErr(ginctx, &webbase.Error{
+					StatusCode: http.StatusInternalServerError,
+				})
+				return
+			}
+			if ok {
+				logger.Debug("redirect %s to %s by pattern %s", path, target, p.Name)
+				ginctx.Redirect(http.StatusTemporaryRedirect, target)
+				return
+			}
+			err = link.ErrNotFound
+		}
+		if errors.Is(err, link.ErrNotFound) && conf.Suggestions != nil {
+			var data NotFoundPageData
+			data, err = newNotFoundPageData(ginctx, conf, org.Name, key)
+			if err != nil {
+				logger.Error("failed to get suggestions. err: %v", err)
+				web.ServeErr(ginctx, &webbase.Error{
+					StatusCode: http.StatusInternalServerError,
+				})
+				return
+			}
+			if len(data.Suggestions) > 0 {
+				web.Serve(ginctx, http.StatusNotFound, "notfound.html.tmpl", data)
+				return
+			}
+			err = link.ErrNotFound
+		}
 		if errors.Is(err, link.ErrNotFound) {
 			ginctx.Redirect(
-				http.StatusTemporaryRedirect, fmt.Sprintf("/links/edit/%s", key))
+				http.StatusTemporaryRedirect, "/links/edit/"+link.EscapeKey(key))
 			return
 		}
 		if err != nil {
@@ -50,10 +112,71 @@ func Handler(conf Config) gin.HandlerFunc {
 			})
 			return
 		}
+		// follow the aliases to the canonical link
+		alias := key
+		key, ln, err = link.Canonical(ginctx, conf.Store, resolved.Org, key, ln)
+		if errors.Is(err, link.ErrNotFound) && resolved.Org != org.Name {
+			logger.Debug("canonical link %s of %s not found in %s",
+				key, alias, resolved.Org)
+			web.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusNotFound,
+				Messages:   []string{"Shared link not found"},
+			})
+			return
+		}
+		if errors.Is(err, link.ErrNotFound) {
+			logger.Debug("canonical link %s of %s not found", key, alias)
+			ginctx.Redirect(
+				http.StatusTemporaryRedirect, "/links/edit/"+link.EscapeKey(key))
+			return
+		}
+		if errors.Is(err, link.ErrAliasLoop) {
+			logger.Error("failed to resolve alias %s. err: %v", alias, err)
+			web.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusLoopDetected,
+				Messages:   []string{"Alias loop"},
+			})
+			return
+		}
+		if err != nil {
+			logger.Error("failed to get canonical link of %s. err: %v", alias, err)
+			web.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusInternalServerError,
+			})
+			return
+		}
 		// Link Found!
-		target, err := ln.GetRedirectLink(param)
-		if errors.Is(err, link.ErrInvalidParams) {
-			logger.Error("invalid param")
+		if ln.Expired(time.Now()) {
+			logger.Debug("link %s expired at %s", key, ln.Meta.ExpiresAt)
+			data := NewExpiredPageData(ginctx)
+			data.Key = key
+			data.Description = ln.Meta.Description
+			data.ExpiresAt = ln.Meta.ExpiresAt
+			web.Serve(ginctx, http.StatusGone, "expired.html.tmpl", data)
+			return
+		}
+		target, err := ln.Resolve(link.Request{
+			Param:     param,
+			Query:     ginctx.Request.URL.Query(),
+			Header:    ginctx.Request.Header,
+			UserEmail: ctx.GetUserEmail(ginctx),
+			ClientIP:  net.ParseIP(ginctx.ClientIP()),
+		})
+		if errors.Is(err, link.ErrNotActive) {
+			logger.Debug("link %s is not active yet", key)
+			web.ServeErr(ginctx, &webbase.Error{
+				StatusCode: http.StatusNotFound,
+				Messages:   []string{"Link is not active yet"},
+			})
+			return
+		} else if errors.Is(err, link.ErrInvalidParams) {
+			logger.Error("invalid param. err: %v", err)
+			messages := []string{"Invalid params"}
+			var missing *link.MissingParamError
+			if errors.As(err, &missing) {
+				messages = append(messages,
+					fmt.Sprintf("Missing parameter %s", missing.Name))
+			}
 			desc, err := ln.Description()
 			if err != nil {
 				logger.Error("failed to get link desc. err: %v", err)
@@ -64,7 +187,7 @@ func Handler(conf Config) gin.HandlerFunc {
 			}
 			web.ServeErr(ginctx, &webbase.Error{
 				StatusCode: http.StatusBadRequest,
-				Messages:   []string{"Invalid params", desc},
+				Messages:   append(messages, desc),
 			})
 			return
 		} else if err != nil {
@@ -74,7 +197,17 @@ func Handler(conf Config) gin.HandlerFunc {
 			})
 			return
 		}
+		if conf.Analytics != nil {
+			// the recorder should not block the redirection.
+			err = conf.Analytics.Record(
+				ginctx.Request.Context(), resolved.Org, key, time.Now())
+			if err != nil {
+				logger.Warn("failed to record hit. err: %v", err)
+			}
+		}
 		logger.Debug("redirect %s to %s", path, target)
+		ginctx.Header(HeaderLinkOrg, resolved.Org)
+		ginctx.Header(HeaderLinkKey, key)
 		ginctx.Redirect(http.StatusTemporaryRedirect, target)
 	}
 }
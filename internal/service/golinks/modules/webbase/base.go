@@ -1,6 +1,8 @@
 package webbase
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -10,6 +12,8 @@ import (
 
 	"github.com/haostudio/golinks/internal/api/middlewares"
 	"github.com/haostudio/golinks/internal/api/web"
+	"github.com/haostudio/golinks/internal/auth"
+	"github.com/haostudio/golinks/internal/service/golinks/ctx"
 )
 
 // Base defines the web handler module.
@@ -77,6 +81,26 @@ func (w *Base) handlerWithStatusCode(tmpl string, statusCode int,
 	}
 }
 
+// RoleRequired returns the middleware that serves the error page if the
+// request user doesn't hold the required role.
+func (w *Base) RoleRequired(required auth.Role) gin.HandlerFunc {
+	return ctx.RoleRequired(required, func(ginctx *gin.Context, err error) {
+		if errors.Is(err, ctx.ErrForbidden) {
+			w.ServeErr(ginctx, &Error{
+				StatusCode: http.StatusForbidden,
+				Messages:   []string{"Permission denied"},
+				Log:        fmt.Sprintf("%s role required", required),
+			})
+		} else {
+			w.ServeErr(ginctx, &Error{
+				StatusCode: http.StatusInternalServerError,
+				Log:        fmt.Sprintf("failed to get role. %v", err),
+			})
+		}
+		ginctx.Abort()
+	})
+}
+
 // ServeErr serves a html err page.
 func (w *Base) ServeErr(ctx *gin.Context, err *Error) {
 	if w.Traced {
@@ -3,6 +3,7 @@ package webbase
 import (
 	"github.com/gin-gonic/gin"
 
+	"github.com/haostudio/golinks/internal/auth"
 	"github.com/haostudio/golinks/internal/service/golinks/ctx"
 	"github.com/haostudio/golinks/internal/version"
 )
@@ -13,6 +14,8 @@ type Data struct {
 	BinVersion string
 	Ctx        struct {
 		Org, User   string
+		Role        auth.Role
+		Orgs        []string
 		LoggedIn    bool
 		AuthEnabled bool
 	}
@@ -31,8 +34,13 @@ func NewData(title string, ginctx *gin.Context) Data {
 	user, err := ctx.GetUser(ginctx)
 	if err == nil {
 		data.Ctx.User = user.Email
+		data.Ctx.Orgs = user.Orgs
 		data.Ctx.LoggedIn = true
 	}
+	role, err := ctx.GetRole(ginctx)
+	if err == nil {
+		data.Ctx.Role = role
+	}
 	data.Ctx.AuthEnabled = ctx.IsAuthEnabled(ginctx)
 	return data
 }
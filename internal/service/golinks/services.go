@@ -11,9 +11,15 @@ import (
 	"github.com/popodidi/log"
 	"github.com/soheilhy/cmux"
 
+	"github.com/haostudio/golinks/internal/analytics"
 	"github.com/haostudio/golinks/internal/api/middlewares"
 	"github.com/haostudio/golinks/internal/auth"
+	"github.com/haostudio/golinks/internal/auth/oidc"
 	"github.com/haostudio/golinks/internal/link"
+	"github.com/haostudio/golinks/internal/link/history"
+	"github.com/haostudio/golinks/internal/link/pattern"
+	"github.com/haostudio/golinks/internal/link/share"
+	"github.com/haostudio/golinks/internal/link/suggest"
 	"github.com/haostudio/golinks/internal/service"
 	"github.com/haostudio/golinks/internal/service/golinks/ctx"
 	"github.com/haostudio/golinks/internal/service/golinks/modules/authapi"
@@ -21,6 +27,7 @@ import (
 	"github.com/haostudio/golinks/internal/service/golinks/modules/landingweb"
 	"github.com/haostudio/golinks/internal/service/golinks/modules/linkapi"
 	"github.com/haostudio/golinks/internal/service/golinks/modules/linkweb"
+	"github.com/haostudio/golinks/internal/service/golinks/modules/patternapi"
 	"github.com/haostudio/golinks/internal/service/golinks/modules/redirect"
 )
 
@@ -34,8 +41,21 @@ type Config struct {
 		Enabled    bool
 		DefaultOrg string        // default org for Auth.Enabled = false
 		Manager    *auth.Manager // provider for Auth.Enabled = true
+		SSO        *oidc.Client  // optional single sign-on provider
 	}
 	LinkStore link.Store
+	// LinkHistory should wrap LinkStore so that the changes are recorded.
+	LinkHistory history.Store   // optional
+	Analytics   analytics.Store // optional
+	// Patterns routes the paths no link matches.
+	Patterns *pattern.Router // optional
+	// Shares are the links shared with the other orgs.
+	Shares share.Store // optional
+	// FallbackOrgs are the orgs to resolve the links missing in an org, e.g.
+	// a global org of the company-wide links.
+	FallbackOrgs []string // optional
+	// Suggestions should wrap LinkStore so that the key index is up to date.
+	Suggestions *suggest.Index // optional
 }
 
 // New returns a golinks http service.
@@ -78,11 +98,37 @@ func (s *svc) buildRouter() http.Handler {
 	router.HandleMethodNotAllowed = false
 	router.ForwardedByClientIP = true
 	router.AppEngine = false
-	router.UseRawPath = false
+	// match the escaped path so that link keys with "/" (%2F) are a single
+	// path parameter.
+	router.UseRawPath = true
 	router.UnescapePathValues = true
 
 	// Log server config
 	logger.Info("server link store: %s", s.LinkStore)
+	if s.Analytics != nil {
+		logger.Info("server analytics store: %s", s.Analytics)
+	} else {
+		logger.Warn("server analytics disabled")
+	}
+	if s.LinkHistory == nil {
+		logger.Warn("server link history disabled")
+	}
+	if s.Patterns != nil {
+		logger.Info("server pattern store: %s", s.Patterns)
+	} else {
+		logger.Warn("server pattern routes disabled")
+	}
+	if s.Shares != nil {
+		logger.Info("server share store: %s", s.Shares)
+	} else {
+		logger.Warn("server link sharing disabled")
+	}
+	if s.Suggestions == nil {
+		logger.Warn("server link suggestions disabled")
+	}
+	if len(s.FallbackOrgs) > 0 {
+		logger.Info("server fallback orgs: %s", strings.Join(s.FallbackOrgs, ", "))
+	}
 	if s.Auth.Enabled {
 		logger.Info("server auth provider: %s", s.Auth.Manager)
 	} else {
@@ -135,6 +181,7 @@ func (s *svc) buildRouter() http.Handler {
 			Traced:     s.Traced,
 			Manager:    s.Auth.Manager,
 			PathPrefix: "auth",
+			SSO:        s.Auth.SSO,
 		})
 		authWebMiddleware = authweb.AuthRequired("/auth")
 		authAPIMiddleware = ctx.AuthSimple401
@@ -147,14 +194,37 @@ func (s *svc) buildRouter() http.Handler {
 		lnGroup.Use(authweb.OrgRequired("/auth"))
 	}
 	linkweb.Register(lnGroup, linkweb.Config{
-		Store:  s.LinkStore,
-		Traced: s.Traced,
+		Store:        s.LinkStore,
+		Analytics:    s.Analytics,
+		History:      s.LinkHistory,
+		Patterns:     s.Patterns,
+		Shares:       s.Shares,
+		FallbackOrgs: s.FallbackOrgs,
+		Traced:       s.Traced,
 	})
 
 	// Link api module
 	lnAPIGroup := router.Group("api/links")
 	lnAPIGroup.Use(authAPIMiddleware)
-	linkapi.Register(lnAPIGroup, s.LinkStore)
+	lnAPIConfig := linkapi.Config{
+		Store:     s.LinkStore,
+		Analytics: s.Analytics,
+		History:   s.LinkHistory,
+		Shares:    s.Shares,
+	}
+	if s.Auth.Enabled {
+		lnAPIConfig.Manager = s.Auth.Manager
+	}
+	linkapi.Register(lnAPIGroup, lnAPIConfig)
+
+	// Pattern api module
+	if s.Patterns != nil {
+		patternAPIGroup := router.Group("api/patterns")
+		patternAPIGroup.Use(authAPIMiddleware)
+		patternapi.Register(patternAPIGroup, patternapi.Config{
+			Router: s.Patterns,
+		})
+	}
 
 	// Auth module
 	if s.Auth.Enabled {
@@ -175,8 +245,13 @@ func (s *svc) buildRouter() http.Handler {
 	}
 	noRoute = append(noRoute,
 		redirect.Handler(redirect.Config{
-			Traced: s.Traced,
-			Store:  s.LinkStore,
+			Traced:       s.Traced,
+			Store:        s.LinkStore,
+			Analytics:    s.Analytics,
+			Patterns:     s.Patterns,
+			Shares:       s.Shares,
+			FallbackOrgs: s.FallbackOrgs,
+			Suggestions:  s.Suggestions,
 		}),
 	)
 	router.NoRoute(noRoute...)